@@ -1,6 +1,6 @@
 module github.com/zc2638/go-standard
 
-go 1.12
+go 1.21
 
 require (
 	github.com/go-sql-driver/mysql v1.4.1
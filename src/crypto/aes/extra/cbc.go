@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"crypto/aes"
 	"crypto/cipher"
+	"errors"
 )
 
 func CBCEncrypt(originText, key, iv []byte) ([]byte, error) {
@@ -13,14 +14,32 @@ func CBCEncrypt(originText, key, iv []byte) ([]byte, error) {
 	if err != nil {
 		return nil, err
 	}
+	return CBCEncryptWithBlock(block, originText, iv)
+}
+
+func CBCDecrypt(cipherText, key, iv []byte) ([]byte, error) {
+
+	// 创建一个cipher.Block。参数key为密钥，长度只能是16、24、32字节，用以选择AES-128、AES-192、AES-256
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return CBCDecryptWithBlock(block, cipherText, iv)
+}
+
+// 使用任意分组密码(如SM4、DES)进行CBC加密
+func CBCEncryptWithBlock(block cipher.Block, originText, iv []byte) ([]byte, error) {
 
 	// 返回加密字节块的大小
 	blockSize := block.BlockSize()
+	if len(iv) != blockSize {
+		return nil, errors.New("iv length must equal block size")
+	}
 
-	// PKCS5填充需加密内容
-	originText = PKCS5Padding(originText, blockSize)
+	// PKCS5填充需加密内容，填充到新的切片，不修改调用方的originText
+	originText = PKCS5Padding(append([]byte(nil), originText...), blockSize)
 
-	// 返回一个密码分组链接模式的、底层用Block加密的cipher.BlockMode，初始向量iv的长度必须等于Block的块尺寸(Block块尺寸等于密钥尺寸)
+	// 返回一个密码分组链接模式的、底层用Block加密的cipher.BlockMode，初始向量iv的长度必须等于Block的块尺寸
 	blockMode := cipher.NewCBCEncrypter(block, iv)
 
 	// 根据 需加密内容[]byte长度,初始化一个新的byte数组，返回byte数组内存地址
@@ -32,12 +51,17 @@ func CBCEncrypt(originText, key, iv []byte) ([]byte, error) {
 	return cipherText, nil
 }
 
-func CBCDecrypt(cipherText, key, iv []byte) ([]byte, error) {
+// 使用任意分组密码(如SM4、DES)进行CBC解密
+func CBCDecryptWithBlock(block cipher.Block, cipherText, iv []byte) ([]byte, error) {
 
-	// 创建一个cipher.Block。参数key为密钥，长度只能是16、24、32字节，用以选择AES-128、AES-192、AES-256
-	block, err := aes.NewCipher(key)
-	if err != nil {
-		return nil, err
+	blockSize := block.BlockSize()
+	if len(iv) != blockSize {
+		return nil, errors.New("iv length must equal block size")
+	}
+
+	// 密文长度必须是块大小的整数倍，否则CryptBlocks会panic
+	if len(cipherText) == 0 || len(cipherText)%blockSize != 0 {
+		return nil, errors.New("cipherText is not a multiple of the block size")
 	}
 
 	// 返回一个密码分组链接模式的、底层用b解密的cipher.BlockMode，初始向量iv必须和加密时使用的iv相同
@@ -50,8 +74,7 @@ func CBCDecrypt(cipherText, key, iv []byte) ([]byte, error) {
 	blockMode.CryptBlocks(originText, cipherText)
 
 	// PKCS5反填充解密内容
-	originText = PKCS5UnPadding(originText)
-	return originText, nil
+	return PKCS5UnPadding(originText, blockSize)
 }
 
 func PKCS5Padding(cipherText []byte, blockSize int) []byte {
@@ -66,12 +89,25 @@ func PKCS5Padding(cipherText []byte, blockSize int) []byte {
 	return append(cipherText, padText...)
 }
 
-func PKCS5UnPadding(originText []byte) []byte {
+// 密钥错误或密文损坏时填充内容不合法，返回错误
+func PKCS5UnPadding(originText []byte, blockSize int) ([]byte, error) {
 
 	// 获取 解密内容长度
 	length := len(originText)
+	if length == 0 || length%blockSize != 0 {
+		return nil, errors.New("invalid padding")
+	}
 	// 获取反填充长度(只获取最后个byte当做长度，因为填充的时候是重复按照长度填充的)
 	unPadding := int(originText[length-1])
+	if unPadding == 0 || unPadding > blockSize {
+		return nil, errors.New("invalid padding")
+	}
+	// 填充的每个字节都必须等于填充长度
+	for _, b := range originText[length-unPadding:] {
+		if int(b) != unPadding {
+			return nil, errors.New("invalid padding")
+		}
+	}
 	// 截取解密内容中的原文内容
-	return originText[:(length - unPadding)]
-}
\ No newline at end of file
+	return originText[:(length - unPadding)], nil
+}
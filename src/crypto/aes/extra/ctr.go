@@ -3,6 +3,7 @@ package extra
 import (
 	"crypto/aes"
 	"crypto/cipher"
+	"errors"
 )
 
 func CTREncrypt(originText, key, iv []byte) ([]byte, error) {
@@ -12,36 +13,73 @@ func CTREncrypt(originText, key, iv []byte) ([]byte, error) {
 	if err != nil {
 		return nil, err
 	}
+	return CTREncryptWithBlock(block, originText, iv)
+}
+
+func CTRDecrypt(cipherText, key, iv []byte) ([]byte, error) {
+
+	// 创建一个cipher.Block。参数key为密钥，长度只能是16、24、32字节，用以选择AES-128、AES-192、AES-256
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return CTRDecryptWithBlock(block, cipherText, iv)
+}
+
+// 使用任意分组密码进行CTR加密，返回 iv || 密文
+func CTREncryptWithBlock(block cipher.Block, originText, iv []byte) ([]byte, error) {
 
-	// 根据 需加密内容[]byte长度,初始化一个新的byte数组，返回byte数组内存地址
-	cipherText := make([]byte, aes.BlockSize+len(originText))
+	cipherText, err := prefixIV(block, originText, iv)
+	if err != nil {
+		return nil, err
+	}
 
 	// 返回一个计数器模式的、底层采用block生成key流的cipher.Stream，初始向量iv的长度必须等于block的块尺寸
 	stream := cipher.NewCTR(block, iv)
 
 	// 从加密器的key流和src中依次取出字节二者xor后写入dst，src和dst可指向同一内存地址
-	// cipherText[:aes.BlockSize]为iv值，所以只写入cipherText后面部分
-	stream.XORKeyStream(cipherText[aes.BlockSize:], originText)
+	// cipherText[:BlockSize]为iv值，所以只写入cipherText后面部分
+	stream.XORKeyStream(cipherText[len(iv):], originText)
 
 	return cipherText, nil
 }
 
-func CTRDecrypt(cipherText, key, iv []byte) ([]byte, error) {
+// 使用任意分组密码进行CTR解密，cipherText为 iv || 密文
+func CTRDecryptWithBlock(block cipher.Block, cipherText, iv []byte) ([]byte, error) {
 
-	// 创建一个cipher.Block。参数key为密钥，长度只能是16、24、32字节，用以选择AES-128、AES-192、AES-256
-	block, err := aes.NewCipher(key)
+	originText, err := stripIV(block, cipherText, iv)
 	if err != nil {
 		return nil, err
 	}
 
-	// 只使用cipherText除去iv部分
-	cipherText = cipherText[aes.BlockSize:]
-
 	// 返回一个计数器模式的、底层采用block生成key流的cipher.Stream，初始向量iv的长度必须等于block的块尺寸
 	stream := cipher.NewCTR(block, iv)
 
-	// 从加密器的key流和src中依次取出字节二者xor后写入dst，src和dst可指向同一内存地址
-	stream.XORKeyStream(cipherText, cipherText)
+	// 从加密器的key流和src中依次取出字节二者xor后写入dst，解密到新的切片，不修改调用方的cipherText
+	stream.XORKeyStream(originText, cipherText[len(iv):])
+
+	return originText, nil
+}
 
+// 分配 iv || 密文 的空间并写入iv
+func prefixIV(block cipher.Block, originText, iv []byte) ([]byte, error) {
+
+	if len(iv) != block.BlockSize() {
+		return nil, errors.New("iv length must equal block size")
+	}
+	cipherText := make([]byte, len(iv)+len(originText))
+	copy(cipherText, iv)
 	return cipherText, nil
 }
+
+// 校验密文长度，返回存放明文的新切片
+func stripIV(block cipher.Block, cipherText, iv []byte) ([]byte, error) {
+
+	if len(iv) != block.BlockSize() {
+		return nil, errors.New("iv length must equal block size")
+	}
+	if len(cipherText) < len(iv) {
+		return nil, errors.New("cipherText too short")
+	}
+	return make([]byte, len(cipherText)-len(iv)), nil
+}
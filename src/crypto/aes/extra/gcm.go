@@ -3,7 +3,7 @@ package extra
 import (
 	"crypto/aes"
 	"crypto/cipher"
-	"log"
+	"errors"
 )
 
 func GCMEncrypt(originText, key, nonce []byte) ([]byte, error) {
@@ -11,14 +11,32 @@ func GCMEncrypt(originText, key, nonce []byte) ([]byte, error) {
 	// 创建一个cipher.Block。参数key为密钥，长度只能是16、24、32字节，用以选择AES-128、AES-192、AES-256
 	block, err := aes.NewCipher(key)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
+	return GCMEncryptWithBlock(block, originText, nonce)
+}
+
+func GCMDecrypt(cipherText, key, nonce []byte) ([]byte, error) {
+
+	// 创建一个cipher.Block。参数key为密钥，长度只能是16、24、32字节，用以选择AES-128、AES-192、AES-256
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return GCMDecryptWithBlock(block, cipherText, nonce)
+}
+
+// 使用任意128位分组密码(如SM4)进行GCM加密
+func GCMEncryptWithBlock(block cipher.Block, originText, nonce []byte) ([]byte, error) {
 
 	// 函数用迦洛瓦计数器模式包装提供的128位Block接口，并返回cipher.AEAD
 	g, err := cipher.NewGCM(block)
 	if err != nil {
 		return nil, err
 	}
+	if len(nonce) != g.NonceSize() {
+		return nil, errors.New("incorrect nonce length")
+	}
 
 	// 返回加密结果。认证附加的additionalData，将加密结果添加到dst生成新的加密结果，nonce的长度必须是NonceSize()字节，且对给定的key和时间都是独一无二的
 	cipherText := g.Seal(nil, nonce, originText, nil)
@@ -26,20 +44,18 @@ func GCMEncrypt(originText, key, nonce []byte) ([]byte, error) {
 	return cipherText, nil
 }
 
-func GCMDecrypt(cipherText, key, nonce []byte) ([]byte, error) {
-
-	// 创建一个cipher.Block。参数key为密钥，长度只能是16、24、32字节，用以选择AES-128、AES-192、AES-256
-	block, err := aes.NewCipher(key)
-	if err != nil {
-		return nil, err
-	}
+// 使用任意128位分组密码(如SM4)进行GCM解密
+func GCMDecryptWithBlock(block cipher.Block, cipherText, nonce []byte) ([]byte, error) {
 
 	// 函数用迦洛瓦计数器模式包装提供的128位Block接口，并返回cipher.AEAD
-	aesgcm, err := cipher.NewGCM(block)
+	g, err := cipher.NewGCM(block)
 	if err != nil {
 		return nil, err
 	}
+	if len(nonce) != g.NonceSize() {
+		return nil, errors.New("incorrect nonce length")
+	}
 
 	// 返回解密结果。认证附加的additionalData，将解密结果添加到dst生成新的加密结果，nonce的长度必须是NonceSize()字节，nonce和data都必须和加密时使用的相同
-	return aesgcm.Open(nil, nonce, cipherText, nil)
+	return g.Open(nil, nonce, cipherText, nil)
 }
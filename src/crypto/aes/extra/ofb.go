@@ -4,9 +4,9 @@ import (
 	"bytes"
 	"crypto/aes"
 	"crypto/cipher"
+	"errors"
 	"io"
 	"io/ioutil"
-	"log"
 )
 
 func OFBEncrypt(originText, key, iv []byte) ([]byte, error) {
@@ -16,38 +16,52 @@ func OFBEncrypt(originText, key, iv []byte) ([]byte, error) {
 	if err != nil {
 		return nil, err
 	}
+	return OFBEncryptWithBlock(block, originText, iv)
+}
+
+func OFBDecrypt(cipherText, key, iv []byte) ([]byte, error) {
+
+	// 创建一个cipher.Block。参数key为密钥，长度只能是16、24、32字节，用以选择AES-128、AES-192、AES-256
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return OFBDecryptWithBlock(block, cipherText, iv)
+}
 
-	// 根据 需加密内容[]byte长度,初始化一个新的byte数组，返回byte数组内存地址
-	cipherText := make([]byte, aes.BlockSize+len(originText))
+// 使用任意分组密码进行OFB加密，返回 iv || 密文
+func OFBEncryptWithBlock(block cipher.Block, originText, iv []byte) ([]byte, error) {
+
+	cipherText, err := prefixIV(block, originText, iv)
+	if err != nil {
+		return nil, err
+	}
 
 	// 返回一个输出反馈模式的、底层采用b生成key流的cipher.Stream，初始向量iv的长度必须等于b的块尺寸
 	stream := cipher.NewOFB(block, iv)
 
 	// 从加密器的key流和src中依次取出字节二者xor后写入dst，src和dst可指向同一内存地址
-	// cipherText[:aes.BlockSize]为iv值，所以只写入cipherText后面部分
-	stream.XORKeyStream(cipherText[aes.BlockSize:], originText)
+	// cipherText[:BlockSize]为iv值，所以只写入cipherText后面部分
+	stream.XORKeyStream(cipherText[len(iv):], originText)
 
 	return cipherText, nil
 }
 
-func OFBDecrypt(cipherText, key, iv []byte) ([]byte, error) {
+// 使用任意分组密码进行OFB解密，cipherText为 iv || 密文
+func OFBDecryptWithBlock(block cipher.Block, cipherText, iv []byte) ([]byte, error) {
 
-	// 创建一个cipher.Block。参数key为密钥，长度只能是16、24、32字节，用以选择AES-128、AES-192、AES-256
-	block, err := aes.NewCipher(key)
+	originText, err := stripIV(block, cipherText, iv)
 	if err != nil {
 		return nil, err
 	}
 
-	// 只使用cipherText除去iv部分
-	cipherText = cipherText[aes.BlockSize:]
-
 	// 返回一个输出反馈模式的、底层采用b生成key流的cipher.Stream，初始向量iv的长度必须等于b的块尺寸
 	stream := cipher.NewOFB(block, iv)
 
-	// 从加密器的key流和src中依次取出字节二者xor后写入dst，src和dst可指向同一内存地址
-	stream.XORKeyStream(cipherText, cipherText)
+	// 从加密器的key流和src中依次取出字节二者xor后写入dst，解密到新的切片，不修改调用方的cipherText
+	stream.XORKeyStream(originText, cipherText[len(iv):])
 
-	return cipherText, nil
+	return originText, nil
 }
 
 func OFBEncryptStreamReader(originText, key, iv []byte) ([]byte, error) {
@@ -57,6 +71,24 @@ func OFBEncryptStreamReader(originText, key, iv []byte) ([]byte, error) {
 	if err != nil {
 		return nil, err
 	}
+	return OFBEncryptStreamReaderWithBlock(block, originText, iv)
+}
+
+func OFBDecryptStreamWriter(cipherText, key, iv []byte) ([]byte, error) {
+
+	// 创建一个cipher.Block。参数key为密钥，长度只能是16、24、32字节，用以选择AES-128、AES-192、AES-256
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return OFBDecryptStreamWriterWithBlock(block, cipherText, iv)
+}
+
+func OFBEncryptStreamReaderWithBlock(block cipher.Block, originText, iv []byte) ([]byte, error) {
+
+	if len(iv) != block.BlockSize() {
+		return nil, errors.New("iv length must equal block size")
+	}
 
 	// 返回一个输出反馈模式的、底层采用b生成key流的cipher.Stream，初始向量iv的长度必须等于b的块尺寸
 	stream := cipher.NewOFB(block, iv)
@@ -70,12 +102,10 @@ func OFBEncryptStreamReader(originText, key, iv []byte) ([]byte, error) {
 	return ioutil.ReadAll(reader)
 }
 
-func OFBDecryptStreamWriter(cipherText, key, iv []byte) ([]byte, error) {
+func OFBDecryptStreamWriterWithBlock(block cipher.Block, cipherText, iv []byte) ([]byte, error) {
 
-	// 创建一个cipher.Block。参数key为密钥，长度只能是16、24、32字节，用以选择AES-128、AES-192、AES-256
-	block, err := aes.NewCipher(key)
-	if err != nil {
-		return nil, err
+	if len(iv) != block.BlockSize() {
+		return nil, errors.New("iv length must equal block size")
 	}
 
 	// 返回一个输出反馈模式的、底层采用b生成key流的cipher.Stream，初始向量iv的长度必须等于b的块尺寸
@@ -93,7 +123,7 @@ func OFBDecryptStreamWriter(cipherText, key, iv []byte) ([]byte, error) {
 
 	// 把reader内容拷贝到writer, writer会调用write方法写入内容
 	if _, err := io.Copy(writer, bytes.NewReader(cipherText)); err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
 
 	return originText.Bytes(), nil
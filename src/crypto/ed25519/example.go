@@ -0,0 +1,163 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"github.com/zc2638/go-standard/src/crypto/ed25519/extra"
+	"log"
+)
+
+// 实现了RFC 8032定义的Ed25519签名算法
+func main() {
+
+	// 生成密钥、签名、验签
+	SignAndVerify()
+	// PKCS#8/PKIX PEM编码与解析
+	PEM()
+	// OpenSSH密钥格式
+	OpenSSH()
+	// RFC 8032 测试向量校验
+	TestVectors()
+}
+
+func SignAndVerify() {
+
+	// 生成一对公钥/私钥，random为nil时使用crypto/rand
+	pub, priv, err := extra.GenerateKey(rand.Reader)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// 声明签名内容
+	msg := []byte("hello, world")
+
+	// Ed25519直接对原始消息签名，不需要预先hash
+	sig := extra.Sign(priv, msg)
+	fmt.Println("Ed25519签名: ", hex.EncodeToString(sig))
+
+	// 验证签名
+	fmt.Println("Ed25519验签: ", extra.Verify(pub, msg, sig))
+}
+
+func PEM() {
+
+	pub, priv, err := extra.GenerateKey(nil)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// 私钥编码为PKCS#8 "PRIVATE KEY"
+	priPem, err := extra.EncodePrivateKeyPEM(priv)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(string(priPem))
+
+	// 公钥编码为PKIX "PUBLIC KEY"
+	pubPem, err := extra.EncodePublicKeyPEM(pub)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(string(pubPem))
+
+	// 解析PEM
+	priv2, err := extra.BuildEd25519PrivateKey(priPem)
+	if err != nil {
+		log.Fatal(err)
+	}
+	pub2, err := extra.BuildEd25519PublicKey(pubPem)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println("PEM解析: ", bytes.Equal(priv, priv2), bytes.Equal(pub, pub2))
+}
+
+func OpenSSH() {
+
+	pub, priv, err := extra.GenerateKey(nil)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// authorized_keys格式的公钥
+	authorized := extra.MarshalAuthorizedKey(pub, "user@example")
+	fmt.Print(string(authorized))
+
+	// "OPENSSH PRIVATE KEY"格式的私钥，可直接被ssh使用
+	sshPem, err := extra.MarshalOpenSSHPrivateKey(priv, "user@example")
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(string(sshPem))
+
+	// 解析
+	pub2, comment, err := extra.ParseAuthorizedKey(authorized)
+	if err != nil {
+		log.Fatal(err)
+	}
+	priv2, _, err := extra.ParseOpenSSHPrivateKey(sshPem)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println("OpenSSH解析: ", bytes.Equal(pub, pub2), bytes.Equal(priv, priv2), comment)
+}
+
+func TestVectors() {
+
+	// RFC 8032 7.1 TEST 1、TEST 2、TEST 3、TEST SHA(abc)
+	var vectors = []struct {
+		seed, pub, msg, sig string
+	}{
+		{
+			"9d61b19deffd5a60ba844af492ec2cc44449c5697b326919703bac031cae7f60",
+			"d75a980182b10ab7d54bfed3c964073a0ee172f3daa62325af021a68f707511a",
+			"",
+			"e5564300c360ac729086e2cc806e828a84877f1eb8e5d974d873e065224901555fb8821590a33bacc61e39701cf9b46bd25bf5f0595bbe24655141438e7a100b",
+		},
+		{
+			"4ccd089b28ff96da9db6c346ec114e0f5b8a319f35aba624da8cf6ed4fb8a6fb",
+			"3d4017c3e843895a92b70aa74d1b7ebc9c982ccf2ec4968cc0cd55f12af4660c",
+			"72",
+			"92a009a9f0d4cab8720e820b5f642540a2b27b5416503f8fb3762223ebdb69da085ac1e43e15996e458f3613d0f11d8c387b2eaeb4302aeeb00d291612bb0c00",
+		},
+		{
+			"c5aa8df43f9f837bedb7442f31dcb7b166d38535076f094b85ce3a2e0b4458f7",
+			"fc51cd8e6218a1a38da47ed00230f0580816ed13ba3303ac5deb911548908025",
+			"af82",
+			"6291d657deec24024827e69c3abe01a30ce548a284743a445e3680d7db5ac3ac18ff9b538d16f290ae67f760984dc6594a7c15e9716ed28dc027beceea1ec40a",
+		},
+		{
+			"833fe62409237b9d62ec77587520911e9a759cec1d19755b7da901b96dca3d42",
+			"ec172b93ad5e563bf4932c70e1245034c35467ef2efd4d64ebf819683467e2bf",
+			"ddaf35a193617abacc417349ae20413112e6fa4e89a97ea20a9eeee64b55d39a2192992a274fc1a836ba3c23a3feebbd454d4423643ce80e2a9ac94fa54ca49f",
+			"dc2a4459e7369633a52b1bf277839a00201009a3efbf3ecb69bea2186c26b58909351fc9ac90b3ecfdfbc7c66431e0303dca179c138ac17ad9bef1177331a704",
+		},
+	}
+
+	for i, v := range vectors {
+		seed, _ := hex.DecodeString(v.seed)
+		pub, _ := hex.DecodeString(v.pub)
+		msg, _ := hex.DecodeString(v.msg)
+		sig, _ := hex.DecodeString(v.sig)
+
+		// 由种子计算公钥
+		priv := extra.NewKeyFromSeed(seed)
+		if !bytes.Equal(priv[extra.SeedSize:], pub) {
+			log.Fatalf("test %d: public key mismatch", i+1)
+		}
+		// Ed25519签名是确定性的，同一私钥对同一消息的签名相同
+		if !bytes.Equal(extra.Sign(priv, msg), sig) {
+			log.Fatalf("test %d: signature mismatch", i+1)
+		}
+		if !extra.Verify(pub, msg, sig) {
+			log.Fatalf("test %d: verify failed", i+1)
+		}
+		// 篡改消息后验签失败
+		if extra.Verify(pub, append(msg, 0), sig) {
+			log.Fatalf("test %d: verify tampered message", i+1)
+		}
+	}
+	fmt.Println("RFC 8032 测试向量校验通过")
+}
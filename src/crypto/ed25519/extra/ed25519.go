@@ -0,0 +1,47 @@
+package extra
+
+import (
+	"crypto/ed25519"
+	"io"
+)
+
+// 签名运算由标准库crypto/ed25519实现(常量时间)，本包只负责PEM与OpenSSH格式的编码与解析
+const (
+	// 公钥长度
+	PublicKeySize = ed25519.PublicKeySize
+	// 私钥长度，内容为 种子 || 公钥
+	PrivateKeySize = ed25519.PrivateKeySize
+	// 签名长度
+	SignatureSize = ed25519.SignatureSize
+	// 种子长度，RFC 8032中的私钥
+	SeedSize = ed25519.SeedSize
+)
+
+type PublicKey = ed25519.PublicKey
+
+// 实现crypto.Signer接口。Ed25519对原始消息签名，opts必须为crypto.Hash(0)
+type PrivateKey = ed25519.PrivateKey
+
+func GenerateKey(random io.Reader) (PublicKey, PrivateKey, error) {
+
+	// 随机读取32字节种子，random为nil时使用crypto/rand
+	return ed25519.GenerateKey(random)
+}
+
+func NewKeyFromSeed(seed []byte) PrivateKey {
+
+	// RFC 8032 5.1.5: 由32字节种子计算私钥，种子长度错误时panic
+	return ed25519.NewKeyFromSeed(seed)
+}
+
+func Sign(privateKey PrivateKey, message []byte) []byte {
+
+	// RFC 8032 5.1.6，私钥长度错误时panic
+	return ed25519.Sign(privateKey, message)
+}
+
+func Verify(publicKey PublicKey, message, sig []byte) bool {
+
+	// RFC 8032 5.1.7，公钥长度错误时panic
+	return ed25519.Verify(publicKey, message, sig)
+}
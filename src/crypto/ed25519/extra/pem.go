@@ -0,0 +1,106 @@
+package extra
+
+import (
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+)
+
+func MarshalPKCS8PrivateKey(privateKey PrivateKey) ([]byte, error) {
+
+	if len(privateKey) != PrivateKeySize {
+		return nil, errors.New("ed25519: bad private key length")
+	}
+
+	// RFC 8410 7: 由x509编码为PKCS#8，privateKey字段为 OCTET STRING 包裹的32字节种子
+	return x509.MarshalPKCS8PrivateKey(privateKey)
+}
+
+func ParsePKCS8PrivateKey(der []byte) (PrivateKey, error) {
+
+	key, err := x509.ParsePKCS8PrivateKey(der)
+	if err != nil {
+		return nil, err
+	}
+	privateKey, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return nil, errors.New("ed25519: not an Ed25519 private key")
+	}
+	return privateKey, nil
+}
+
+func MarshalPKIXPublicKey(publicKey PublicKey) ([]byte, error) {
+
+	if len(publicKey) != PublicKeySize {
+		return nil, errors.New("ed25519: bad public key length")
+	}
+
+	// RFC 8410 4: 算法标识1.3.101.112，不带参数
+	return x509.MarshalPKIXPublicKey(publicKey)
+}
+
+func ParsePKIXPublicKey(der []byte) (PublicKey, error) {
+
+	key, err := x509.ParsePKIXPublicKey(der)
+	if err != nil {
+		return nil, err
+	}
+	publicKey, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return nil, errors.New("ed25519: not an Ed25519 public key")
+	}
+	return publicKey, nil
+}
+
+func EncodePrivateKeyPEM(privateKey PrivateKey) ([]byte, error) {
+
+	der, err := MarshalPKCS8PrivateKey(privateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	// 返回PEM编码的"PRIVATE KEY"块
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil
+}
+
+func EncodePublicKeyPEM(publicKey PublicKey) ([]byte, error) {
+
+	der, err := MarshalPKIXPublicKey(publicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	// 返回PEM编码的"PUBLIC KEY"块
+	return pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), nil
+}
+
+func BuildEd25519PublicKey(publicKey []byte) (PublicKey, error) {
+
+	// 返回解码得到的pem.Block和剩余未解码的数据。如果未发现PEM数据，返回(nil, data)
+	block, _ := pem.Decode(publicKey)
+	if block == nil {
+		return nil, errors.New("public key error")
+	}
+
+	// 解析一个DER编码的公钥。这些公钥一般在以"BEGIN PUBLIC KEY"出现的PEM块中
+	return ParsePKIXPublicKey(block.Bytes)
+}
+
+func BuildEd25519PrivateKey(privateKey []byte) (PrivateKey, error) {
+
+	// 返回解码得到的pem.Block和剩余未解码的数据。如果未发现PEM数据，返回(nil, data)
+	block, _ := pem.Decode(privateKey)
+	if block == nil {
+		return nil, errors.New("private key error")
+	}
+
+	// OpenSSH格式的私钥
+	if block.Type == "OPENSSH PRIVATE KEY" {
+		key, _, err := parseOpenSSHPrivateKey(block.Bytes)
+		return key, err
+	}
+
+	// 解析一个未加密的PKCS#8私钥
+	return ParsePKCS8PrivateKey(block.Bytes)
+}
@@ -0,0 +1,189 @@
+package extra
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/pem"
+	"errors"
+	"strings"
+)
+
+// OpenSSH密钥格式，参考 OpenSSH 源码中的 PROTOCOL.key 及 RFC 8709
+const (
+	sshKeyType      = "ssh-ed25519"
+	sshPrivateMagic = "openssh-key-v1\x00"
+)
+
+func MarshalAuthorizedKey(publicKey PublicKey, comment string) []byte {
+
+	// authorized_keys格式: 类型 base64(公钥线格式) 注释
+	line := sshKeyType + " " + base64.StdEncoding.EncodeToString(sshPublicKeyBlob(publicKey))
+	if comment != "" {
+		line += " " + comment
+	}
+	return []byte(line + "\n")
+}
+
+func ParseAuthorizedKey(in []byte) (PublicKey, string, error) {
+
+	fields := strings.Fields(string(in))
+	if len(fields) < 2 || fields[0] != sshKeyType {
+		return nil, "", errors.New("ed25519: not an ssh-ed25519 authorized key")
+	}
+	blob, err := base64.StdEncoding.DecodeString(fields[1])
+	if err != nil {
+		return nil, "", err
+	}
+
+	// 线格式: string 类型, string 32字节公钥
+	r := &sshReader{buf: blob}
+	if string(r.readString()) != sshKeyType {
+		return nil, "", errors.New("ed25519: key type mismatch")
+	}
+	pub := r.readString()
+	if r.err != nil || len(pub) != PublicKeySize {
+		return nil, "", errors.New("ed25519: invalid ssh public key")
+	}
+	return PublicKey(pub), strings.Join(fields[2:], " "), nil
+}
+
+func MarshalOpenSSHPrivateKey(privateKey PrivateKey, comment string) ([]byte, error) {
+
+	if len(privateKey) != PrivateKeySize {
+		return nil, errors.New("ed25519: bad private key length")
+	}
+	pub := privateKey[SeedSize:]
+
+	// 两个相同的随机checkint，解密后用于校验口令是否正确(未加密时同样需要)
+	var check [4]byte
+	if _, err := rand.Read(check[:]); err != nil {
+		return nil, err
+	}
+
+	// 私钥段: checkint checkint string类型 string公钥 string(种子||公钥) string注释 填充
+	var priv bytes.Buffer
+	priv.Write(check[:])
+	priv.Write(check[:])
+	writeSSHString(&priv, []byte(sshKeyType))
+	writeSSHString(&priv, pub)
+	writeSSHString(&priv, privateKey)
+	writeSSHString(&priv, []byte(comment))
+	// 未加密时块大小为8，填充内容为 1, 2, 3...
+	for i := byte(1); priv.Len()%8 != 0; i++ {
+		priv.WriteByte(i)
+	}
+
+	// 整体结构: magic string加密算法 stringKDF stringKDF参数 uint32密钥数 string公钥 string私钥段
+	var out bytes.Buffer
+	out.WriteString(sshPrivateMagic)
+	writeSSHString(&out, []byte("none"))
+	writeSSHString(&out, []byte("none"))
+	writeSSHString(&out, nil)
+	binary.Write(&out, binary.BigEndian, uint32(1))
+	writeSSHString(&out, sshPublicKeyBlob(PublicKey(pub)))
+	writeSSHString(&out, priv.Bytes())
+
+	return pem.EncodeToMemory(&pem.Block{Type: "OPENSSH PRIVATE KEY", Bytes: out.Bytes()}), nil
+}
+
+func ParseOpenSSHPrivateKey(in []byte) (PrivateKey, string, error) {
+
+	block, _ := pem.Decode(in)
+	if block == nil || block.Type != "OPENSSH PRIVATE KEY" {
+		return nil, "", errors.New("ed25519: not an OpenSSH private key")
+	}
+	return parseOpenSSHPrivateKey(block.Bytes)
+}
+
+func parseOpenSSHPrivateKey(data []byte) (PrivateKey, string, error) {
+
+	if !bytes.HasPrefix(data, []byte(sshPrivateMagic)) {
+		return nil, "", errors.New("ed25519: invalid OpenSSH key magic")
+	}
+	r := &sshReader{buf: data[len(sshPrivateMagic):]}
+	cipherName := string(r.readString())
+	kdfName := string(r.readString())
+	r.readString()
+	if cipherName != "none" || kdfName != "none" {
+		return nil, "", errors.New("ed25519: encrypted OpenSSH keys are not supported")
+	}
+	if n := r.readUint32(); n != 1 {
+		return nil, "", errors.New("ed25519: OpenSSH file must contain exactly one key")
+	}
+	r.readString()
+	priv := &sshReader{buf: r.readString()}
+	if r.err != nil {
+		return nil, "", r.err
+	}
+
+	if priv.readUint32() != priv.readUint32() {
+		return nil, "", errors.New("ed25519: OpenSSH checkint mismatch")
+	}
+	if string(priv.readString()) != sshKeyType {
+		return nil, "", errors.New("ed25519: not an ssh-ed25519 key")
+	}
+	pub := priv.readString()
+	key := priv.readString()
+	comment := priv.readString()
+	if priv.err != nil {
+		return nil, "", priv.err
+	}
+	if len(pub) != PublicKeySize || len(key) != PrivateKeySize {
+		return nil, "", errors.New("ed25519: invalid OpenSSH key length")
+	}
+
+	// 根据种子重新计算公钥，确保文件中的公私钥匹配
+	pk := NewKeyFromSeed(key[:SeedSize])
+	if !bytes.Equal(pk[SeedSize:], pub) {
+		return nil, "", errors.New("ed25519: OpenSSH public key mismatch")
+	}
+	return pk, string(comment), nil
+}
+
+func sshPublicKeyBlob(publicKey PublicKey) []byte {
+	var buf bytes.Buffer
+	writeSSHString(&buf, []byte(sshKeyType))
+	writeSSHString(&buf, publicKey)
+	return buf.Bytes()
+}
+
+func writeSSHString(buf *bytes.Buffer, s []byte) {
+
+	// SSH线格式中string为 uint32大端长度 || 内容
+	binary.Write(buf, binary.BigEndian, uint32(len(s)))
+	buf.Write(s)
+}
+
+type sshReader struct {
+	buf []byte
+	err error
+}
+
+func (r *sshReader) readUint32() uint32 {
+	if r.err != nil {
+		return 0
+	}
+	if len(r.buf) < 4 {
+		r.err = errors.New("ed25519: truncated ssh data")
+		return 0
+	}
+	v := binary.BigEndian.Uint32(r.buf)
+	r.buf = r.buf[4:]
+	return v
+}
+
+func (r *sshReader) readString() []byte {
+	n := r.readUint32()
+	if r.err != nil {
+		return nil
+	}
+	if uint32(len(r.buf)) < n {
+		r.err = errors.New("ed25519: truncated ssh data")
+		return nil
+	}
+	s := r.buf[:n]
+	r.buf = r.buf[n:]
+	return s
+}
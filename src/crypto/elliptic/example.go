@@ -1,11 +1,16 @@
 package main
 
 import (
+	"bytes"
+	"crypto/ecdsa"
 	"crypto/elliptic"
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
+	"github.com/zc2638/go-standard/src/crypto/elliptic/extra"
 	"log"
+	"math/big"
 )
 
 // 实现了几条覆盖素数有限域的标准椭圆曲线
@@ -21,7 +26,7 @@ func main() {
 	curve := elliptic.P521()
 
 	// 返回一个公钥/私钥对。priv是私钥，而(x,y)是公钥。密钥对是通过提供的随机数读取器来生成的，该io.Reader接口必须返回随机数据
-	priv, x, y, err := elliptic.GenerateKey(elliptic.P256(), rand.Reader)
+	priv, x, y, err := elliptic.GenerateKey(curve, rand.Reader)
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -34,4 +39,79 @@ func main() {
 
 	// 将一个Marshal编码后的点还原；如果出错，x会被设为nil
 	elliptic.Unmarshal(curve, d)
-}
\ No newline at end of file
+
+	// ECDH密钥协商
+	ECDH()
+	// ECIES公钥加密/解密
+	ECIES()
+}
+
+func ECDH() {
+
+	for _, curve := range []elliptic.Curve{elliptic.P256(), elliptic.P384(), elliptic.P521()} {
+
+		// 双方各自生成密钥对
+		alice, err := extra.GenerateKey(curve)
+		if err != nil {
+			log.Fatal(err)
+		}
+		bob, err := extra.GenerateKey(curve)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		// 使用自己的私钥和对方的公钥计算共享密钥
+		s1, err := extra.ECDH(alice, &bob.PublicKey)
+		if err != nil {
+			log.Fatal(err)
+		}
+		s2, err := extra.ECDH(bob, &alice.PublicKey)
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println(curve.Params().Name, "ECDH共享密钥: ", hex.EncodeToString(s1), bytes.Equal(s1, s2))
+
+		// 共享密钥不应直接作为对称密钥使用，需经过KDF派生
+		fmt.Println("HKDF-SHA256派生密钥: ", hex.EncodeToString(extra.HKDF(sha256.New, s1, nil, []byte("example"), 32)))
+	}
+}
+
+func ECIES() {
+
+	// 接收方密钥对，公钥可分发给客户端
+	key, err := extra.GenerateKey(elliptic.P256())
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// 声明一个随意长度的 需加密内容
+	var origin = []byte("need to ecies encode test text")
+
+	// 加密，每次使用新的临时密钥
+	cipherText, err := extra.ECIESEncrypt(&key.PublicKey, origin)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println("ECIES加密内容: ", hex.EncodeToString(cipherText))
+
+	// 解密
+	originText, err := extra.ECIESDecrypt(key, cipherText)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println("ECIES解密内容: ", string(originText))
+
+	// 已知答案: P-256接收方私钥d，临时公钥R与密文C || T
+	// 期望值由OpenSSL独立计算: pkeyutl -derive得到Z，kdf X963KDF(digest:SHA256, info:R)得到K || IV，再以AES-128-GCM加密
+	d, _ := new(big.Int).SetString("885d7f76a8c780748385fe9dd42284d06d866a8bc0a01c5da2edd7295a44aa89", 16)
+	recipient := &ecdsa.PrivateKey{D: d}
+	recipient.Curve = elliptic.P256()
+	recipient.X, recipient.Y = recipient.Curve.ScalarBaseMult(d.Bytes())
+	known, _ := hex.DecodeString("048d3d72ca86af1b5b6cf77567baee0cef5b3ebb1775c41c93af61dce1c79322156a7c3408e2d7ee383ed6715768f912b4e4e51adab1da8a29cd17bee1164780c3" +
+		"3406a7659d7f2470c293aaa5232417c3557d686055452f8d3a1869b848008bbc634d")
+	originText, err = extra.ECIESDecrypt(recipient, known)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println("ECIES已知答案: ", string(originText), string(originText) == "ECIES known answer")
+}
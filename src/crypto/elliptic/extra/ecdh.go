@@ -0,0 +1,105 @@
+package extra
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+)
+
+func GenerateKey(curve elliptic.Curve) (*ecdsa.PrivateKey, error) {
+
+	// 生成一对公钥/私钥，私钥D在[1, N-1]区间内随机选取
+	return ecdsa.GenerateKey(curve, rand.Reader)
+}
+
+func ECDH(privateKey *ecdsa.PrivateKey, publicKey *ecdsa.PublicKey) ([]byte, error) {
+
+	if privateKey == nil || publicKey == nil || privateKey.Curve == nil || publicKey.Curve == nil || privateKey.D == nil {
+		return nil, errors.New("ecdh: nil key")
+	}
+
+	// 双方必须使用同一条曲线
+	curve := privateKey.Curve
+	if curve.Params().Name != publicKey.Curve.Params().Name {
+		return nil, errors.New("ecdh: curve mismatch")
+	}
+
+	// 校验对方公钥是否在曲线上，防止无效曲线攻击。对不在曲线上的点调用ScalarMult会panic
+	if publicKey.X == nil || publicKey.Y == nil || !curve.IsOnCurve(publicKey.X, publicKey.Y) {
+		return nil, errors.New("ecdh: invalid public key")
+	}
+
+	// 计算共享点 S = d * Q，只使用x坐标作为共享密钥(SEC 1 3.3.1 Elliptic Curve Diffie-Hellman Primitive)
+	x, y := curve.ScalarMult(publicKey.X, publicKey.Y, privateKey.D.Bytes())
+	if x.Sign() == 0 && y.Sign() == 0 {
+		return nil, errors.New("ecdh: shared point is infinity")
+	}
+
+	// x坐标按曲线字节长度左侧补0，保证双方得到的共享密钥长度一致
+	return leftPad(x.Bytes(), curveByteSize(curve)), nil
+}
+
+func BuildECPublicKey(publicKey []byte) (*ecdsa.PublicKey, error) {
+
+	// 返回解码得到的pem.Block和剩余未解码的数据。如果未发现PEM数据，返回(nil, data)
+	block, _ := pem.Decode(publicKey)
+	if block == nil {
+		return nil, errors.New("public key error")
+	}
+
+	// 解析一个DER编码的公钥。这些公钥一般在以"BEGIN PUBLIC KEY"出现的PEM块中
+	pubInterface, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	// 指定为ecdsa.PublicKey结构
+	pub, ok := pubInterface.(*ecdsa.PublicKey)
+	if !ok {
+		return nil, errors.New("public key is not an ec key")
+	}
+	return pub, nil
+}
+
+func BuildECPrivateKey(privateKey []byte) (*ecdsa.PrivateKey, error) {
+
+	// 返回解码得到的pem.Block和剩余未解码的数据。如果未发现PEM数据，返回(nil, data)
+	block, _ := pem.Decode(privateKey)
+	if block == nil {
+		return nil, errors.New("private key error")
+	}
+
+	// "EC PRIVATE KEY"为SEC 1格式，其余按PKCS#8解析
+	if block.Type == "EC PRIVATE KEY" {
+		return x509.ParseECPrivateKey(block.Bytes)
+	}
+
+	// 解析一个未加密的PKCS#8私钥
+	priInterface, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	// 指定为ecdsa.PrivateKey结构
+	pri, ok := priInterface.(*ecdsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("private key is not an ec key")
+	}
+	return pri, nil
+}
+
+func curveByteSize(curve elliptic.Curve) int {
+	return (curve.Params().BitSize + 7) / 8
+}
+
+func leftPad(b []byte, size int) []byte {
+	if len(b) >= size {
+		return b
+	}
+	out := make([]byte, size)
+	copy(out[size-len(b):], b)
+	return out
+}
@@ -0,0 +1,115 @@
+package extra
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/sha256"
+	"errors"
+)
+
+// ECIES，采用X9.63-KDF-SHA256与AES-GCM的配置(SEC 1 5.1的KDF与点编码，对称部分使用AEAD)
+// 与Apple Security框架的kSecKeyAlgorithmECIESEncryptionStandardVariableIVX963SHA256AESGCM互通
+//
+// 密文格式: R || C || T
+// R为临时公钥，使用SEC 1 2.3.3未压缩格式(0x04 || X || Y)
+// Z为ECDH共享秘密的x坐标，K || IV = X9.63-KDF-SHA256(Z, SharedInfo = R)
+// 不超过256位的曲线使用AES-128，更大的曲线使用AES-256；IV为16字节
+// C || T为AES-GCM加密结果及16字节认证标签，没有附加认证数据
+const (
+	eciesIVSize  = 16
+	eciesTagSize = 16
+)
+
+var ErrNilKey = errors.New("ecies: nil key")
+
+func ECIESEncrypt(publicKey *ecdsa.PublicKey, originText []byte) ([]byte, error) {
+
+	if publicKey == nil || publicKey.Curve == nil {
+		return nil, ErrNilKey
+	}
+
+	// 生成与接收方同曲线的临时密钥对
+	ephemeral, err := GenerateKey(publicKey.Curve)
+	if err != nil {
+		return nil, err
+	}
+
+	// 临时私钥与接收方公钥计算共享秘密Z
+	z, err := ECDH(ephemeral, publicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	// 将临时公钥编码为未压缩格式，作为密文前缀，同时作为KDF的SharedInfo
+	r := elliptic.Marshal(publicKey.Curve, ephemeral.X, ephemeral.Y)
+
+	// 根据共享秘密派生对称密钥与IV
+	aead, iv, err := eciesAEAD(publicKey.Curve, z, r)
+	if err != nil {
+		return nil, err
+	}
+
+	// 返回加密结果，将结果追加到R之后
+	return aead.Seal(r, iv, originText, nil), nil
+}
+
+func ECIESDecrypt(privateKey *ecdsa.PrivateKey, cipherText []byte) ([]byte, error) {
+
+	if privateKey == nil || privateKey.Curve == nil || privateKey.D == nil {
+		return nil, ErrNilKey
+	}
+
+	curve := privateKey.Curve
+	pointLen := 1 + 2*curveByteSize(curve)
+	if len(cipherText) < pointLen+eciesTagSize {
+		return nil, errors.New("ecies: cipherText too short")
+	}
+
+	// 还原临时公钥；Unmarshal会校验点是否在曲线上，出错时x为nil
+	r := cipherText[:pointLen]
+	x, y := elliptic.Unmarshal(curve, r)
+	if x == nil {
+		return nil, errors.New("ecies: invalid ephemeral public key")
+	}
+
+	// 私钥与临时公钥计算共享秘密Z
+	z, err := ECDH(privateKey, &ecdsa.PublicKey{Curve: curve, X: x, Y: y})
+	if err != nil {
+		return nil, err
+	}
+
+	// 根据共享秘密派生对称密钥与IV
+	aead, iv, err := eciesAEAD(curve, z, r)
+	if err != nil {
+		return nil, err
+	}
+
+	// 返回解密结果，认证失败时返回错误
+	return aead.Open(nil, iv, cipherText[pointLen:], nil)
+}
+
+func eciesAEAD(curve elliptic.Curve, z, r []byte) (cipher.AEAD, []byte, error) {
+
+	keySize := 16
+	if curve.Params().BitSize > 256 {
+		keySize = 32
+	}
+
+	// 派生 密钥 || IV。每次加密都使用新的临时密钥，因此派生出的IV不会重复
+	k := X963KDF(sha256.New, z, r, keySize+eciesIVSize)
+
+	// 创建一个cipher.Block，密钥长度决定AES-128或AES-256
+	block, err := aes.NewCipher(k[:keySize])
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// 使用16字节IV的迦洛瓦计数器模式，返回cipher.AEAD
+	aead, err := cipher.NewGCMWithNonceSize(block, eciesIVSize)
+	if err != nil {
+		return nil, nil, err
+	}
+	return aead, k[keySize:], nil
+}
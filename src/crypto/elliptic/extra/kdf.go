@@ -0,0 +1,51 @@
+package extra
+
+import (
+	"crypto/hmac"
+	"encoding/binary"
+	"hash"
+)
+
+func X963KDF(h func() hash.Hash, z, sharedInfo []byte, length int) []byte {
+
+	// ANSI X9.63密钥派生函数(SEC 1 3.6.1)
+	// K = Hash(Z || Counter || SharedInfo)，Counter为从1开始的32位大端计数器，依次拼接直到满足length
+	var counter [4]byte
+	out := make([]byte, 0, length)
+	for i := uint32(1); len(out) < length; i++ {
+		binary.BigEndian.PutUint32(counter[:], i)
+
+		d := h()
+		d.Write(z)
+		d.Write(counter[:])
+		d.Write(sharedInfo)
+		out = d.Sum(out)
+	}
+	return out[:length]
+}
+
+func HKDF(h func() hash.Hash, secret, salt, info []byte, length int) []byte {
+
+	// RFC 5869定义的基于HMAC的提取-扩展密钥派生函数
+	// Extract: PRK = HMAC-Hash(salt, IKM)，salt为空时使用HashLen个0
+	if len(salt) == 0 {
+		salt = make([]byte, h().Size())
+	}
+	extractor := hmac.New(h, salt)
+	extractor.Write(secret)
+	prk := extractor.Sum(nil)
+
+	// Expand: T(i) = HMAC-Hash(PRK, T(i-1) | info | i)
+	var t []byte
+	out := make([]byte, 0, length)
+	expander := hmac.New(h, prk)
+	for i := byte(1); len(out) < length; i++ {
+		expander.Reset()
+		expander.Write(t)
+		expander.Write(info)
+		expander.Write([]byte{i})
+		t = expander.Sum(nil)
+		out = append(out, t...)
+	}
+	return out[:length]
+}
@@ -3,6 +3,10 @@ package main
 import (
 	"crypto/hmac"
 	"crypto/sha256"
+	"fmt"
+	"github.com/zc2638/go-standard/src/crypto/hmac/extra"
+	"log"
+	"time"
 )
 
 // 实现了U.S. Federal Information Processing Standards Publication 198规定的HMAC（加密哈希信息认证码）
@@ -23,4 +27,98 @@ func main() {
 
 	// 比较两个MAC是否相同
 	hmac.Equal(expectedMAC, expectedMAC)
-}
\ No newline at end of file
+
+	// RFC 4226 基于计数器的一次性密码
+	HOTP()
+	// RFC 6238 基于时间的一次性密码
+	TOTP()
+}
+
+func HOTP() {
+
+	// RFC 4226 附录D 测试向量
+	secret := []byte("12345678901234567890")
+	expected := []string{"755224", "287082", "359152", "969429", "338314", "254676", "287922", "162583", "399871", "520489"}
+	for i, want := range expected {
+		code, err := extra.HOTP(secret, uint64(i), 6, extra.SHA1)
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println("HOTP counter", i, code, code == want)
+	}
+
+	// 服务端保存计数器，允许客户端向前偏移3次
+	h := &extra.HOTPConfig{Secret: secret, LookAhead: 3}
+	counter, err := h.Validate("969429", 0)
+	fmt.Println("HOTP校验(跳过3次): ", counter, err)
+	// 同一密码再次使用
+	_, err = h.Validate("969429", counter)
+	fmt.Println("HOTP重放: ", err)
+}
+
+func TOTP() {
+
+	// RFC 6238 附录B 测试向量，不同算法使用不同长度的密钥
+	seeds := map[extra.Algorithm][]byte{
+		extra.SHA1:   []byte("12345678901234567890"),
+		extra.SHA256: []byte("12345678901234567890123456789012"),
+		extra.SHA512: []byte("1234567890123456789012345678901234567890123456789012345678901234"),
+	}
+	vectors := []struct {
+		unix int64
+		alg  extra.Algorithm
+		code string
+	}{
+		{59, extra.SHA1, "94287082"},
+		{59, extra.SHA256, "46119246"},
+		{59, extra.SHA512, "90693936"},
+		{1111111109, extra.SHA1, "07081804"},
+		{1111111109, extra.SHA256, "68084774"},
+		{1111111109, extra.SHA512, "25091201"},
+		{2000000000, extra.SHA1, "69279037"},
+		{2000000000, extra.SHA256, "90698825"},
+		{2000000000, extra.SHA512, "38618901"},
+		{20000000000, extra.SHA1, "65353130"},
+		{20000000000, extra.SHA256, "77737706"},
+		{20000000000, extra.SHA512, "47863826"},
+	}
+	for _, v := range vectors {
+		t := &extra.TOTPConfig{Secret: seeds[v.alg], Digits: 8, Algorithm: v.alg}
+		code, err := t.Generate(time.Unix(v.unix, 0))
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println("TOTP", v.alg, v.unix, code, code == v.code)
+	}
+
+	// 为新用户生成base32密钥
+	secretText, err := extra.GenerateSecret(20)
+	if err != nil {
+		log.Fatal(err)
+	}
+	secret, err := extra.DecodeSecret(secretText)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println("TOTP密钥: ", secretText)
+
+	// 生成otpauth URI，转换为二维码后可被认证器应用扫描
+	t := &extra.TOTPConfig{Secret: secret, Digits: 6, Algorithm: extra.SHA1, Period: 30 * time.Second, Skew: 1}
+	fmt.Println("otpauth URI: ", t.URI("Example Co", "alice@example.com"))
+
+	// 登录校验，允许前后各1个时间步的时钟偏差
+	now := time.Now()
+	code, err := t.Generate(now.Add(-30 * time.Second))
+	if err != nil {
+		log.Fatal(err)
+	}
+	// last为该用户上次验证成功的计数器，需要持久化
+	var last uint64
+	last, err = t.Validate(code, now, last)
+	fmt.Println("TOTP校验(上一个时间步): ", err)
+	// 同一密码在有效期内再次提交
+	_, err = t.Validate(code, now, last)
+	fmt.Println("TOTP重放: ", err)
+	_, err = t.Validate("000000", now, last)
+	fmt.Println("错误密码: ", err)
+}
@@ -0,0 +1,229 @@
+package extra
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidDigits = errors.New("otp: digits must be between 6 and 8")
+	ErrInvalidCode   = errors.New("otp: invalid code")
+	ErrCodeReused    = errors.New("otp: code has already been used")
+)
+
+// HMAC使用的哈希算法，名称与otpauth URI中的algorithm参数一致
+type Algorithm int
+
+const (
+	SHA1 Algorithm = iota
+	SHA256
+	SHA512
+)
+
+func (a Algorithm) String() string {
+	switch a {
+	case SHA256:
+		return "SHA256"
+	case SHA512:
+		return "SHA512"
+	}
+	return "SHA1"
+}
+
+func (a Algorithm) hash() func() hash.Hash {
+	switch a {
+	case SHA256:
+		return sha256.New
+	case SHA512:
+		return sha512.New
+	}
+	return sha1.New
+}
+
+// 密钥使用不带填充的标准base32编码，与Google Authenticator等应用兼容
+var secretEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// 生成size字节的随机密钥，返回base32编码。RFC 4226建议至少160位(20字节)
+func GenerateSecret(size int) (string, error) {
+	if size < 16 {
+		return "", errors.New("otp: secret must be at least 16 bytes")
+	}
+	secret := make([]byte, size)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return secretEncoding.EncodeToString(secret), nil
+}
+
+// 解码base32密钥，忽略空格、大小写及填充
+func DecodeSecret(secret string) ([]byte, error) {
+	secret = strings.ToUpper(strings.Replace(secret, " ", "", -1))
+	secret = strings.TrimRight(secret, "=")
+	return secretEncoding.DecodeString(secret)
+}
+
+// RFC 4226 5.3，计算计数器counter对应的一次性密码
+func HOTP(secret []byte, counter uint64, digits int, alg Algorithm) (string, error) {
+
+	if digits < 6 || digits > 8 {
+		return "", ErrInvalidDigits
+	}
+
+	// HS = HMAC(K, C)，C为8字节大端计数器
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], counter)
+	h := hmac.New(alg.hash(), secret)
+	h.Write(msg[:])
+	sum := h.Sum(nil)
+
+	// 动态截断: 取最后一个字节的低4位作为偏移，读取31位整数
+	offset := sum[len(sum)-1] & 0x0f
+	code := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	// 取模并左侧补0
+	mod := uint32(1)
+	for i := 0; i < digits; i++ {
+		mod *= 10
+	}
+	return fmt.Sprintf("%0*d", digits, code%mod), nil
+}
+
+// 位数为0时使用6位
+func defaultDigits(digits int) int {
+	if digits == 0 {
+		return 6
+	}
+	return digits
+}
+
+// RFC 4226 基于计数器的一次性密码
+type HOTPConfig struct {
+	Secret    []byte
+	Digits    int
+	Algorithm Algorithm
+	// 向前查找的计数器数量，用于容忍客户端多按了几次
+	LookAhead int
+}
+
+func (c *HOTPConfig) Generate(counter uint64) (string, error) {
+	return HOTP(c.Secret, counter, defaultDigits(c.Digits), c.Algorithm)
+}
+
+// 从服务端保存的counter开始校验，成功时返回下一次应保存的counter
+// 计数器只会前进，已使用过的密码无法再次通过校验
+func (c *HOTPConfig) Validate(code string, counter uint64) (uint64, error) {
+	for i := 0; i <= c.LookAhead; i++ {
+		ok, err := c.match(code, counter+uint64(i))
+		if err != nil {
+			return counter, err
+		}
+		if ok {
+			return counter + uint64(i) + 1, nil
+		}
+	}
+	return counter, ErrInvalidCode
+}
+
+func (c *HOTPConfig) match(code string, counter uint64) (bool, error) {
+	expected, err := c.Generate(counter)
+	if err != nil {
+		return false, err
+	}
+	return subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1, nil
+}
+
+// otpauth://hotp URI
+func (c *HOTPConfig) URI(issuer, account string, counter uint64) string {
+	v := url.Values{}
+	v.Set("counter", strconv.FormatUint(counter, 10))
+	return provisioningURI("hotp", issuer, account, c.Secret, c.Algorithm, defaultDigits(c.Digits), v)
+}
+
+// RFC 6238 基于时间的一次性密码
+type TOTPConfig struct {
+	Secret    []byte
+	Digits    int
+	Algorithm Algorithm
+	// 时间步长，为0时使用30秒
+	Period time.Duration
+	// 允许前后偏差的时间步数，用于容忍时钟偏差
+	Skew int
+}
+
+func (c *TOTPConfig) period() uint64 {
+	if c.Period < time.Second {
+		return 30
+	}
+	return uint64(c.Period / time.Second)
+}
+
+// 时间t对应的计数器 T = floor(unix / period)
+func (c *TOTPConfig) Counter(t time.Time) uint64 {
+	return uint64(t.Unix()) / c.period()
+}
+
+func (c *TOTPConfig) Generate(t time.Time) (string, error) {
+	return HOTP(c.Secret, c.Counter(t), defaultDigits(c.Digits), c.Algorithm)
+}
+
+// 校验时间t的密码，允许前后Skew个时间步。last为该用户上次验证成功的计数器(首次为0)
+// 成功时返回本次匹配的计数器，调用方需要保存它，计数器不大于last的密码视为重放
+func (c *TOTPConfig) Validate(code string, t time.Time, last uint64) (uint64, error) {
+
+	current := c.Counter(t)
+	for i := -c.Skew; i <= c.Skew; i++ {
+		if i < 0 && uint64(-i) > current {
+			continue
+		}
+		counter := uint64(int64(current) + int64(i))
+		expected, err := HOTP(c.Secret, counter, defaultDigits(c.Digits), c.Algorithm)
+		if err != nil {
+			return last, err
+		}
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) != 1 {
+			continue
+		}
+		if counter <= last {
+			return last, ErrCodeReused
+		}
+		return counter, nil
+	}
+	return last, ErrInvalidCode
+}
+
+// otpauth://totp URI，可直接生成二维码供认证器应用扫描
+func (c *TOTPConfig) URI(issuer, account string) string {
+	v := url.Values{}
+	v.Set("period", strconv.FormatUint(c.period(), 10))
+	return provisioningURI("totp", issuer, account, c.Secret, c.Algorithm, defaultDigits(c.Digits), v)
+}
+
+// 格式参考 https://github.com/google/google-authenticator/wiki/Key-Uri-Format
+// otpauth://TYPE/ISSUER:ACCOUNT?secret=SECRET&issuer=ISSUER&algorithm=SHA1&digits=6&period=30
+func provisioningURI(typ, issuer, account string, secret []byte, alg Algorithm, digits int, v url.Values) string {
+
+	label := url.PathEscape(account)
+	if issuer != "" {
+		label = url.PathEscape(issuer) + ":" + label
+		v.Set("issuer", issuer)
+	}
+	v.Set("secret", secretEncoding.EncodeToString(secret))
+	v.Set("algorithm", alg.String())
+	v.Set("digits", strconv.Itoa(digits))
+
+	// 部分认证器不识别+号表示的空格
+	return "otpauth://" + typ + "/" + label + "?" + strings.Replace(v.Encode(), "+", "%20", -1)
+}
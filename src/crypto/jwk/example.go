@@ -0,0 +1,192 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"fmt"
+	ecExtra "github.com/zc2638/go-standard/src/crypto/elliptic/extra"
+	"github.com/zc2638/go-standard/src/crypto/jwk/extra"
+	jwtExtra "github.com/zc2638/go-standard/src/crypto/jwt/extra"
+	rsaExtra "github.com/zc2638/go-standard/src/crypto/rsa/extra"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"time"
+)
+
+const PrivatePemFile = "testdata/rsa_private.pem"
+
+// 实现了RFC 7517规定的JSON Web Key与JWK Set，以及RFC 7638指纹
+func main() {
+
+	// RSA/EC密钥与JWK互相转换
+	Convert()
+	// RFC 7638 3.1 中的指纹示例
+	Thumbprint()
+	// 发布JWKS并通过客户端获取验签公钥
+	JWKS()
+}
+
+func loadRSAKey() ([]byte, *rsa.PrivateKey) {
+
+	// 读取rsa示例中generateRSAKey生成的PKCS#8私钥
+	priPem, err := ioutil.ReadFile(PrivatePemFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+	pri, err := rsaExtra.BuildRSAPrivateKey(priPem)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return priPem, pri
+}
+
+func Convert() {
+
+	_, pri := loadRSAKey()
+	rsaJWK, err := extra.New(pri, jwtExtra.RS256, "sig")
+	if err != nil {
+		log.Fatal(err)
+	}
+	// 只输出公钥部分
+	b, _ := json.MarshalIndent(rsaJWK.Public(), "", "  ")
+	fmt.Println("RSA JWK: ", string(b))
+
+	// JWK还原为私钥
+	key, err := rsaJWK.PrivateKey()
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println("RSA私钥还原一致: ", key.(*rsa.PrivateKey).D.Cmp(pri.D) == 0)
+
+	// EC密钥
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		log.Fatal(err)
+	}
+	ecJWK, err := extra.New(ecKey, jwtExtra.ES256, "sig")
+	if err != nil {
+		log.Fatal(err)
+	}
+	b, _ = json.Marshal(ecJWK)
+	fmt.Println("EC JWK(含私钥): ", string(b))
+
+	// JWK转换为PKIX PEM，交给elliptic示例中的BuildECPublicKey解析
+	pubPem, err := ecJWK.PublicKeyPEM()
+	if err != nil {
+		log.Fatal(err)
+	}
+	pub, err := ecExtra.BuildECPublicKey(pubPem)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println("EC公钥还原一致: ", pub.X.Cmp(ecKey.X) == 0 && pub.Y.Cmp(ecKey.Y) == 0)
+}
+
+func Thumbprint() {
+
+	k := extra.JWK{
+		Kty: "RSA",
+		N:   "0vx7agoebGcQSuuPiLJXZptN9nndrQmbXEps2aiAFbWhM78LhWx4cbbfAAtVT86zwu1RK7aPFFxuhDR1L6tSoc_BJECPebWKRXjBZCiFV4n3oknjhMstn64tZ_2W-5JsGY4Hc5n9yBXArwl93lqt7_RN5w6Cf0h4QyQ5v-65YGjQR0_FDW2QvzqY368QQMicAtaSqzs8KJZgnYb9c7d0zgdAZHzu6qMQvRL5hajrn1n91CbOpbISD08qNLyrdkt-bFTWhAI4vMQFh6WeZu0fM4lFd2NcRwr3XPksINHaQ-G_xBniIqbw0Ls1jF44-csFCur-kEgU8awapJzKnqDKgw",
+		E:   "AQAB",
+		Alg: "RS256",
+		Kid: "2011-04-29",
+	}
+	thumbprint, err := k.Thumbprint()
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println("RFC 7638指纹: ", thumbprint, thumbprint == "NzbLsXh8uDCcd-6MNwXF4W_7noWXFZAfHkxZsRGC9Xs")
+}
+
+func JWKS() {
+
+	rsaPem, rsaKey := loadRSAKey()
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		log.Fatal(err)
+	}
+	rsaJWK, err := extra.New(rsaKey, jwtExtra.RS256, "sig")
+	if err != nil {
+		log.Fatal(err)
+	}
+	ecJWK, err := extra.New(ecKey, jwtExtra.ES256, "sig")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// 先只发布RSA公钥，模拟之后轮换新增EC密钥
+	set := &extra.Set{Keys: []extra.JWK{*rsaJWK}}
+	handler, err := extra.NewHandler(set, 10*time.Minute)
+	if err != nil {
+		log.Fatal(err)
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/.well-known/jwks.json", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handler.ServeHTTP(w, r)
+	}))
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/.well-known/jwks.json")
+	if err != nil {
+		log.Fatal(err)
+	}
+	resp.Body.Close()
+	fmt.Println("Cache-Control: ", resp.Header.Get("Cache-Control"), "ETag: ", resp.Header.Get("ETag"))
+
+	client := extra.NewClient(server.URL + "/.well-known/jwks.json")
+	// 示例中不限制刷新间隔
+	client.MinRefreshInterval = 0
+
+	// 客户端实现了jwt的KeyResolver
+	verifier := &jwtExtra.Verifier{
+		Algorithms: []string{jwtExtra.RS256, jwtExtra.ES256},
+		Resolver:   client,
+	}
+	token, err := jwtExtra.Sign(jwtExtra.RS256, rsaKey, rsaJWK.Kid, jwtExtra.Claims{Subject: "jwks"})
+	if err != nil {
+		log.Fatal(err)
+	}
+	if _, err := verifier.Verify(token, nil); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println("RS256通过JWKS校验")
+
+	// 获取PEM公钥，交给rsa示例中的Verify
+	msg := []byte("hello, world")
+	sig, err := rsaExtra.Sign(rsaPem, msg)
+	if err != nil {
+		log.Fatal(err)
+	}
+	pubPem, err := client.PublicKeyPEM(rsaJWK.Kid)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := rsaExtra.Verify(pubPem, msg, sig); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println("rsa Verify通过JWKS公钥校验")
+
+	// 轮换: 新增EC密钥，客户端遇到未知kid时自动刷新
+	set.Keys = append(set.Keys, *ecJWK)
+	if handler, err = extra.NewHandler(set, 10*time.Minute); err != nil {
+		log.Fatal(err)
+	}
+	token, err = jwtExtra.Sign(jwtExtra.ES256, ecKey, ecJWK.Kid, jwtExtra.Claims{Subject: "jwks"})
+	if err != nil {
+		log.Fatal(err)
+	}
+	if _, err := verifier.Verify(token, nil); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println("ES256在密钥轮换后通过JWKS校验")
+
+	// 不存在的kid
+	_, err = client.PublicKey("unknown")
+	fmt.Println("未知kid: ", err)
+}
@@ -0,0 +1,198 @@
+package extra
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+
+	jwt "github.com/zc2638/go-standard/src/crypto/jwt/extra"
+)
+
+// 从远程JWKS地址获取验签公钥，kid未知时自动刷新
+// 同一时间只发出一个刷新请求，其余查询等待该请求完成；请求期间不持有锁，不会阻塞已缓存kid的查询
+type Client struct {
+	// JWKS地址
+	URL string
+	// 为nil时使用超时为10秒的http.Client
+	HTTPClient *http.Client
+	// 两次请求之间的最小间隔(包括失败的请求)，防止未知kid或服务端故障导致频繁请求
+	MinRefreshInterval time.Duration
+	// 缓存的最长时间，超过后下次查询时刷新
+	MaxAge time.Duration
+
+	mu   sync.Mutex
+	set  *Set
+	etag string
+	// 最近一次成功获取的时间
+	fetchedAt time.Time
+	// 最近一次请求的时间及错误
+	attemptedAt time.Time
+	lastErr     error
+	// 刷新进行中时不为nil，刷新完成后关闭
+	inflight chan struct{}
+}
+
+var defaultHTTPClient = &http.Client{Timeout: 10 * time.Second}
+
+func NewClient(url string) *Client {
+	return &Client{
+		URL:                url,
+		MinRefreshInterval: time.Minute,
+		MaxAge:             time.Hour,
+	}
+}
+
+// 根据kid返回JWK
+func (c *Client) Lookup(kid string) (*JWK, error) {
+
+	c.mu.Lock()
+	set, fetchedAt := c.set, c.fetchedAt
+	c.mu.Unlock()
+
+	// 缓存为空或已过期时刷新，刷新失败时继续使用旧的缓存
+	if set == nil || (c.MaxAge > 0 && time.Since(fetchedAt) > c.MaxAge) {
+		s, err := c.refresh()
+		if s == nil {
+			return nil, err
+		}
+		set = s
+	}
+	if k, ok := set.Lookup(kid); ok {
+		return k, nil
+	}
+
+	// kid未知，可能是对方轮换了密钥，距离上次请求超过最小间隔时重新获取
+	s, err := c.refresh()
+	if s != nil {
+		if k, ok := s.Lookup(kid); ok {
+			return k, nil
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return nil, ErrKeyNotFound
+}
+
+// 根据kid返回*rsa.PublicKey或*ecdsa.PublicKey
+func (c *Client) PublicKey(kid string) (interface{}, error) {
+	k, err := c.Lookup(kid)
+	if err != nil {
+		return nil, err
+	}
+	return k.PublicKey()
+}
+
+// 根据kid返回PKIX PEM编码的公钥，可直接用于rsa示例中的Verify、VerifyPass等方法
+func (c *Client) PublicKeyPEM(kid string) ([]byte, error) {
+	k, err := c.Lookup(kid)
+	if err != nil {
+		return nil, err
+	}
+	return k.PublicKeyPEM()
+}
+
+// 实现jwt示例中的KeyResolver接口
+func (c *Client) ResolveKey(header *jwt.Header) (interface{}, error) {
+	if header.Kid == "" {
+		return nil, ErrKeyNotFound
+	}
+	return c.PublicKey(header.Kid)
+}
+
+// 返回刷新后的缓存。已有请求进行中时等待其结果，距离上次请求不足MinRefreshInterval时直接返回缓存
+func (c *Client) refresh() (*Set, error) {
+
+	c.mu.Lock()
+	if wait := c.inflight; wait != nil {
+		c.mu.Unlock()
+		<-wait
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		return c.set, c.lastErr
+	}
+	if !c.attemptedAt.IsZero() && time.Since(c.attemptedAt) < c.MinRefreshInterval {
+		defer c.mu.Unlock()
+		if c.set == nil {
+			return nil, c.lastErr
+		}
+		return c.set, nil
+	}
+	wait := make(chan struct{})
+	c.inflight = wait
+	etag := ""
+	if c.set != nil {
+		etag = c.etag
+	}
+	c.mu.Unlock()
+
+	set, etag, err := c.fetch(etag)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	c.attemptedAt = now
+	c.lastErr = err
+	if err == nil {
+		c.fetchedAt = now
+		// 304时set为nil，继续使用原有缓存
+		if set != nil {
+			c.set = set
+			c.etag = etag
+		}
+	}
+	c.inflight = nil
+	close(wait)
+	return c.set, err
+}
+
+// 请求JWKS，内容未变化时返回nil
+func (c *Client) fetch(etag string) (*Set, string, error) {
+
+	req, err := http.NewRequest(http.MethodGet, c.URL, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	req.Header.Set("Accept", "application/jwk-set+json, application/json")
+	// 条件请求，内容未变化时服务端返回304
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+
+	client := c.HTTPClient
+	if client == nil {
+		client = defaultHTTPClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusNotModified:
+		return nil, etag, nil
+	case http.StatusOK:
+	default:
+		return nil, "", fmt.Errorf("jwk: unexpected status %s", resp.Status)
+	}
+
+	// 限制响应大小
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, "", err
+	}
+	var set Set
+	if err := json.Unmarshal(body, &set); err != nil {
+		return nil, "", err
+	}
+	if len(set.Keys) == 0 {
+		return nil, "", errors.New("jwk: empty key set")
+	}
+	return &set, resp.Header.Get("ETag"), nil
+}
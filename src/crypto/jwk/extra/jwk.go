@@ -0,0 +1,307 @@
+package extra
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"math/big"
+)
+
+// JSON Web Key，RFC 7517。数值参数均为不带填充的base64url编码的大端字节
+type JWK struct {
+	Kty    string   `json:"kty"`
+	Use    string   `json:"use,omitempty"`
+	KeyOps []string `json:"key_ops,omitempty"`
+	Alg    string   `json:"alg,omitempty"`
+	Kid    string   `json:"kid,omitempty"`
+
+	// EC，RFC 7518 6.2
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+
+	// RSA，RFC 7518 6.3
+	N  string `json:"n,omitempty"`
+	E  string `json:"e,omitempty"`
+	P  string `json:"p,omitempty"`
+	Q  string `json:"q,omitempty"`
+	Dp string `json:"dp,omitempty"`
+	Dq string `json:"dq,omitempty"`
+	Qi string `json:"qi,omitempty"`
+
+	// EC及RSA私钥
+	D string `json:"d,omitempty"`
+
+	// 对称密钥，RFC 7518 6.4
+	K string `json:"k,omitempty"`
+}
+
+var b64 = base64.RawURLEncoding
+
+// 由*rsa.PublicKey、*rsa.PrivateKey、*ecdsa.PublicKey、*ecdsa.PrivateKey或[]byte创建JWK，kid为RFC 7638指纹
+func New(key interface{}, alg, use string) (*JWK, error) {
+
+	var k JWK
+	switch key := key.(type) {
+	case *rsa.PublicKey:
+		k.setRSAPublic(key)
+	case *rsa.PrivateKey:
+		k.setRSAPublic(&key.PublicKey)
+		k.D = b64.EncodeToString(key.D.Bytes())
+		if len(key.Primes) == 2 {
+			// 预计算值，RSA私钥运算使用中国剩余定理加速
+			key.Precompute()
+			k.P = b64.EncodeToString(key.Primes[0].Bytes())
+			k.Q = b64.EncodeToString(key.Primes[1].Bytes())
+			k.Dp = b64.EncodeToString(key.Precomputed.Dp.Bytes())
+			k.Dq = b64.EncodeToString(key.Precomputed.Dq.Bytes())
+			k.Qi = b64.EncodeToString(key.Precomputed.Qinv.Bytes())
+		}
+	case *ecdsa.PublicKey:
+		if err := k.setECPublic(key); err != nil {
+			return nil, err
+		}
+	case *ecdsa.PrivateKey:
+		if err := k.setECPublic(&key.PublicKey); err != nil {
+			return nil, err
+		}
+		k.D = b64.EncodeToString(padded(key.D, key.Curve))
+	case []byte:
+		k.Kty = "oct"
+		k.K = b64.EncodeToString(key)
+	default:
+		return nil, errors.New("jwk: unsupported key type")
+	}
+	k.Alg = alg
+	k.Use = use
+
+	thumbprint, err := k.Thumbprint()
+	if err != nil {
+		return nil, err
+	}
+	k.Kid = thumbprint
+	return &k, nil
+}
+
+func (k *JWK) setRSAPublic(pub *rsa.PublicKey) {
+	k.Kty = "RSA"
+	k.N = b64.EncodeToString(pub.N.Bytes())
+	k.E = b64.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+}
+
+func (k *JWK) setECPublic(pub *ecdsa.PublicKey) error {
+	crv, err := curveName(pub.Curve)
+	if err != nil {
+		return err
+	}
+	k.Kty = "EC"
+	k.Crv = crv
+	// 坐标必须按曲线长度补齐，RFC 7518 6.2.1.2
+	k.X = b64.EncodeToString(padded(pub.X, pub.Curve))
+	k.Y = b64.EncodeToString(padded(pub.Y, pub.Curve))
+	return nil
+}
+
+// RFC 7638指纹: 对必需成员按字典序组成的JSON做SHA-256，结果为base64url编码
+func (k *JWK) Thumbprint() (string, error) {
+
+	var members interface{}
+	switch k.Kty {
+	case "RSA":
+		members = struct {
+			E   string `json:"e"`
+			Kty string `json:"kty"`
+			N   string `json:"n"`
+		}{k.E, k.Kty, k.N}
+	case "EC":
+		members = struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+			Y   string `json:"y"`
+		}{k.Crv, k.Kty, k.X, k.Y}
+	case "oct":
+		members = struct {
+			K   string `json:"k"`
+			Kty string `json:"kty"`
+		}{k.K, k.Kty}
+	default:
+		return "", errors.New("jwk: unsupported kty")
+	}
+
+	// encoding/json按结构体字段顺序输出且不含空白，满足RFC 7638的规范化要求
+	data, err := json.Marshal(members)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return b64.EncodeToString(sum[:]), nil
+}
+
+// 返回不含私钥参数的JWK；对称密钥没有公开部分，返回nil
+func (k *JWK) Public() *JWK {
+	if k.Kty == "oct" {
+		return nil
+	}
+	pub := *k
+	pub.D, pub.P, pub.Q, pub.Dp, pub.Dq, pub.Qi = "", "", "", "", "", ""
+	return &pub
+}
+
+func (k *JWK) IsPrivate() bool {
+	return k.D != "" || k.K != ""
+}
+
+// 返回*rsa.PublicKey、*ecdsa.PublicKey或[]byte(对称密钥)
+func (k *JWK) PublicKey() (interface{}, error) {
+
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		if !e.IsInt64() || e.Int64() > 1<<31-1 || e.Int64() < 3 {
+			return nil, errors.New("jwk: invalid RSA exponent")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		curve, err := curveByName(k.Crv)
+		if err != nil {
+			return nil, err
+		}
+		x, err := decodeInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		// 校验点是否在曲线上
+		if !curve.IsOnCurve(x, y) {
+			return nil, errors.New("jwk: point is not on curve")
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	case "oct":
+		return b64.DecodeString(k.K)
+	}
+	return nil, errors.New("jwk: unsupported kty")
+}
+
+// 返回*rsa.PrivateKey或*ecdsa.PrivateKey
+func (k *JWK) PrivateKey() (interface{}, error) {
+
+	if k.D == "" {
+		return nil, errors.New("jwk: not a private key")
+	}
+	pub, err := k.PublicKey()
+	if err != nil {
+		return nil, err
+	}
+	d, err := decodeInt(k.D)
+	if err != nil {
+		return nil, err
+	}
+
+	switch pub := pub.(type) {
+	case *rsa.PublicKey:
+		priv := &rsa.PrivateKey{PublicKey: *pub, D: d}
+		if k.P == "" || k.Q == "" {
+			return nil, errors.New("jwk: RSA private key without primes is not supported")
+		}
+		p, err := decodeInt(k.P)
+		if err != nil {
+			return nil, err
+		}
+		q, err := decodeInt(k.Q)
+		if err != nil {
+			return nil, err
+		}
+		priv.Primes = []*big.Int{p, q}
+		// 校验私钥参数是否一致
+		if err := priv.Validate(); err != nil {
+			return nil, err
+		}
+		priv.Precompute()
+		return priv, nil
+	case *ecdsa.PublicKey:
+		priv := &ecdsa.PrivateKey{PublicKey: *pub, D: d}
+		// 校验 D*G 是否等于公钥
+		x, y := pub.Curve.ScalarBaseMult(padded(d, pub.Curve))
+		if x.Cmp(pub.X) != 0 || y.Cmp(pub.Y) != 0 {
+			return nil, errors.New("jwk: EC private key does not match public key")
+		}
+		return priv, nil
+	}
+	return nil, errors.New("jwk: unsupported kty")
+}
+
+// 将公钥编码为PKIX PEM，可直接传给rsa示例中的BuildRSAPublicKey等方法
+func (k *JWK) PublicKeyPEM() ([]byte, error) {
+
+	pub, err := k.PublicKey()
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := pub.([]byte); ok {
+		return nil, errors.New("jwk: symmetric key has no public key")
+	}
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), nil
+}
+
+func curveName(curve elliptic.Curve) (string, error) {
+	switch curve.Params().Name {
+	case "P-256", "P-384", "P-521":
+		return curve.Params().Name, nil
+	}
+	return "", errors.New("jwk: unsupported curve")
+}
+
+func curveByName(name string) (elliptic.Curve, error) {
+	switch name {
+	case "P-256":
+		return elliptic.P256(), nil
+	case "P-384":
+		return elliptic.P384(), nil
+	case "P-521":
+		return elliptic.P521(), nil
+	}
+	return nil, errors.New("jwk: unsupported curve")
+}
+
+func padded(n *big.Int, curve elliptic.Curve) []byte {
+	size := (curve.Params().BitSize + 7) / 8
+	b := n.Bytes()
+	if len(b) >= size {
+		return b
+	}
+	out := make([]byte, size)
+	copy(out[size-len(b):], b)
+	return out
+}
+
+func decodeInt(s string) (*big.Int, error) {
+	if s == "" {
+		return nil, errors.New("jwk: missing parameter")
+	}
+	b, err := b64.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(b), nil
+}
@@ -0,0 +1,76 @@
+package extra
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+var ErrKeyNotFound = errors.New("jwk: key not found")
+
+// JWK Set，RFC 7517 5
+type Set struct {
+	Keys []JWK `json:"keys"`
+}
+
+// 根据kid查找密钥
+func (s *Set) Lookup(kid string) (*JWK, bool) {
+	for i := range s.Keys {
+		if s.Keys[i].Kid == kid {
+			return &s.Keys[i], true
+		}
+	}
+	return nil, false
+}
+
+// 返回只包含公钥的Set，对称密钥会被丢弃
+func (s *Set) Public() *Set {
+	pub := &Set{Keys: make([]JWK, 0, len(s.Keys))}
+	for i := range s.Keys {
+		if k := s.Keys[i].Public(); k != nil {
+			pub.Keys = append(pub.Keys, *k)
+		}
+	}
+	return pub
+}
+
+// 返回一个发布JWKS文档的http.Handler，只输出公钥
+// maxAge用于Cache-Control，同时返回ETag以支持条件请求
+func NewHandler(set *Set, maxAge time.Duration) (http.Handler, error) {
+
+	body, err := json.Marshal(set.Public())
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(body)
+	etag := `"` + hex.EncodeToString(sum[:8]) + `"`
+	cacheControl := "public, max-age=" + strconv.Itoa(int(maxAge/time.Second))
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+
+		w.Header().Set("Cache-Control", cacheControl)
+		w.Header().Set("ETag", etag)
+
+		// 内容未变化时返回304
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/jwk-set+json")
+		w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+		if r.Method == http.MethodHead {
+			return
+		}
+		w.Write(body)
+	}), nil
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"github.com/zc2638/go-standard/src/crypto/jwt/extra"
+	rsaExtra "github.com/zc2638/go-standard/src/crypto/rsa/extra"
+	"io/ioutil"
+	"log"
+	"strings"
+	"time"
+)
+
+const (
+	PublicPemFile  = "testdata/rsa_public.pem"
+	PrivatePemFile = "testdata/rsa_private.pem"
+)
+
+// 自定义声明，内嵌注册声明
+type UserClaims struct {
+	extra.Claims
+	Name  string `json:"name"`
+	Admin bool   `json:"admin"`
+}
+
+// 实现了RFC 7519规定的JWT签发与校验(JWS紧凑序列化)
+func main() {
+
+	// HS256对称签名
+	HMAC()
+	// RS256/PS256使用rsa示例中的密钥
+	RSA()
+	// ES256签名
+	ECDSA()
+	// 拒绝alg: none及算法混淆攻击
+	Attacks()
+}
+
+func newClaims() UserClaims {
+	now := time.Now()
+	return UserClaims{
+		Claims: extra.Claims{
+			Issuer:    "go-standard",
+			Subject:   "1234567890",
+			Audience:  extra.Audience{"api"},
+			IssuedAt:  extra.NewNumericDate(now),
+			NotBefore: extra.NewNumericDate(now),
+			ExpiresAt: extra.NewNumericDate(now.Add(time.Hour)),
+		},
+		Name:  "John Doe",
+		Admin: true,
+	}
+}
+
+func HMAC() {
+
+	secret := []byte("test hmac secret")
+
+	// 签发
+	token, err := extra.Sign(extra.HS256, secret, "hmac-1", newClaims())
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println("HS256: ", token)
+
+	// 校验签名、过期时间、签发者及受众，允许30秒时钟偏差
+	verifier := &extra.Verifier{
+		Algorithms: []string{extra.HS256},
+		Resolver:   extra.StaticKeys{"hmac-1": secret},
+		Leeway:     30 * time.Second,
+		Issuer:     "go-standard",
+		Audience:   "api",
+	}
+	var claims UserClaims
+	if _, err := verifier.Verify(token, &claims); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println("HS256校验通过: ", claims.Name, claims.Admin)
+
+	// 过期的token
+	expired := newClaims()
+	expired.ExpiresAt = extra.NewNumericDate(time.Now().Add(-time.Minute))
+	token, _ = extra.Sign(extra.HS256, secret, "hmac-1", expired)
+	_, err = verifier.Verify(token, nil)
+	fmt.Println("过期token: ", err)
+}
+
+func RSA() {
+
+	// 读取rsa示例中生成的PKCS#8私钥与PKIX公钥
+	priPem, err := ioutil.ReadFile(PrivatePemFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+	pubPem, err := ioutil.ReadFile(PublicPemFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+	pri, err := rsaExtra.BuildRSAPrivateKey(priPem)
+	if err != nil {
+		log.Fatal(err)
+	}
+	pub, err := rsaExtra.BuildRSAPublicKey(pubPem)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// 通过kid查找公钥，可以替换为从JWKS获取
+	resolver := extra.KeyResolverFunc(func(h *extra.Header) (interface{}, error) {
+		if h.Kid != "rsa-1" {
+			return nil, extra.ErrKeyNotFound
+		}
+		return pub, nil
+	})
+	verifier := &extra.Verifier{
+		Algorithms: []string{extra.RS256, extra.PS256},
+		Resolver:   resolver,
+	}
+
+	for _, alg := range []string{extra.RS256, extra.PS256} {
+		token, err := extra.Sign(alg, pri, "rsa-1", newClaims())
+		if err != nil {
+			log.Fatal(err)
+		}
+		header, err := verifier.Verify(token, nil)
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println(header.Alg, "校验通过")
+	}
+}
+
+func ECDSA() {
+
+	// 生成P-256密钥对
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	token, err := extra.Sign(extra.ES256, key, "ec-1", newClaims())
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println("ES256: ", token)
+
+	verifier := &extra.Verifier{
+		Algorithms: []string{extra.ES256},
+		Resolver:   extra.StaticKeys{"ec-1": &key.PublicKey},
+	}
+	if _, err := verifier.Verify(token, nil); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println("ES256校验通过")
+}
+
+func Attacks() {
+
+	pubPem, err := ioutil.ReadFile(PublicPemFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+	pub, err := rsaExtra.BuildRSAPublicKey(pubPem)
+	if err != nil {
+		log.Fatal(err)
+	}
+	verifier := &extra.Verifier{
+		Algorithms: []string{extra.RS256},
+		Resolver:   extra.StaticKeys{"rsa-1": pub},
+	}
+
+	// alg: none，签名为空
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none","kid":"rsa-1"}`))
+	payload := base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"admin"}`))
+	_, err = verifier.Verify(strings.Join([]string{header, payload, ""}, "."), nil)
+	fmt.Println("alg none: ", err)
+
+	// 算法混淆: 以公开的RSA公钥PEM作为HMAC密钥签发HS256
+	forged, _ := extra.Sign(extra.HS256, pubPem, "rsa-1", map[string]string{"sub": "admin"})
+	_, err = verifier.Verify(forged, nil)
+	fmt.Println("HS256伪造(不在允许列表): ", err)
+
+	// 即使允许列表包含HS256，密钥类型与alg不匹配时同样拒绝
+	verifier.Algorithms = append(verifier.Algorithms, extra.HS256)
+	_, err = verifier.Verify(forged, nil)
+	fmt.Println("HS256伪造(密钥类型不匹配): ", err)
+}
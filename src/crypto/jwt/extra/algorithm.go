@@ -0,0 +1,165 @@
+package extra
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+	"math/big"
+)
+
+// JWS签名算法，RFC 7518 3.1
+const (
+	HS256 = "HS256"
+	HS384 = "HS384"
+	HS512 = "HS512"
+	RS256 = "RS256"
+	RS384 = "RS384"
+	RS512 = "RS512"
+	PS256 = "PS256"
+	ES256 = "ES256"
+	ES384 = "ES384"
+)
+
+type algorithm struct {
+	hash  crypto.Hash
+	sign  func(hash crypto.Hash, key interface{}, hashed []byte) ([]byte, error)
+	check func(hash crypto.Hash, key interface{}, hashed, sig []byte) error
+}
+
+var algorithms = map[string]algorithm{
+	HS256: {crypto.SHA256, signHMAC, verifyHMAC},
+	HS384: {crypto.SHA384, signHMAC, verifyHMAC},
+	HS512: {crypto.SHA512, signHMAC, verifyHMAC},
+	RS256: {crypto.SHA256, signPKCS1v15, verifyPKCS1v15},
+	RS384: {crypto.SHA384, signPKCS1v15, verifyPKCS1v15},
+	RS512: {crypto.SHA512, signPKCS1v15, verifyPKCS1v15},
+	PS256: {crypto.SHA256, signPSS, verifyPSS},
+	ES256: {crypto.SHA256, signECDSA, verifyECDSA},
+	ES384: {crypto.SHA384, signECDSA, verifyECDSA},
+}
+
+// PSS参数，RFC 7518 3.5要求盐长度等于hash长度
+var pssOptions = &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash}
+
+func signHMAC(hash crypto.Hash, key interface{}, signingInput []byte) ([]byte, error) {
+
+	// HS系列的密钥必须是[]byte，防止把公钥当作HMAC密钥使用
+	secret, ok := key.([]byte)
+	if !ok || len(secret) == 0 {
+		return nil, ErrInvalidKeyType
+	}
+	h := hmac.New(hash.New, secret)
+	h.Write(signingInput)
+	return h.Sum(nil), nil
+}
+
+func verifyHMAC(hash crypto.Hash, key interface{}, signingInput, sig []byte) error {
+	expected, err := signHMAC(hash, key, signingInput)
+	if err != nil {
+		return err
+	}
+	// 比较两个MAC是否相同，耗时与内容无关
+	if !hmac.Equal(expected, sig) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+func signPKCS1v15(hash crypto.Hash, key interface{}, signingInput []byte) ([]byte, error) {
+	priv, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, ErrInvalidKeyType
+	}
+	return rsa.SignPKCS1v15(rand.Reader, priv, hash, digest(hash, signingInput))
+}
+
+func verifyPKCS1v15(hash crypto.Hash, key interface{}, signingInput, sig []byte) error {
+	pub, ok := key.(*rsa.PublicKey)
+	if !ok {
+		return ErrInvalidKeyType
+	}
+	if err := rsa.VerifyPKCS1v15(pub, hash, digest(hash, signingInput), sig); err != nil {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+func signPSS(hash crypto.Hash, key interface{}, signingInput []byte) ([]byte, error) {
+	priv, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, ErrInvalidKeyType
+	}
+	return rsa.SignPSS(rand.Reader, priv, hash, digest(hash, signingInput), pssOptions)
+}
+
+func verifyPSS(hash crypto.Hash, key interface{}, signingInput, sig []byte) error {
+	pub, ok := key.(*rsa.PublicKey)
+	if !ok {
+		return ErrInvalidKeyType
+	}
+	if err := rsa.VerifyPSS(pub, hash, digest(hash, signingInput), sig, pssOptions); err != nil {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+func signECDSA(hash crypto.Hash, key interface{}, signingInput []byte) ([]byte, error) {
+
+	priv, ok := key.(*ecdsa.PrivateKey)
+	if !ok || !curveMatches(hash, priv.Curve) {
+		return nil, ErrInvalidKeyType
+	}
+	r, s, err := ecdsa.Sign(rand.Reader, priv, digest(hash, signingInput))
+	if err != nil {
+		return nil, err
+	}
+
+	// JWS中ECDSA签名为定长的 R || S，而不是DER编码，RFC 7518 3.4
+	size := (priv.Curve.Params().BitSize + 7) / 8
+	sig := make([]byte, 2*size)
+	rb, sb := r.Bytes(), s.Bytes()
+	copy(sig[size-len(rb):size], rb)
+	copy(sig[2*size-len(sb):], sb)
+	return sig, nil
+}
+
+func verifyECDSA(hash crypto.Hash, key interface{}, signingInput, sig []byte) error {
+
+	pub, ok := key.(*ecdsa.PublicKey)
+	if !ok || !curveMatches(hash, pub.Curve) {
+		return ErrInvalidKeyType
+	}
+	size := (pub.Curve.Params().BitSize + 7) / 8
+	if len(sig) != 2*size {
+		return ErrInvalidSignature
+	}
+	r := new(big.Int).SetBytes(sig[:size])
+	s := new(big.Int).SetBytes(sig[size:])
+	if !ecdsa.Verify(pub, digest(hash, signingInput), r, s) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+func curveMatches(hash crypto.Hash, curve elliptic.Curve) bool {
+
+	// ES256必须使用P-256，ES384必须使用P-384
+	switch hash {
+	case crypto.SHA256:
+		return curve.Params().Name == elliptic.P256().Params().Name
+	case crypto.SHA384:
+		return curve.Params().Name == elliptic.P384().Params().Name
+	}
+	return false
+}
+
+func digest(hash crypto.Hash, data []byte) []byte {
+	h := hash.New()
+	h.Write(data)
+	return h.Sum(nil)
+}
@@ -0,0 +1,270 @@
+package extra
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+var (
+	ErrTokenMalformed        = errors.New("jwt: token is malformed")
+	ErrAlgNone               = errors.New("jwt: alg none is not allowed")
+	ErrAlgNotAllowed         = errors.New("jwt: alg is not allowed")
+	ErrInvalidKeyType        = errors.New("jwt: key type does not match alg")
+	ErrKeyNotFound           = errors.New("jwt: key not found")
+	ErrInvalidSignature      = errors.New("jwt: signature is invalid")
+	ErrTokenExpired          = errors.New("jwt: token is expired")
+	ErrTokenNotValidYet      = errors.New("jwt: token is not valid yet")
+	ErrTokenUsedBeforeIssued = errors.New("jwt: token used before issued")
+	ErrMissingExpiration     = errors.New("jwt: token has no exp claim")
+	ErrInvalidIssuer         = errors.New("jwt: token has invalid issuer")
+	ErrInvalidAudience       = errors.New("jwt: token has invalid audience")
+)
+
+// JOSE头部，RFC 7515 4.1
+type Header struct {
+	Alg string `json:"alg"`
+	Typ string `json:"typ,omitempty"`
+	Kid string `json:"kid,omitempty"`
+}
+
+// 注册声明，RFC 7519 4.1
+type Claims struct {
+	Issuer    string      `json:"iss,omitempty"`
+	Subject   string      `json:"sub,omitempty"`
+	Audience  Audience    `json:"aud,omitempty"`
+	ExpiresAt NumericDate `json:"exp,omitempty"`
+	NotBefore NumericDate `json:"nbf,omitempty"`
+	IssuedAt  NumericDate `json:"iat,omitempty"`
+	ID        string      `json:"jti,omitempty"`
+}
+
+// RFC 7519 2 NumericDate: 距1970-01-01T00:00:00Z的秒数，可以带小数
+type NumericDate float64
+
+// 按整秒签发
+func NewNumericDate(t time.Time) NumericDate {
+	return NumericDate(t.Unix())
+}
+
+func (d NumericDate) Time() time.Time {
+	sec := float64(d)
+	return time.Unix(0, int64(sec*float64(time.Second)))
+}
+
+// aud可以是单个字符串或字符串数组
+type Audience []string
+
+func (a Audience) MarshalJSON() ([]byte, error) {
+	if len(a) == 1 {
+		return json.Marshal(a[0])
+	}
+	return json.Marshal([]string(a))
+}
+
+func (a *Audience) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*a = Audience{s}
+		return nil
+	}
+	var arr []string
+	if err := json.Unmarshal(data, &arr); err != nil {
+		return err
+	}
+	*a = arr
+	return nil
+}
+
+func (a Audience) Contains(aud string) bool {
+	for _, v := range a {
+		if v == aud {
+			return true
+		}
+	}
+	return false
+}
+
+// 根据头部(主要是kid)返回验签使用的密钥
+type KeyResolver interface {
+	ResolveKey(header *Header) (interface{}, error)
+}
+
+// 函数适配为KeyResolver
+type KeyResolverFunc func(header *Header) (interface{}, error)
+
+func (f KeyResolverFunc) ResolveKey(header *Header) (interface{}, error) {
+	return f(header)
+}
+
+// 以kid为键的静态密钥集合
+type StaticKeys map[string]interface{}
+
+func (k StaticKeys) ResolveKey(header *Header) (interface{}, error) {
+	key, ok := k[header.Kid]
+	if !ok {
+		return nil, ErrKeyNotFound
+	}
+	return key, nil
+}
+
+func Sign(alg string, key interface{}, kid string, claims interface{}) (string, error) {
+
+	a, ok := algorithms[alg]
+	if !ok {
+		return "", ErrAlgNotAllowed
+	}
+
+	header, err := json.Marshal(Header{Alg: alg, Typ: "JWT", Kid: kid})
+	if err != nil {
+		return "", err
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+
+	// 签名输入为 BASE64URL(header) || '.' || BASE64URL(payload)，使用不带填充的URL安全base64
+	signingInput := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	sig, err := a.sign(a.hash, key, []byte(signingInput))
+	if err != nil {
+		return "", err
+	}
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(sig), nil
+}
+
+// 验证JWT的签名及注册声明
+type Verifier struct {
+	// 允许的算法列表，必须显式指定，防止算法混淆攻击
+	Algorithms []string
+	// 验签密钥查找
+	Resolver KeyResolver
+	// 时间校验允许的时钟偏差
+	Leeway time.Duration
+	// 不为空时校验iss
+	Issuer string
+	// 不为空时校验aud包含该值
+	Audience string
+	// 为true时要求必须存在exp
+	RequireExpiration bool
+	// 返回当前时间，为nil时使用time.Now
+	Now func() time.Time
+}
+
+func (v *Verifier) Verify(token string, claims interface{}) (*Header, error) {
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrTokenMalformed
+	}
+
+	// 解析头部
+	headerJSON, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, ErrTokenMalformed
+	}
+	var header Header
+	if err := json.Unmarshal(headerJSON, &header); err != nil {
+		return nil, ErrTokenMalformed
+	}
+
+	// 拒绝alg: none，且alg必须在允许列表中。不能信任头部中的alg来决定验签方式
+	if header.Alg == "" || strings.EqualFold(header.Alg, "none") {
+		return nil, ErrAlgNone
+	}
+	if !v.allowed(header.Alg) {
+		return nil, ErrAlgNotAllowed
+	}
+	a, ok := algorithms[header.Alg]
+	if !ok {
+		return nil, ErrAlgNotAllowed
+	}
+
+	// 根据kid查找密钥，密钥类型必须与alg匹配
+	if v.Resolver == nil {
+		return nil, ErrKeyNotFound
+	}
+	key, err := v.Resolver.ResolveKey(&header)
+	if err != nil {
+		return nil, err
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, ErrTokenMalformed
+	}
+	if err := a.check(a.hash, key, []byte(parts[0]+"."+parts[1]), sig); err != nil {
+		return nil, err
+	}
+
+	// 签名通过后再解析载荷
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, ErrTokenMalformed
+	}
+	var registered Claims
+	if err := decodeJSON(payload, &registered); err != nil {
+		return nil, ErrTokenMalformed
+	}
+	if err := v.validate(&registered); err != nil {
+		return nil, err
+	}
+	if claims != nil {
+		if err := decodeJSON(payload, claims); err != nil {
+			return nil, ErrTokenMalformed
+		}
+	}
+	return &header, nil
+}
+
+func (v *Verifier) allowed(alg string) bool {
+	for _, a := range v.Algorithms {
+		if a == alg {
+			return true
+		}
+	}
+	return false
+}
+
+func (v *Verifier) validate(c *Claims) error {
+
+	now := time.Now()
+	if v.Now != nil {
+		now = v.Now()
+	}
+	leeway := v.Leeway.Seconds()
+	unix := float64(now.UnixNano()) / float64(time.Second)
+
+	// exp: 当前时间 > exp + 偏差 即过期
+	if c.ExpiresAt == 0 && v.RequireExpiration {
+		return ErrMissingExpiration
+	}
+	if c.ExpiresAt != 0 && unix > float64(c.ExpiresAt)+leeway {
+		return ErrTokenExpired
+	}
+	// nbf: 当前时间 + 偏差 < nbf 即尚未生效
+	if c.NotBefore != 0 && unix+leeway < float64(c.NotBefore) {
+		return ErrTokenNotValidYet
+	}
+	// iat: 签发时间不能晚于当前时间
+	if c.IssuedAt != 0 && unix+leeway < float64(c.IssuedAt) {
+		return ErrTokenUsedBeforeIssued
+	}
+	if v.Issuer != "" && c.Issuer != v.Issuer {
+		return ErrInvalidIssuer
+	}
+	if v.Audience != "" && !c.Audience.Contains(v.Audience) {
+		return ErrInvalidAudience
+	}
+	return nil
+}
+
+func decodeJSON(data []byte, v interface{}) error {
+
+	// 使用json.Number保留数值精度
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	return dec.Decode(v)
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
+	"github.com/zc2638/go-standard/src/crypto/pkcs12/extra"
+	x509Extra "github.com/zc2638/go-standard/src/crypto/x509/extra"
+	"io/ioutil"
+	"log"
+	"net"
+	"time"
+)
+
+// 实现了RFC 7292规定的PKCS#12(.p12/.pfx)文件的读写
+// 读取支持PBES2与旧式的3DES、RC2算法，写入使用与OpenSSL 3相同的PBES2(AES-256-CBC)与HMAC-SHA256
+func main() {
+
+	// 读取OpenSSL旧格式(RC2-40与3DES)的文件
+	Legacy()
+	// 导出证书链与私钥，并转换为tls.Certificate使用
+	Export()
+}
+
+func Legacy() {
+
+	// openssl pkcs12 -export -legacy -in testdata/x509_cert.pem -inkey testdata/x509_key.pem -passout pass:secret
+	data, err := ioutil.ReadFile("testdata/pkcs12_legacy.p12")
+	if err != nil {
+		log.Fatal(err)
+	}
+	key, cert, caCerts, err := extra.Decode(data, "secret")
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("%T %s %d\n", key, cert.Subject, len(caCerts))
+
+	// 密码错误时MAC校验失败
+	if _, _, _, err := extra.Decode(data, "wrong"); err != nil {
+		fmt.Println(err)
+	}
+
+	// 转换为PEM，可直接用于tls.X509KeyPair
+	certPEM, keyPEM, err := extra.ToPEM(data, "secret")
+	if err != nil {
+		log.Fatal(err)
+	}
+	if _, err := tls.X509KeyPair(certPEM, keyPEM); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(string(certPEM))
+
+	// 同一文件的BER形式: 各层构造类型使用不定长编码，OCTET STRING与[0]加密内容分段，MAC按BER编码的AuthenticatedSafe重新计算
+	ber, err := ioutil.ReadFile("testdata/pkcs12_ber.p12")
+	if err != nil {
+		log.Fatal(err)
+	}
+	key, cert, _, err = extra.Decode(ber, "secret")
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("BER: %T %s\n", key, cert.Subject)
+}
+
+func Export() {
+
+	// 使用临时CA签发中间CA与终端证书
+	rootKey, _ := x509Extra.GenerateKey("p256")
+	root, err := x509Extra.NewRootCA(pkix.Name{CommonName: "PKCS12 Root CA"}, rootKey, 24*time.Hour, 1, nil)
+	if err != nil {
+		log.Fatal(err)
+	}
+	interKey, _ := x509Extra.GenerateKey("p256")
+	interCert, err := root.IssueIntermediate(pkix.Name{CommonName: "PKCS12 Intermediate CA"}, interKey.Public(), 12*time.Hour, 0)
+	if err != nil {
+		log.Fatal(err)
+	}
+	inter := &x509Extra.CA{Cert: interCert, Key: interKey, Parents: root.Chain(), Policy: x509Extra.DefaultPolicy()}
+	key, _ := x509Extra.GenerateKey("rsa2048")
+	der, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+		Subject:  pkix.Name{CommonName: "localhost"},
+		DNSNames: []string{"localhost"},
+	}, key)
+	if err != nil {
+		log.Fatal(err)
+	}
+	csr, _ := x509.ParseCertificateRequest(der)
+	leaf, err := inter.SignCSR(csr, x509Extra.LeafOptions{Validity: time.Hour})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// 导出包含中间证书的.p12文件，FriendlyName在Windows证书管理器中显示
+	encoder := &extra.Encoder{FriendlyName: "localhost", Iterations: 10000}
+	p12, err := encoder.Encode(key, leaf, []*x509.Certificate{interCert}, "changeit")
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println("p12 size:", len(p12))
+
+	// 读取为tls.Certificate，证书链包含中间证书
+	cert, err := extra.ToTLSCertificate(p12, "changeit")
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println("chain length:", len(cert.Certificate), cert.Leaf.Subject)
+
+	// 使用该证书完成一次TLS握手
+	roots, _ := root.Pools()
+	serverConn, clientConn := net.Pipe()
+	go func() {
+		conn := tls.Server(serverConn, &tls.Config{Certificates: []tls.Certificate{cert}})
+		conn.Handshake()
+		conn.Close()
+	}()
+	conn := tls.Client(clientConn, &tls.Config{RootCAs: roots, ServerName: "localhost"})
+	if err := conn.Handshake(); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println("handshake ok, verified chain:", len(conn.ConnectionState().VerifiedChains[0]))
+	conn.Close()
+
+	// tls.Certificate与PEM也可以直接转换为PKCS#12
+	if _, err := extra.FromTLSCertificate(cert, "changeit"); err != nil {
+		log.Fatal(err)
+	}
+	keyPEM, _ := x509Extra.EncodeKeyPEM(key)
+	if _, err := extra.FromPEM(inter.Bundle(leaf), keyPEM, ""); err != nil {
+		log.Fatal(err)
+	}
+
+	// 只读取其中的证书，例如Java信任库
+	certs, err := extra.DecodeTrustStore(p12, "changeit")
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println("certificates:", len(certs))
+}
@@ -0,0 +1,153 @@
+package extra
+
+import "errors"
+
+var errBER = errors.New("pkcs12: malformed BER data")
+
+// Windows与Java导出的文件常使用BER不定长编码，encoding/asn1只支持DER
+// 这里把不定长编码转换为定长编码，其余内容保持不变
+// OCTET STRING中封装的结构(AuthenticatedSafe、SafeContents等)不会被转换，解析前需要分别调用
+func berToDER(data []byte) ([]byte, error) {
+	der, rest, err := convertBER(data, 0)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) > 0 {
+		return nil, errors.New("pkcs12: trailing data after ASN.1 structure")
+	}
+	return der, nil
+}
+
+// 转换为DER后解析
+func unmarshalBER(data []byte, v interface{}) error {
+	der, err := berToDER(data)
+	if err != nil {
+		return err
+	}
+	return unmarshal(der, v)
+}
+
+func convertBER(data []byte, depth int) (der, rest []byte, err error) {
+
+	if depth > 64 || len(data) < 2 {
+		return nil, nil, errBER
+	}
+	constructed := data[0]&0x20 != 0
+	// 构造形式的字符串类型由多段组成，DER要求使用基本形式，需要合并各段内容
+	str := constructed && isStringTag(data[0])
+	pos := 1
+	// 高标签号格式
+	if data[0]&0x1f == 0x1f {
+		for pos < len(data) && data[pos]&0x80 != 0 {
+			pos++
+		}
+		pos++
+	}
+	if pos >= len(data) {
+		return nil, nil, errBER
+	}
+	tag := data[:pos]
+	l := data[pos]
+	pos++
+
+	var body []byte
+	switch {
+	case l == 0x80:
+		// 不定长编码，内容直到两个零字节
+		if !constructed {
+			return nil, nil, errBER
+		}
+		rest = data[pos:]
+		for {
+			if len(rest) >= 2 && rest[0] == 0 && rest[1] == 0 {
+				rest = rest[2:]
+				break
+			}
+			child, r, err := convertBER(rest, depth+1)
+			if err != nil {
+				return nil, nil, err
+			}
+			if body, err = appendChild(body, child, data[0], str); err != nil {
+				return nil, nil, err
+			}
+			rest = r
+		}
+	default:
+		n := int(l)
+		if l > 0x80 {
+			size := int(l & 0x7f)
+			if size > 4 || pos+size > len(data) {
+				return nil, nil, errBER
+			}
+			n = 0
+			for _, b := range data[pos : pos+size] {
+				n = n<<8 | int(b)
+			}
+			pos += size
+		}
+		if n < 0 || pos+n > len(data) {
+			return nil, nil, errBER
+		}
+		content := data[pos : pos+n]
+		rest = data[pos+n:]
+		if !constructed {
+			body = content
+			break
+		}
+		for len(content) > 0 {
+			child, r, err := convertBER(content, depth+1)
+			if err != nil {
+				return nil, nil, err
+			}
+			if body, err = appendChild(body, child, data[0], str); err != nil {
+				return nil, nil, err
+			}
+			content = r
+		}
+	}
+
+	if str {
+		tag = []byte{data[0] &^ 0x20}
+	}
+	der = append(append([]byte(nil), tag...), encodeLength(len(body))...)
+	return append(der, body...), rest, nil
+}
+
+// 通用类的OCTET STRING及字符串类型，BIT STRING的分段带有未使用位数，不做合并
+func isStringTag(tag byte) bool {
+	if tag&0xc0 != 0 {
+		return false
+	}
+	switch tag & 0x1f {
+	case 4, 12, 18, 19, 20, 21, 22, 25, 26, 27, 28, 30:
+		return true
+	}
+	return false
+}
+
+// 字符串的各段已转换为基本形式，类型须与外层相同，只取其内容
+func appendChild(body, child []byte, tag byte, str bool) ([]byte, error) {
+
+	if !str {
+		return append(body, child...), nil
+	}
+	if child[0] != tag&^0x20 {
+		return nil, errBER
+	}
+	header := 2
+	if child[1] > 0x80 {
+		header += int(child[1] & 0x7f)
+	}
+	return append(body, child[header:]...), nil
+}
+
+func encodeLength(n int) []byte {
+	if n < 0x80 {
+		return []byte{byte(n)}
+	}
+	var b []byte
+	for ; n > 0; n >>= 8 {
+		b = append([]byte{byte(n)}, b...)
+	}
+	return append([]byte{0x80 | byte(len(b))}, b...)
+}
@@ -0,0 +1,73 @@
+package extra
+
+import (
+	"crypto"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	x509Extra "github.com/zc2638/go-standard/src/crypto/x509/extra"
+)
+
+// 解码为tls.Certificate，证书链依次为终端证书与CA证书
+func ToTLSCertificate(data []byte, password string) (tls.Certificate, error) {
+	key, cert, cas, err := Decode(data, password)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	chain := [][]byte{cert.Raw}
+	for _, ca := range cas {
+		chain = append(chain, ca.Raw)
+	}
+	return tls.Certificate{Certificate: chain, PrivateKey: key, Leaf: cert}, nil
+}
+
+// 将tls.Certificate编码为PKCS#12
+func FromTLSCertificate(cert tls.Certificate, password string) ([]byte, error) {
+
+	if len(cert.Certificate) == 0 {
+		return nil, ErrNoCertificate
+	}
+	var certs []*x509.Certificate
+	for _, der := range cert.Certificate {
+		c, err := x509.ParseCertificate(der)
+		if err != nil {
+			return nil, err
+		}
+		certs = append(certs, c)
+	}
+	return Encode(cert.PrivateKey, certs[0], certs[1:], password)
+}
+
+// 解码为PEM: 终端证书、CA证书与PKCS#8私钥，可直接用于tls.X509KeyPair
+func ToPEM(data []byte, password string) (certPEM, keyPEM []byte, err error) {
+
+	key, cert, cas, err := Decode(data, password)
+	if err != nil {
+		return nil, nil, err
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, nil, errors.New("pkcs12: unsupported private key type")
+	}
+	if keyPEM, err = x509Extra.EncodeKeyPEM(signer); err != nil {
+		return nil, nil, err
+	}
+	return x509Extra.EncodeCertificates(append([]*x509.Certificate{cert}, cas...)...), keyPEM, nil
+}
+
+// 将PEM证书链与私钥编码为PKCS#12，私钥支持PKCS#8、PKCS#1与SEC1格式
+func FromPEM(certPEM, keyPEM []byte, password string) ([]byte, error) {
+
+	certs, err := x509Extra.ParseCertificates(certPEM)
+	if err != nil {
+		return nil, err
+	}
+	if len(certs) == 0 {
+		return nil, ErrNoCertificate
+	}
+	key, err := x509Extra.ParseKeyPEM(keyPEM)
+	if err != nil {
+		return nil, err
+	}
+	return Encode(key, certs[0], certs[1:], password)
+}
@@ -0,0 +1,112 @@
+package extra
+
+import (
+	"crypto/hmac"
+	"encoding/binary"
+	"errors"
+	"hash"
+	"unicode/utf16"
+)
+
+// 文件中的迭代次数不可信，过大的值会让解析耗费大量CPU
+// 常见工具使用2048至60万次，上限留出足够余量
+const maxIterations = 1 << 22
+
+var errIterations = errors.New("pkcs12: iteration count out of range")
+
+func checkIterations(n int) error {
+	if n < 1 || n > maxIterations {
+		return errIterations
+	}
+	return nil
+}
+
+// RFC 8018 5.2 PBKDF2，用于PBES2
+func pbkdf2(h func() hash.Hash, password, salt []byte, iterations, keyLen int) []byte {
+
+	prf := hmac.New(h, password)
+	var key []byte
+	for block := uint32(1); len(key) < keyLen; block++ {
+		prf.Reset()
+		prf.Write(salt)
+		binary.Write(prf, binary.BigEndian, block)
+		u := prf.Sum(nil)
+		t := append([]byte(nil), u...)
+		for i := 1; i < iterations; i++ {
+			prf.Reset()
+			prf.Write(u)
+			u = prf.Sum(u[:0])
+			for j := range t {
+				t[j] ^= u[j]
+			}
+		}
+		key = append(key, t...)
+	}
+	return key[:keyLen]
+}
+
+// RFC 7292 附录B.3 中ID字节的取值
+const (
+	kdfKey byte = 1
+	kdfIV  byte = 2
+	kdfMAC byte = 3
+)
+
+// RFC 7292 附录B.2 PKCS#12密钥派生，用于旧式PBE算法与MAC
+// u为摘要长度，v为摘要的分组长度
+func pkcs12KDF(h func() hash.Hash, v int, password, salt []byte, iterations int, id byte, size int) []byte {
+
+	d := make([]byte, v)
+	for i := range d {
+		d[i] = id
+	}
+	// I = S || P，二者分别重复填充到v的整数倍
+	in := append(fill(salt, v), fill(password, v)...)
+
+	var out []byte
+	for len(out) < size {
+		a := h()
+		a.Write(d)
+		a.Write(in)
+		sum := a.Sum(nil)
+		for i := 1; i < iterations; i++ {
+			a.Reset()
+			a.Write(sum)
+			sum = a.Sum(sum[:0])
+		}
+		out = append(out, sum...)
+
+		// 每个v字节的块 I_j = (I_j + B + 1) mod 2^(8v)
+		b := fill(sum, v)[:v]
+		for j := 0; j < len(in); j += v {
+			carry := 1
+			for k := v - 1; k >= 0; k-- {
+				n := int(in[j+k]) + int(b[k]) + carry
+				in[j+k] = byte(n)
+				carry = n >> 8
+			}
+		}
+	}
+	return out[:size]
+}
+
+func fill(data []byte, v int) []byte {
+	if len(data) == 0 {
+		return nil
+	}
+	out := make([]byte, v*((len(data)+v-1)/v))
+	for i := 0; i < len(out); i += len(data) {
+		copy(out[i:], data)
+	}
+	return out
+}
+
+// 旧式PBE与MAC使用以两个零字节结尾的UTF-16BE(BMPString)密码
+func bmpPassword(password string) []byte {
+	units := utf16.Encode([]rune(password))
+	out := make([]byte, 2*len(units)+2)
+	for i, u := range units {
+		binary.BigEndian.PutUint16(out[2*i:], u)
+	}
+	return out
+}
@@ -0,0 +1,220 @@
+package extra
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/des"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+)
+
+var (
+	// RFC 7292 附录C 旧式PBE算法
+	oidPBEWithSHAAnd3KeyTripleDESCBC = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 12, 1, 3}
+	oidPBEWithSHAAnd128BitRC2CBC     = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 12, 1, 5}
+	oidPBEWithSHAAnd40BitRC2CBC      = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 12, 1, 6}
+
+	// RFC 8018 PBES2
+	oidPBES2  = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 13}
+	oidPBKDF2 = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 12}
+
+	oidHMACWithSHA1   = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 7}
+	oidHMACWithSHA256 = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 9}
+	oidHMACWithSHA384 = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 10}
+	oidHMACWithSHA512 = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 11}
+
+	oidDESEDE3CBC = asn1.ObjectIdentifier{1, 2, 840, 113549, 3, 7}
+	oidAES128CBC  = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 2}
+	oidAES192CBC  = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 22}
+	oidAES256CBC  = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 42}
+)
+
+var ErrIncorrectPassword = errors.New("pkcs12: decryption password incorrect")
+
+type pbeParams struct {
+	Salt       []byte
+	Iterations int
+}
+
+type pbes2Params struct {
+	KDF    pkix.AlgorithmIdentifier
+	Scheme pkix.AlgorithmIdentifier
+}
+
+type pbkdf2Params struct {
+	Salt       []byte
+	Iterations int
+	KeyLength  int                      `asn1:"optional"`
+	PRF        pkix.AlgorithmIdentifier `asn1:"optional"`
+}
+
+// 旧式PBE算法的分组密码与密钥长度
+type pbeCipher struct {
+	keyLen int
+	block  func(key []byte) (cipher.Block, error)
+}
+
+var pbeCiphers = map[string]pbeCipher{
+	oidPBEWithSHAAnd3KeyTripleDESCBC.String(): {24, des.NewTripleDESCipher},
+	oidPBEWithSHAAnd128BitRC2CBC.String(): {16, func(key []byte) (cipher.Block, error) {
+		return newRC2(key, 128)
+	}},
+	oidPBEWithSHAAnd40BitRC2CBC.String(): {5, func(key []byte) (cipher.Block, error) {
+		return newRC2(key, 40)
+	}},
+}
+
+// PBES2中的对称加密算法与密钥长度
+var pbes2Ciphers = map[string]pbeCipher{
+	oidDESEDE3CBC.String(): {24, des.NewTripleDESCipher},
+	oidAES128CBC.String():  {16, aes.NewCipher},
+	oidAES192CBC.String():  {24, aes.NewCipher},
+	oidAES256CBC.String():  {32, aes.NewCipher},
+}
+
+var hmacHashes = map[string]func() hash.Hash{
+	oidHMACWithSHA1.String():   sha1.New,
+	oidHMACWithSHA256.String(): sha256.New,
+	oidHMACWithSHA384.String(): sha512.New384,
+	oidHMACWithSHA512.String(): sha512.New,
+}
+
+// 按算法标识解密，旧式PBE使用BMPString密码，PBES2使用UTF-8密码
+func decrypt(alg pkix.AlgorithmIdentifier, data []byte, password string) ([]byte, error) {
+
+	block, iv, err := decryptionCipher(alg, password)
+	if err != nil {
+		return nil, err
+	}
+	bs := block.BlockSize()
+	if len(data) == 0 || len(data)%bs != 0 {
+		return nil, errors.New("pkcs12: encrypted data is not a multiple of the block size")
+	}
+	out := make([]byte, len(data))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(out, data)
+
+	// PKCS#7填充错误通常是密码错误导致的
+	n := int(out[len(out)-1])
+	if n == 0 || n > bs || !bytes.Equal(out[len(out)-n:], bytes.Repeat([]byte{byte(n)}, n)) {
+		return nil, ErrIncorrectPassword
+	}
+	return out[:len(out)-n], nil
+}
+
+func decryptionCipher(alg pkix.AlgorithmIdentifier, password string) (cipher.Block, []byte, error) {
+
+	if c, ok := pbeCiphers[alg.Algorithm.String()]; ok {
+		var params pbeParams
+		if err := unmarshal(alg.Parameters.FullBytes, &params); err != nil {
+			return nil, nil, err
+		}
+		if err := checkIterations(params.Iterations); err != nil {
+			return nil, nil, err
+		}
+		pw := bmpPassword(password)
+		key := pkcs12KDF(sha1.New, 64, pw, params.Salt, params.Iterations, kdfKey, c.keyLen)
+		iv := pkcs12KDF(sha1.New, 64, pw, params.Salt, params.Iterations, kdfIV, 8)
+		block, err := c.block(key)
+		return block, iv, err
+	}
+	if !alg.Algorithm.Equal(oidPBES2) {
+		return nil, nil, fmt.Errorf("pkcs12: unsupported encryption algorithm %v", alg.Algorithm)
+	}
+
+	var params pbes2Params
+	if err := unmarshal(alg.Parameters.FullBytes, &params); err != nil {
+		return nil, nil, err
+	}
+	if !params.KDF.Algorithm.Equal(oidPBKDF2) {
+		return nil, nil, fmt.Errorf("pkcs12: unsupported key derivation function %v", params.KDF.Algorithm)
+	}
+	var kdf pbkdf2Params
+	if err := unmarshal(params.KDF.Parameters.FullBytes, &kdf); err != nil {
+		return nil, nil, err
+	}
+	if err := checkIterations(kdf.Iterations); err != nil {
+		return nil, nil, err
+	}
+	// PRF缺省为hmacWithSHA1
+	prf := sha1.New
+	if len(kdf.PRF.Algorithm) > 0 {
+		var ok bool
+		if prf, ok = hmacHashes[kdf.PRF.Algorithm.String()]; !ok {
+			return nil, nil, fmt.Errorf("pkcs12: unsupported PBKDF2 PRF %v", kdf.PRF.Algorithm)
+		}
+	}
+	c, ok := pbes2Ciphers[params.Scheme.Algorithm.String()]
+	if !ok {
+		return nil, nil, fmt.Errorf("pkcs12: unsupported encryption scheme %v", params.Scheme.Algorithm)
+	}
+	var iv []byte
+	if err := unmarshal(params.Scheme.Parameters.FullBytes, &iv); err != nil {
+		return nil, nil, err
+	}
+	block, err := c.block(pbkdf2(prf, []byte(password), kdf.Salt, kdf.Iterations, c.keyLen))
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(iv) != block.BlockSize() {
+		return nil, nil, errors.New("pkcs12: invalid IV length")
+	}
+	return block, iv, nil
+}
+
+// 使用PBES2(PBKDF2-HMAC-SHA256与AES-256-CBC)加密，与OpenSSL 3的默认算法相同
+func encrypt(rand io.Reader, data []byte, password string, iterations int) (pkix.AlgorithmIdentifier, []byte, error) {
+
+	salt := make([]byte, 16)
+	iv := make([]byte, aes.BlockSize)
+	if _, err := io.ReadFull(rand, salt); err != nil {
+		return pkix.AlgorithmIdentifier{}, nil, err
+	}
+	if _, err := io.ReadFull(rand, iv); err != nil {
+		return pkix.AlgorithmIdentifier{}, nil, err
+	}
+	kdf, err := asn1.Marshal(pbkdf2Params{
+		Salt:       salt,
+		Iterations: iterations,
+		PRF:        pkix.AlgorithmIdentifier{Algorithm: oidHMACWithSHA256, Parameters: asn1.NullRawValue},
+	})
+	if err != nil {
+		return pkix.AlgorithmIdentifier{}, nil, err
+	}
+	ivDER, err := asn1.Marshal(iv)
+	if err != nil {
+		return pkix.AlgorithmIdentifier{}, nil, err
+	}
+	params, err := asn1.Marshal(pbes2Params{
+		KDF:    pkix.AlgorithmIdentifier{Algorithm: oidPBKDF2, Parameters: asn1.RawValue{FullBytes: kdf}},
+		Scheme: pkix.AlgorithmIdentifier{Algorithm: oidAES256CBC, Parameters: asn1.RawValue{FullBytes: ivDER}},
+	})
+	if err != nil {
+		return pkix.AlgorithmIdentifier{}, nil, err
+	}
+
+	block, err := aes.NewCipher(pbkdf2(sha256.New, []byte(password), salt, iterations, 32))
+	if err != nil {
+		return pkix.AlgorithmIdentifier{}, nil, err
+	}
+	n := aes.BlockSize - len(data)%aes.BlockSize
+	out := append(append([]byte(nil), data...), bytes.Repeat([]byte{byte(n)}, n)...)
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(out, out)
+	return pkix.AlgorithmIdentifier{Algorithm: oidPBES2, Parameters: asn1.RawValue{FullBytes: params}}, out, nil
+}
+
+// 不允许多余数据的asn1.Unmarshal
+func unmarshal(data []byte, v interface{}) error {
+	rest, err := asn1.Unmarshal(data, v)
+	if err == nil && len(rest) > 0 {
+		return errors.New("pkcs12: trailing data after ASN.1 structure")
+	}
+	return err
+}
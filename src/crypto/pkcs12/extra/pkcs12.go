@@ -0,0 +1,464 @@
+package extra
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"unicode/utf16"
+)
+
+var (
+	oidDataContentType          = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 1}
+	oidEncryptedDataContentType = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 6}
+
+	// RFC 7292 4.2 SafeBag类型
+	oidKeyBag              = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 12, 10, 1, 1}
+	oidPKCS8ShroudedKeyBag = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 12, 10, 1, 2}
+	oidCertBag             = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 12, 10, 1, 3}
+	oidCertTypeX509        = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 22, 1}
+
+	oidFriendlyName = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 20}
+	oidLocalKeyID   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 21}
+
+	oidSHA1   = asn1.ObjectIdentifier{1, 3, 14, 3, 2, 26}
+	oidSHA256 = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1}
+)
+
+var (
+	ErrNoCertificate = errors.New("pkcs12: no certificate found")
+	ErrNoPrivateKey  = errors.New("pkcs12: no private key found")
+	ErrKeyMismatch   = errors.New("pkcs12: private key does not match any certificate")
+)
+
+// MAC摘要算法与PKCS#12密钥派生时的分组长度
+var macHashes = map[string]struct {
+	h func() hash.Hash
+	v int
+}{
+	oidSHA1.String():   {sha1.New, 64},
+	oidSHA256.String(): {sha256.New, 64},
+}
+
+type pfxPdu struct {
+	Version  int
+	AuthSafe contentInfo
+	MacData  macData `asn1:"optional"`
+}
+
+type contentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     asn1.RawValue `asn1:"tag:0,explicit,optional"`
+}
+
+type encryptedData struct {
+	Version              int
+	EncryptedContentInfo encryptedContentInfo
+}
+
+type encryptedContentInfo struct {
+	ContentType                asn1.ObjectIdentifier
+	ContentEncryptionAlgorithm pkix.AlgorithmIdentifier
+	// [0] IMPLICIT OCTET STRING，BER编码时可能是分段的构造形式
+	EncryptedContent asn1.RawValue `asn1:"tag:0,optional"`
+}
+
+type macData struct {
+	Mac        digestInfo
+	MacSalt    []byte
+	Iterations int `asn1:"optional,default:1"`
+}
+
+type digestInfo struct {
+	Algorithm pkix.AlgorithmIdentifier
+	Digest    []byte
+}
+
+type safeBag struct {
+	ID         asn1.ObjectIdentifier
+	Value      asn1.RawValue     `asn1:"tag:0,explicit"`
+	Attributes []pkcs12Attribute `asn1:"set,optional"`
+}
+
+type pkcs12Attribute struct {
+	ID    asn1.ObjectIdentifier
+	Value asn1.RawValue `asn1:"set"`
+}
+
+type certBag struct {
+	ID   asn1.ObjectIdentifier
+	Data []byte `asn1:"tag:0,explicit"`
+}
+
+type encryptedPrivateKeyInfo struct {
+	Algorithm     pkix.AlgorithmIdentifier
+	EncryptedData []byte
+}
+
+// 解码PKCS#12文件，返回私钥、与私钥匹配的证书以及其余的CA证书
+// 支持PBES2(AES、3DES)与旧式的PBE-SHA1-3DES、PBE-SHA1-RC2算法，存在MAC时先校验MAC
+func Decode(data []byte, password string) (crypto.PrivateKey, *x509.Certificate, []*x509.Certificate, error) {
+
+	keys, certs, err := decodeBags(data, password)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if len(keys) == 0 {
+		return nil, nil, nil, ErrNoPrivateKey
+	}
+	if len(certs) == 0 {
+		return nil, nil, nil, ErrNoCertificate
+	}
+	key := keys[0]
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, nil, nil, errors.New("pkcs12: unsupported private key type")
+	}
+	pub, err := x509.MarshalPKIXPublicKey(signer.Public())
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	// 按公钥找出终端证书，文件中的证书顺序不可靠
+	var leaf *x509.Certificate
+	var cas []*x509.Certificate
+	for _, cert := range certs {
+		if leaf == nil && string(cert.RawSubjectPublicKeyInfo) == string(pub) {
+			leaf = cert
+		} else {
+			cas = append(cas, cert)
+		}
+	}
+	if leaf == nil {
+		return nil, nil, nil, ErrKeyMismatch
+	}
+	return key, leaf, cas, nil
+}
+
+// 只包含证书的文件，常用于Java信任库
+func DecodeTrustStore(data []byte, password string) ([]*x509.Certificate, error) {
+	_, certs, err := decodeBags(data, password)
+	if err != nil {
+		return nil, err
+	}
+	if len(certs) == 0 {
+		return nil, ErrNoCertificate
+	}
+	return certs, nil
+}
+
+func decodeBags(data []byte, password string) ([]crypto.PrivateKey, []*x509.Certificate, error) {
+
+	der, err := berToDER(data)
+	if err != nil {
+		return nil, nil, err
+	}
+	var pfx pfxPdu
+	if err := unmarshal(der, &pfx); err != nil {
+		return nil, nil, fmt.Errorf("pkcs12: %v", err)
+	}
+	if pfx.Version != 3 {
+		return nil, nil, fmt.Errorf("pkcs12: unsupported version %d", pfx.Version)
+	}
+	if !pfx.AuthSafe.ContentType.Equal(oidDataContentType) {
+		return nil, nil, errors.New("pkcs12: only password integrity mode is supported")
+	}
+	authSafe, err := contentData(pfx.AuthSafe)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(pfx.MacData.Mac.Algorithm.Algorithm) > 0 {
+		if err := verifyMAC(&pfx.MacData, authSafe, password); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	// MAC按原始字节计算，校验之后再转换封装的内容
+	var contents []contentInfo
+	if err := unmarshalBER(authSafe, &contents); err != nil {
+		return nil, nil, err
+	}
+	var keys []crypto.PrivateKey
+	var certs []*x509.Certificate
+	for _, ci := range contents {
+		var safeContents []byte
+		switch {
+		case ci.ContentType.Equal(oidDataContentType):
+			if safeContents, err = contentData(ci); err != nil {
+				return nil, nil, err
+			}
+		case ci.ContentType.Equal(oidEncryptedDataContentType):
+			var ed encryptedData
+			if err := unmarshal(ci.Content.Bytes, &ed); err != nil {
+				return nil, nil, err
+			}
+			info := ed.EncryptedContentInfo
+			encrypted, err := implicitOctets(info.EncryptedContent)
+			if err != nil {
+				return nil, nil, err
+			}
+			if safeContents, err = decrypt(info.ContentEncryptionAlgorithm, encrypted, password); err != nil {
+				return nil, nil, err
+			}
+		default:
+			return nil, nil, fmt.Errorf("pkcs12: unsupported content type %v", ci.ContentType)
+		}
+
+		var bags []safeBag
+		if err := unmarshalBER(safeContents, &bags); err != nil {
+			return nil, nil, err
+		}
+		for _, bag := range bags {
+			switch {
+			case bag.ID.Equal(oidCertBag):
+				var cb certBag
+				if err := unmarshal(bag.Value.Bytes, &cb); err != nil {
+					return nil, nil, err
+				}
+				if !cb.ID.Equal(oidCertTypeX509) {
+					continue
+				}
+				cert, err := x509.ParseCertificate(cb.Data)
+				if err != nil {
+					return nil, nil, err
+				}
+				certs = append(certs, cert)
+			case bag.ID.Equal(oidKeyBag):
+				key, err := x509.ParsePKCS8PrivateKey(bag.Value.Bytes)
+				if err != nil {
+					return nil, nil, err
+				}
+				keys = append(keys, key)
+			case bag.ID.Equal(oidPKCS8ShroudedKeyBag):
+				var info encryptedPrivateKeyInfo
+				if err := unmarshal(bag.Value.Bytes, &info); err != nil {
+					return nil, nil, err
+				}
+				plain, err := decrypt(info.Algorithm, info.EncryptedData, password)
+				if err != nil {
+					return nil, nil, err
+				}
+				if plain, err = berToDER(plain); err != nil {
+					return nil, nil, err
+				}
+				key, err := x509.ParsePKCS8PrivateKey(plain)
+				if err != nil {
+					return nil, nil, err
+				}
+				keys = append(keys, key)
+			}
+		}
+	}
+	return keys, certs, nil
+}
+
+// 隐式标签的OCTET STRING内容，构造形式时拼接各段
+// 外层已转换为DER，各段是基本形式的OCTET STRING
+func implicitOctets(raw asn1.RawValue) ([]byte, error) {
+
+	if !raw.IsCompound {
+		return raw.Bytes, nil
+	}
+	var out []byte
+	for rest := raw.Bytes; len(rest) > 0; {
+		var segment []byte
+		var err error
+		if rest, err = asn1.Unmarshal(rest, &segment); err != nil {
+			return nil, err
+		}
+		out = append(out, segment...)
+	}
+	return out, nil
+}
+
+// data类型ContentInfo中的OCTET STRING内容
+func contentData(ci contentInfo) ([]byte, error) {
+	var data []byte
+	if err := unmarshal(ci.Content.Bytes, &data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+func verifyMAC(md *macData, content []byte, password string) error {
+
+	alg, ok := macHashes[md.Mac.Algorithm.Algorithm.String()]
+	if !ok {
+		return fmt.Errorf("pkcs12: unsupported MAC algorithm %v", md.Mac.Algorithm.Algorithm)
+	}
+	if err := checkIterations(md.Iterations); err != nil {
+		return err
+	}
+	passwords := [][]byte{bmpPassword(password)}
+	// 空密码有两种编码方式，不同工具的实现不一致
+	if password == "" {
+		passwords = append(passwords, nil)
+	}
+	for _, pw := range passwords {
+		if hmac.Equal(computeMAC(alg.h, alg.v, content, pw, md.MacSalt, md.Iterations), md.Mac.Digest) {
+			return nil
+		}
+	}
+	return ErrIncorrectPassword
+}
+
+func computeMAC(h func() hash.Hash, v int, content, password, salt []byte, iterations int) []byte {
+	key := pkcs12KDF(h, v, password, salt, iterations, kdfMAC, h().Size())
+	mac := hmac.New(h, key)
+	mac.Write(content)
+	return mac.Sum(nil)
+}
+
+// PKCS#12编码参数
+type Encoder struct {
+	// PBKDF2与MAC的迭代次数，为0时使用2048，与OpenSSL相同
+	Iterations int
+	// 终端证书与私钥的名称，部分系统导入时显示该名称
+	FriendlyName string
+	// 为nil时使用crypto/rand
+	Rand io.Reader
+}
+
+// 使用默认参数编码
+func Encode(key crypto.PrivateKey, cert *x509.Certificate, caCerts []*x509.Certificate, password string) ([]byte, error) {
+	return (&Encoder{}).Encode(key, cert, caCerts, password)
+}
+
+// 编码为PKCS#12文件: 证书与私钥分别使用PBES2(AES-256-CBC)加密，完整性使用HMAC-SHA256
+func (e *Encoder) Encode(key crypto.PrivateKey, cert *x509.Certificate, caCerts []*x509.Certificate, password string) ([]byte, error) {
+
+	random := e.Rand
+	if random == nil {
+		random = rand.Reader
+	}
+	iterations := e.Iterations
+	if iterations == 0 {
+		iterations = 2048
+	}
+
+	// 通过localKeyId关联终端证书与私钥
+	keyID := sha1.Sum(cert.Raw)
+	attrs, err := e.attributes(keyID[:])
+	if err != nil {
+		return nil, err
+	}
+
+	var certBags []safeBag
+	for i, c := range append([]*x509.Certificate{cert}, caCerts...) {
+		value, err := asn1.Marshal(certBag{ID: oidCertTypeX509, Data: c.Raw})
+		if err != nil {
+			return nil, err
+		}
+		bag := safeBag{ID: oidCertBag, Value: explicitContent(value)}
+		if i == 0 {
+			bag.Attributes = attrs
+		}
+		certBags = append(certBags, bag)
+	}
+	certContents, err := asn1.Marshal(certBags)
+	if err != nil {
+		return nil, err
+	}
+	alg, encrypted, err := encrypt(random, certContents, password, iterations)
+	if err != nil {
+		return nil, err
+	}
+	ed, err := asn1.Marshal(encryptedData{EncryptedContentInfo: encryptedContentInfo{
+		ContentType:                oidDataContentType,
+		ContentEncryptionAlgorithm: alg,
+		EncryptedContent:           asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, Bytes: encrypted},
+	}})
+	if err != nil {
+		return nil, err
+	}
+
+	pkcs8, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+	alg, encrypted, err = encrypt(random, pkcs8, password, iterations)
+	if err != nil {
+		return nil, err
+	}
+	shrouded, err := asn1.Marshal(encryptedPrivateKeyInfo{Algorithm: alg, EncryptedData: encrypted})
+	if err != nil {
+		return nil, err
+	}
+	keyContents, err := asn1.Marshal([]safeBag{{ID: oidPKCS8ShroudedKeyBag, Value: explicitContent(shrouded), Attributes: attrs}})
+	if err != nil {
+		return nil, err
+	}
+	keyInfo, err := dataContentInfo(keyContents)
+	if err != nil {
+		return nil, err
+	}
+
+	authSafe, err := asn1.Marshal([]contentInfo{
+		{ContentType: oidEncryptedDataContentType, Content: explicitContent(ed)},
+		keyInfo,
+	})
+	if err != nil {
+		return nil, err
+	}
+	pfx := pfxPdu{Version: 3}
+	if pfx.AuthSafe, err = dataContentInfo(authSafe); err != nil {
+		return nil, err
+	}
+
+	salt := make([]byte, 16)
+	if _, err := io.ReadFull(random, salt); err != nil {
+		return nil, err
+	}
+	pfx.MacData = macData{
+		Mac: digestInfo{
+			Algorithm: pkix.AlgorithmIdentifier{Algorithm: oidSHA256, Parameters: asn1.NullRawValue},
+			Digest:    computeMAC(sha256.New, 64, authSafe, bmpPassword(password), salt, iterations),
+		},
+		MacSalt:    salt,
+		Iterations: iterations,
+	}
+	return asn1.Marshal(pfx)
+}
+
+func (e *Encoder) attributes(keyID []byte) ([]pkcs12Attribute, error) {
+
+	value, err := asn1.Marshal(keyID)
+	if err != nil {
+		return nil, err
+	}
+	attrs := []pkcs12Attribute{{ID: oidLocalKeyID, Value: asn1.RawValue{Class: asn1.ClassUniversal, Tag: asn1.TagSet, IsCompound: true, Bytes: value}}}
+	if e.FriendlyName != "" {
+		// friendlyName为BMPString
+		units := utf16.Encode([]rune(e.FriendlyName))
+		bmp := make([]byte, 2*len(units))
+		for i, u := range units {
+			bmp[2*i], bmp[2*i+1] = byte(u>>8), byte(u)
+		}
+		value, err := asn1.Marshal(asn1.RawValue{Class: asn1.ClassUniversal, Tag: asn1.TagBMPString, Bytes: bmp})
+		if err != nil {
+			return nil, err
+		}
+		attrs = append(attrs, pkcs12Attribute{ID: oidFriendlyName, Value: asn1.RawValue{Class: asn1.ClassUniversal, Tag: asn1.TagSet, IsCompound: true, Bytes: value}})
+	}
+	return attrs, nil
+}
+
+func dataContentInfo(data []byte) (contentInfo, error) {
+	octets, err := asn1.Marshal(data)
+	if err != nil {
+		return contentInfo{}, err
+	}
+	return contentInfo{ContentType: oidDataContentType, Content: explicitContent(octets)}, nil
+}
+
+// encoding/asn1对RawValue字段忽略explicit标记，需要自行构造[0]标签
+func explicitContent(der []byte) asn1.RawValue {
+	return asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: der}
+}
@@ -3,8 +3,11 @@ package main
 import (
 	"crypto/rand"
 	"fmt"
+	"github.com/zc2638/go-standard/src/crypto/rand/extra"
 	"log"
 	"math/big"
+	"strings"
+	"time"
 )
 
 // 实现了用于加解密的更安全的随机数生成器
@@ -31,4 +34,122 @@ func main() {
 		log.Fatal(err)
 	}
 	fmt.Println(p.Bytes())
+
+	// 自定义字符集的随机字符串及token
+	RandomString()
+	// 便于人工输入的编码
+	HumanCode()
+	// 口令与密码
+	PasswordGen()
+	// UUIDv4、UUIDv7及ULID
+	UniqueID()
+}
+
+func RandomString() {
+
+	// 拒绝采样，每个字符等概率出现
+	s, err := extra.String(extra.Alphanumeric, 32)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println("随机字符串: ", s)
+
+	// 32字节随机数的URL安全token，可作为API Key
+	token, err := extra.Token(32)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println("API Key: ", "sk_"+token)
+
+	// 统计分布: 3个字符的字符集，每个字符出现约1/3
+	counts := map[rune]int{}
+	s, _ = extra.String("abc", 30000)
+	for _, c := range s {
+		counts[c]++
+	}
+	fmt.Println("字符分布: ", counts)
+}
+
+func HumanCode() {
+
+	// 12位邀请码，每4位一组，末尾追加校验位
+	code, err := extra.Code(12, 4, true)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println("邀请码: ", code)
+
+	// 用户输入时大小写、分隔符以及I/L/O的误输入都会被规范化
+	body, err := extra.VerifyCode(strings.ToLower(code))
+	fmt.Println("校验邀请码: ", body, err)
+
+	// 输错一位字符
+	wrong := []byte(code)
+	if wrong[0] == 'A' {
+		wrong[0] = 'B'
+	} else {
+		wrong[0] = 'A'
+	}
+	_, err = extra.VerifyCode(string(wrong))
+	fmt.Println("输错一位: ", err)
+}
+
+func PasswordGen() {
+
+	phrase, err := extra.Passphrase(6, "-", nil)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("口令: %s (%.1f bits)\n", phrase, extra.PassphraseEntropy(6, nil))
+
+	pwd, err := extra.Password(extra.DefaultPolicy)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println("密码: ", pwd)
+
+	// 只包含字母与数字，至少2位数字，排除容易混淆的字符
+	pwd, err = extra.Password(extra.Policy{Length: 10, MinDigits: 2, MinSymbols: -1, ExcludeAmbiguous: true})
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println("无符号密码: ", pwd)
+}
+
+func UniqueID() {
+
+	v4, err := extra.NewV4()
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println("UUIDv4: ", v4, v4.Version())
+
+	// UUIDv7按时间排序，适合作为数据库主键
+	var prev string
+	for i := 0; i < 3; i++ {
+		v7, err := extra.NewV7()
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println("UUIDv7: ", v7, v7.Time().Format(time.RFC3339Nano), v7.String() > prev)
+		prev = v7.String()
+	}
+	parsed, err := extra.ParseUUID(prev)
+	fmt.Println("解析UUID: ", parsed.String() == prev, err)
+
+	prev = ""
+	for i := 0; i < 3; i++ {
+		id, err := extra.NewULID()
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println("ULID: ", id, id.String() > prev)
+		prev = id.String()
+	}
+	id, err := extra.ParseULID(prev)
+	fmt.Println("解析ULID: ", id.String() == prev, id.Time().Format(time.RFC3339Nano), err)
+
+	// 规范中的示例 01ARZ3NDEKTSV4RRFFQ69G5FAV 对应时间 2016-07-30T23:54:10.259Z
+	id, err = extra.ParseULID("01ARZ3NDEKTSV4RRFFQ69G5FAV")
+	fmt.Println("ULID示例: ", id.String(), id.Time().UTC().Format(time.RFC3339Nano), err)
 }
@@ -0,0 +1,85 @@
+package extra
+
+import (
+	"errors"
+	"strings"
+)
+
+// Crockford base32字符集，去掉了容易混淆的I、L、O、U
+// https://www.crockford.com/base32.html
+const Crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// 校验位使用模37，额外的5个符号表示32~36
+const crockfordCheck = Crockford + "*~$=U"
+
+var ErrInvalidCode = errors.New("rand: invalid code")
+
+// 生成便于人工输入的邀请码、兑换码等，字符按groupSize分组并以'-'连接
+// checkDigit为true时末尾追加一位Crockford校验位，可以在查库前发现大部分输入错误
+func Code(length, groupSize int, checkDigit bool) (string, error) {
+
+	s, err := String(Crockford, length)
+	if err != nil {
+		return "", err
+	}
+	if checkDigit {
+		s += string(crockfordCheck[checksum(s)])
+	}
+	if groupSize <= 0 {
+		return s, nil
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if i > 0 && i%groupSize == 0 {
+			b.WriteByte('-')
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String(), nil
+}
+
+// 规范化用户输入的编码: 去掉分隔符、转为大写，并将I/L映射为1、O映射为0
+func NormalizeCode(code string) string {
+	var b strings.Builder
+	for _, c := range strings.ToUpper(code) {
+		switch c {
+		case '-', ' ':
+			continue
+		case 'I', 'L':
+			c = '1'
+		case 'O':
+			c = '0'
+		}
+		b.WriteRune(c)
+	}
+	return b.String()
+}
+
+// 校验带校验位的编码，返回规范化后不含校验位的编码
+func VerifyCode(code string) (string, error) {
+
+	s := NormalizeCode(code)
+	if len(s) < 2 {
+		return "", ErrInvalidCode
+	}
+	body, check := s[:len(s)-1], s[len(s)-1]
+	for i := 0; i < len(body); i++ {
+		if strings.IndexByte(Crockford, body[i]) < 0 {
+			return "", ErrInvalidCode
+		}
+	}
+	if crockfordCheck[checksum(body)] != check {
+		return "", ErrInvalidCode
+	}
+	return body, nil
+}
+
+// 将编码视为32进制整数，计算其模37的值
+func checksum(s string) int {
+	sum := 0
+	for i := 0; i < len(s); i++ {
+		sum = (sum*32 + strings.IndexByte(Crockford, s[i])) % 37
+	}
+	return sum
+}
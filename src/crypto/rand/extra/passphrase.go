@@ -0,0 +1,81 @@
+package extra
+
+import (
+	"errors"
+	"math"
+	"strings"
+)
+
+// 内置的简短英文词表，均为小写且互不重复，每个词约8.5位熵
+// 对安全性要求较高时可以传入EFF长词表(7776词，每词约12.9位熵)
+var DefaultWords = []string{
+	"acid", "acorn", "actor", "adobe", "aged", "agent", "album", "alert", "alley", "amber",
+	"ample", "angel", "ankle", "apple", "april", "apron", "arena", "armor", "army", "arrow",
+	"atlas", "atom", "attic", "audio", "autumn", "avid", "award", "axis", "bacon", "badge",
+	"bagel", "baker", "bamboo", "banjo", "barn", "basil", "basin", "beach", "beard", "bench",
+	"berry", "bike", "birch", "bison", "blade", "blank", "blaze", "blend", "bliss", "bloom",
+	"blues", "board", "boat", "bonus", "boots", "brave", "bread", "brick", "bride", "brook",
+	"brush", "bubble", "bucket", "cabin", "cable", "cactus", "camel", "candy", "canoe", "canvas",
+	"cargo", "carol", "carpet", "cedar", "chalk", "charm", "chess", "chief", "chili", "chirp",
+	"cider", "cinema", "citrus", "clay", "cliff", "clock", "cloud", "clover", "coach", "cobra",
+	"cocoa", "comet", "coral", "cosmic", "cotton", "crane", "crisp", "crown", "cubic", "daisy",
+	"dance", "delta", "denim", "depot", "desert", "diary", "dime", "dingo", "disco", "dock",
+	"dolphin", "donut", "dove", "dozen", "dragon", "dream", "drift", "drum", "dune", "eagle",
+	"early", "easel", "echo", "eclipse", "elbow", "elder", "ember", "empty", "enjoy", "equal",
+	"essay", "ethic", "fable", "fairy", "falcon", "fancy", "fern", "ferry", "fiber", "field",
+	"flame", "flask", "fleet", "flint", "flute", "focus", "forest", "fossil", "fox", "frame",
+	"fresh", "frost", "fudge", "fungi", "galaxy", "garden", "gecko", "giant", "ginger", "glade",
+	"globe", "glove", "gold", "grape", "gravel", "green", "guava", "guitar", "habit", "hammer",
+	"harbor", "harp", "hazel", "heart", "hedge", "helium", "hero", "hiker", "honey", "hotel",
+	"humble", "husky", "igloo", "index", "ink", "island", "ivory", "jacket", "jade", "jaguar",
+	"jazz", "jelly", "jewel", "jockey", "joke", "judge", "juice", "jumbo", "jungle", "karma",
+	"kayak", "kettle", "kiwi", "koala", "label", "ladder", "lagoon", "lake", "lamp", "lava",
+	"lemon", "level", "lilac", "lime", "linen", "lion", "llama", "lobby", "locket", "lotus",
+	"lucky", "lunar", "lyric", "magnet", "mango", "maple", "marble", "meadow", "melon", "metro",
+	"mint", "mocha", "model", "moose", "mosaic", "motel", "mural", "music", "nectar", "needle",
+	"noble", "noodle", "north", "novel", "nutmeg", "oasis", "ocean", "olive", "omega", "onion",
+	"opera", "orbit", "orchid", "otter", "oven", "owl", "oyster", "paddle", "palm", "panda",
+	"paper", "parade", "peach", "pearl", "pebble", "pepper", "piano", "pilot", "pixel", "plaza",
+	"plum", "polar", "pony", "poppy", "prism", "pulse", "puzzle", "quail", "quartz", "quest",
+	"quiet", "quilt", "rabbit", "radar", "radio", "raven", "relic", "ribbon", "river", "robin",
+	"rocket", "rose", "ruby", "rustic", "saddle", "salad", "salmon", "sandal", "satin", "scarf",
+	"scout", "shadow", "shell", "silver", "sketch", "sky", "slate", "snow", "sofa", "solar",
+	"spark", "spice", "spoon", "spruce", "squid", "stone", "storm", "sugar", "summit", "sunny",
+	"swan", "syrup", "table", "tango", "temple", "thorn", "tiger", "timber", "toast", "topaz",
+	"torch", "tower", "trail", "tulip", "tundra", "turtle", "twig", "ultra", "umbrella", "unity",
+	"urban", "valley", "velvet", "venom", "violet", "viper", "vivid", "voyage", "waffle", "walnut",
+	"wave", "willow", "window", "winter", "wizard", "wolf", "yacht", "yarn", "yeast", "yogurt",
+	"zebra", "zenith", "zinc", "zone",
+}
+
+// 从词表words中无偏地选取count个词，以sep连接。words为nil时使用DefaultWords
+func Passphrase(count int, sep string, words []string) (string, error) {
+
+	if count <= 0 {
+		return "", ErrInvalidLength
+	}
+
+	if words == nil {
+		words = DefaultWords
+	}
+	if len(words) < 2 {
+		return "", errors.New("rand: word list is too short")
+	}
+	out := make([]string, count)
+	for i := range out {
+		idx, err := Intn(len(words))
+		if err != nil {
+			return "", err
+		}
+		out[i] = words[idx]
+	}
+	return strings.Join(out, sep), nil
+}
+
+// 返回由count个词组成的口令的熵(位)
+func PassphraseEntropy(count int, words []string) float64 {
+	if words == nil {
+		words = DefaultWords
+	}
+	return float64(count) * math.Log2(float64(len(words)))
+}
@@ -0,0 +1,107 @@
+package extra
+
+import (
+	"errors"
+	"strings"
+)
+
+const (
+	DefaultSymbols = "!@#$%^&*()-_=+[]{};:,.<>?/~"
+	// 容易混淆的字符
+	Ambiguous = "Il1O0o|`'\""
+)
+
+// 密码生成策略，Min*为各字符类至少出现的次数，为0表示该类可选，为-1表示禁用
+type Policy struct {
+	Length     int
+	MinLower   int
+	MinUpper   int
+	MinDigits  int
+	MinSymbols int
+	// 为空时使用DefaultSymbols，可以包含非ASCII字符
+	Symbols string
+	// 排除容易混淆的字符
+	ExcludeAmbiguous bool
+}
+
+// 适用于大多数网站的默认策略
+var DefaultPolicy = Policy{Length: 16, MinLower: 1, MinUpper: 1, MinDigits: 1, MinSymbols: 1}
+
+func Password(p Policy) (string, error) {
+
+	if p.Length <= 0 {
+		return "", ErrInvalidLength
+	}
+
+	symbols := p.Symbols
+	if symbols == "" {
+		symbols = DefaultSymbols
+	}
+	classes := []struct {
+		chars string
+		min   int
+	}{
+		{LowerLetters, p.MinLower},
+		{UpperLetters, p.MinUpper},
+		{Digits, p.MinDigits},
+		{symbols, p.MinSymbols},
+	}
+
+	// 先从每个字符类中选取至少Min个字符，其余从所有启用的字符类中选取
+	// 按rune选取，Symbols中的多字节字符不会被截断，Length为字符数
+	var all []rune
+	var out []rune
+	required := 0
+	for _, c := range classes {
+		if c.min < 0 {
+			continue
+		}
+		chars := c.chars
+		if p.ExcludeAmbiguous {
+			chars = removeChars(chars, Ambiguous)
+		}
+		runes := []rune(chars)
+		all = append(all, runes...)
+		required += c.min
+		for i := 0; i < c.min; i++ {
+			idx, err := Intn(len(runes))
+			if err != nil {
+				return "", err
+			}
+			out = append(out, runes[idx])
+		}
+	}
+	if len(all) == 0 {
+		return "", errors.New("rand: password policy allows no characters")
+	}
+	if required > p.Length {
+		return "", errors.New("rand: password policy requires more characters than length")
+	}
+
+	for len(out) < p.Length {
+		idx, err := Intn(len(all))
+		if err != nil {
+			return "", err
+		}
+		out = append(out, all[idx])
+	}
+
+	// Fisher-Yates洗牌，避免必选字符总出现在开头
+	for i := len(out) - 1; i > 0; i-- {
+		j, err := Intn(i + 1)
+		if err != nil {
+			return "", err
+		}
+		out[i], out[j] = out[j], out[i]
+	}
+	return string(out), nil
+}
+
+func removeChars(s, remove string) string {
+	return strings.Map(func(r rune) rune {
+		if strings.ContainsRune(remove, r) {
+			return -1
+		}
+		return r
+	}, s)
+}
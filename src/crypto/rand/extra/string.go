@@ -0,0 +1,104 @@
+package extra
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+// 常用字符集
+const (
+	Digits       = "0123456789"
+	LowerLetters = "abcdefghijklmnopqrstuvwxyz"
+	UpperLetters = "ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	Letters      = LowerLetters + UpperLetters
+	Alphanumeric = Digits + Letters
+	Hex          = "0123456789abcdef"
+	// RFC 4648 URL安全的base64字符集
+	URLSafe = Alphanumeric + "-_"
+)
+
+var (
+	ErrInvalidAlphabet = errors.New("rand: alphabet must contain 2 to 256 unique characters")
+	ErrInvalidLength   = errors.New("rand: length must be positive")
+)
+
+// 随机数来源，默认使用crypto/rand的全局强随机生成器，切勿替换为math/rand
+var Reader io.Reader = rand.Reader
+
+// 返回[0, n)之间均匀分布的随机整数
+func Intn(n int) (int, error) {
+
+	if n <= 0 {
+		return 0, errors.New("rand: n must be positive")
+	}
+	// 直接 uint64 % n 会使较小的数出现概率偏高，丢弃落在最后一个不完整区间的值
+	max := ^uint64(0)
+	limit := max - max%uint64(n)
+	var b [8]byte
+	for {
+		if _, err := io.ReadFull(Reader, b[:]); err != nil {
+			return 0, err
+		}
+		v := binary.BigEndian.Uint64(b[:])
+		if v < limit {
+			return int(v % uint64(n)), nil
+		}
+	}
+}
+
+// 从字符集alphabet中无偏地选取length个字符
+func String(alphabet string, length int) (string, error) {
+
+	if length <= 0 {
+		return "", ErrInvalidLength
+	}
+	chars := []rune(alphabet)
+	if len(chars) < 2 || len(chars) > 256 || !unique(chars) {
+		return "", ErrInvalidAlphabet
+	}
+
+	// 拒绝采样: 每个随机字节取不小于字符集大小的最小2^k掩码，超出字符集大小的值丢弃
+	// 例如62个字符使用掩码63，平均每个字符消耗约1.03个字节，且不存在取模偏差
+	mask := byte(1)
+	for int(mask) < len(chars)-1 {
+		mask = mask<<1 | 1
+	}
+
+	out := make([]rune, 0, length)
+	buf := make([]byte, length+length/4+8)
+	for len(out) < length {
+		if _, err := io.ReadFull(Reader, buf); err != nil {
+			return "", err
+		}
+		for _, b := range buf {
+			idx := int(b & mask)
+			if idx >= len(chars) {
+				continue
+			}
+			out = append(out, chars[idx])
+			if len(out) == length {
+				break
+			}
+		}
+	}
+	return string(out), nil
+}
+
+// 由n字节随机数生成的URL安全token，适合作为API Key、会话ID等
+func Token(n int) (string, error) {
+	// 每个字符6位，向上取整
+	return String(URLSafe, (n*8+5)/6)
+}
+
+func unique(chars []rune) bool {
+	seen := make(map[rune]bool, len(chars))
+	for _, c := range chars {
+		if seen[c] {
+			return false
+		}
+		seen[c] = true
+	}
+	return true
+}
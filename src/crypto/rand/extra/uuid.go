@@ -0,0 +1,230 @@
+package extra
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"io"
+	"sync"
+	"time"
+)
+
+var ErrInvalidUUID = errors.New("rand: invalid UUID")
+
+// RFC 9562 UUID
+type UUID [16]byte
+
+// 版本4: 122位随机数
+func NewV4() (UUID, error) {
+
+	var u UUID
+	if _, err := io.ReadFull(Reader, u[:]); err != nil {
+		return u, err
+	}
+	// 高4位为版本号，variant为10
+	u[6] = u[6]&0x0f | 0x40
+	u[8] = u[8]&0x3f | 0x80
+	return u, nil
+}
+
+var v7 struct {
+	sync.Mutex
+	ms  uint64
+	seq uint16
+}
+
+// 版本7: 48位Unix毫秒时间戳 + 74位随机数，按生成时间排序
+// 同一毫秒内以rand_a的12位作为递增计数器(RFC 9562 6.2 方法1)，保证单进程内严格递增
+func NewV7() (UUID, error) {
+
+	var u UUID
+	if _, err := io.ReadFull(Reader, u[:]); err != nil {
+		return u, err
+	}
+
+	v7.Lock()
+	ms := uint64(time.Now().UnixNano() / int64(time.Millisecond))
+	if ms > v7.ms {
+		v7.ms = ms
+		// 计数器初始值取随机数的低11位，保留足够的递增空间
+		v7.seq = binary.BigEndian.Uint16(u[6:8]) & 0x07ff
+	} else {
+		// 时钟回拨或同一毫秒内，沿用上次时间戳并递增计数器，溢出时借用下一毫秒
+		v7.seq++
+		if v7.seq > 0x0fff {
+			v7.ms++
+			v7.seq = 0
+		}
+	}
+	ms, seq := v7.ms, v7.seq
+	v7.Unlock()
+
+	u[0] = byte(ms >> 40)
+	u[1] = byte(ms >> 32)
+	u[2] = byte(ms >> 24)
+	u[3] = byte(ms >> 16)
+	u[4] = byte(ms >> 8)
+	u[5] = byte(ms)
+	u[6] = 0x70 | byte(seq>>8)
+	u[7] = byte(seq)
+	u[8] = u[8]&0x3f | 0x80
+	return u, nil
+}
+
+func (u UUID) Version() int {
+	return int(u[6] >> 4)
+}
+
+// 版本7中的时间戳
+func (u UUID) Time() time.Time {
+	return msTime(u[:6])
+}
+
+// 8-4-4-4-12格式
+func (u UUID) String() string {
+	var buf [36]byte
+	hex.Encode(buf[0:8], u[0:4])
+	buf[8] = '-'
+	hex.Encode(buf[9:13], u[4:6])
+	buf[13] = '-'
+	hex.Encode(buf[14:18], u[6:8])
+	buf[18] = '-'
+	hex.Encode(buf[19:23], u[8:10])
+	buf[23] = '-'
+	hex.Encode(buf[24:], u[10:])
+	return string(buf[:])
+}
+
+func ParseUUID(s string) (UUID, error) {
+
+	var u UUID
+	if len(s) != 36 || s[8] != '-' || s[13] != '-' || s[18] != '-' || s[23] != '-' {
+		return u, ErrInvalidUUID
+	}
+	h := s[0:8] + s[9:13] + s[14:18] + s[19:23] + s[24:]
+	if _, err := hex.Decode(u[:], []byte(h)); err != nil {
+		return u, ErrInvalidUUID
+	}
+	return u, nil
+}
+
+var ErrInvalidULID = errors.New("rand: invalid ULID")
+
+// https://github.com/ulid/spec: 48位毫秒时间戳 + 80位随机数，26个Crockford base32字符
+type ULID [16]byte
+
+var ulid struct {
+	sync.Mutex
+	ms   uint64
+	last ULID
+}
+
+// 同一毫秒内将上一个ULID的随机部分加1，保证单进程内单调递增
+func NewULID() (ULID, error) {
+
+	var u ULID
+	if _, err := io.ReadFull(Reader, u[6:]); err != nil {
+		return u, err
+	}
+
+	ulid.Lock()
+	defer ulid.Unlock()
+
+	ms := uint64(time.Now().UnixNano() / int64(time.Millisecond))
+	if ms <= ulid.ms {
+		u = ulid.last
+		// 80位随机部分加1，溢出时按规范返回错误
+		i := 15
+		for ; i >= 6; i-- {
+			u[i]++
+			if u[i] != 0 {
+				break
+			}
+		}
+		if i < 6 {
+			return ULID{}, errors.New("rand: ULID random component overflow")
+		}
+	} else {
+		ulid.ms = ms
+		u[0] = byte(ms >> 40)
+		u[1] = byte(ms >> 32)
+		u[2] = byte(ms >> 24)
+		u[3] = byte(ms >> 16)
+		u[4] = byte(ms >> 8)
+		u[5] = byte(ms)
+	}
+	ulid.last = u
+	return u, nil
+}
+
+func (u ULID) Time() time.Time {
+	return msTime(u[:6])
+}
+
+// 128位按5位一组编码，首字符只使用高3位
+func (u ULID) String() string {
+
+	var out [26]byte
+	// 从最低位开始，每次取5位
+	for i := 25; i >= 0; i-- {
+		bit := uint(25-i) * 5
+		out[i] = Crockford[bitsAt(u[:], bit)]
+	}
+	return string(out[:])
+}
+
+func ParseULID(s string) (ULID, error) {
+
+	var u ULID
+	if len(s) != 26 {
+		return u, ErrInvalidULID
+	}
+	s = NormalizeCode(s)
+	// 首字符最大为7，否则超出128位
+	if len(s) != 26 || s[0] > '7' {
+		return u, ErrInvalidULID
+	}
+	for i := 0; i < 26; i++ {
+		v := indexCrockford(s[i])
+		if v < 0 {
+			return u, ErrInvalidULID
+		}
+		// 左移5位并加上当前字符
+		carry := byte(v)
+		for j := 15; j >= 0; j-- {
+			next := u[j] >> 3
+			u[j] = u[j]<<5 | carry
+			carry = next
+		}
+	}
+	return u, nil
+}
+
+// 返回从最低位起第bit位开始的5位
+func bitsAt(b []byte, bit uint) byte {
+	var v byte
+	for k := uint(0); k < 5; k++ {
+		pos := bit + k
+		if pos >= 128 {
+			break
+		}
+		if b[15-pos/8]>>(pos%8)&1 == 1 {
+			v |= 1 << k
+		}
+	}
+	return v
+}
+
+func indexCrockford(c byte) int {
+	for i := 0; i < len(Crockford); i++ {
+		if Crockford[i] == c {
+			return i
+		}
+	}
+	return -1
+}
+
+func msTime(b []byte) time.Time {
+	ms := int64(b[0])<<40 | int64(b[1])<<32 | int64(b[2])<<24 | int64(b[3])<<16 | int64(b[4])<<8 | int64(b[5])
+	return time.Unix(ms/1000, ms%1000*int64(time.Millisecond))
+}
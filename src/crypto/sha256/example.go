@@ -4,7 +4,10 @@ import (
 	"crypto"
 	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
 	"fmt"
+	"github.com/zc2638/go-standard/src/crypto/sha256/extra"
+	"log"
 )
 
 // 实现了SHA224和SHA256哈希算法
@@ -12,6 +15,8 @@ func main() {
 
 	sha224Demo()
 	sha256Demo()
+	// RFC 6962 Merkle树
+	merkleDemo()
 }
 
 func sha224Demo() {
@@ -62,4 +67,98 @@ func sha256Demo() {
 	// 直接使用sha256.Sum256
 	m3 := sha256.Sum256([]byte("Hello World"))
 	fmt.Println(base64.StdEncoding.EncodeToString(m3[:]))
-}
\ No newline at end of file
+}
+
+func merkleDemo() {
+
+	// RFC 6962 参考实现中的测试数据
+	leaves := []string{"", "00", "10", "2021", "3031", "40414243", "5051525354555657", "606162636465666768696a6b6c6d6e6f"}
+	roots := []string{
+		"6e340b9cffb37a989ca544e6bb780a2c78901d3fb33738768511a30617afa01d",
+		"fac54203e7cc696cf0dfcb42c92a1d9dbaf70ad9e621f4bd8d98662f00e3c125",
+		"aeb6bcfe274b70a14fb067a5e5578264db0fa9b51af5e0ba159158f329e06e77",
+		"d37ee418976dd95753c1c73862b9398fa2a2cf9b4ff0fdfe8b30cd95209614b7",
+		"4e3bbb1f7b478dcfe71fb631631519a3bca12c9aefca1612bfce4c13a86264d4",
+		"76e67dadbcdf1e10e1b74ddc608abd2f98dfb16fbce75277b5232a127f2087ef",
+		"ddb89be403809e325750d3d263cd78929c2942b7942a34b77e122c9594a74c8c",
+		"5dc9da79a70659a9ad559cb701ded9a2ab9d823aad2f4960cfe370eff4604328",
+	}
+
+	// 流式计算根哈希
+	var builder extra.Builder
+	var tree extra.Tree
+	for i, leaf := range leaves {
+		data, _ := hex.DecodeString(leaf)
+		builder.Append(data)
+		tree.Append(data)
+		root := builder.Root()
+		fmt.Println("Merkle root", i+1, hex.EncodeToString(root[:]), hex.EncodeToString(root[:]) == roots[i])
+	}
+
+	// 校验所有大小的树中每个叶子的包含证明，以及任意两个大小之间的一致性证明
+	ok := true
+	for size := uint64(1); size <= tree.Size(); size++ {
+		root := tree.RootAt(size)
+		for i := uint64(0); i < size; i++ {
+			proof, err := tree.InclusionProof(i, size)
+			if err != nil {
+				log.Fatal(err)
+			}
+			data, _ := hex.DecodeString(leaves[i])
+			if extra.VerifyInclusion(extra.LeafHash(data), proof, root) != nil {
+				ok = false
+			}
+		}
+		for old := uint64(0); old <= size; old++ {
+			proof, err := tree.ConsistencyProof(old, size)
+			if err != nil {
+				log.Fatal(err)
+			}
+			if extra.VerifyConsistency(proof, tree.RootAt(old), root) != nil {
+				ok = false
+			}
+		}
+	}
+	fmt.Println("全部包含及一致性证明校验通过: ", ok)
+
+	// 审计日志: 客户端保存旧的根，服务端追加日志后提供一致性证明，证明历史记录未被篡改
+	oldSize, oldRoot := tree.Size(), tree.Root()
+	for _, entry := range []string{"user alice login", "user bob logout", "config changed"} {
+		tree.Append([]byte(entry))
+	}
+	proof, err := tree.ConsistencyProof(oldSize, tree.Size())
+	if err != nil {
+		log.Fatal(err)
+	}
+	// 序列化后传输
+	b, err := proof.MarshalBinary()
+	if err != nil {
+		log.Fatal(err)
+	}
+	var received extra.ConsistencyProof
+	if err := received.UnmarshalBinary(b); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println("一致性证明字节数: ", len(b), "校验: ", extra.VerifyConsistency(&received, oldRoot, tree.Root()))
+
+	// 某条日志的包含证明
+	inclusion, err := tree.InclusionProof(9, tree.Size())
+	if err != nil {
+		log.Fatal(err)
+	}
+	b, _ = inclusion.MarshalBinary()
+	var receivedInclusion extra.InclusionProof
+	if err := receivedInclusion.UnmarshalBinary(b); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println("包含证明: ", extra.VerifyInclusion(extra.LeafHash([]byte("user bob logout")), &receivedInclusion, tree.Root()))
+	fmt.Println("篡改的日志: ", extra.VerifyInclusion(extra.LeafHash([]byte("user eve logout")), &receivedInclusion, tree.Root()))
+
+	// 篡改历史记录后，旧的根无法通过一致性校验
+	var forged extra.Tree
+	for i := uint64(0); i < tree.Size(); i++ {
+		forged.Append([]byte{byte(i)})
+	}
+	proof, _ = forged.ConsistencyProof(oldSize, forged.Size())
+	fmt.Println("历史被篡改: ", extra.VerifyConsistency(proof, oldRoot, forged.Root()))
+}
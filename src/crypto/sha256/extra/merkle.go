@@ -0,0 +1,279 @@
+package extra
+
+import (
+	"crypto/sha256"
+	"errors"
+)
+
+// RFC 6962 2.1 使用前缀区分叶子与内部节点，防止第二原像攻击
+const (
+	leafPrefix = 0x00
+	nodePrefix = 0x01
+)
+
+var (
+	ErrIndexOutOfRange = errors.New("merkle: index out of range")
+	ErrInvalidProof    = errors.New("merkle: invalid proof")
+	ErrRootMismatch    = errors.New("merkle: root hash mismatch")
+)
+
+type Hash [sha256.Size]byte
+
+// 空树的根: SHA-256("")
+var EmptyRoot = Hash(sha256.Sum256(nil))
+
+// MTH({d}) = SHA-256(0x00 || d)
+func LeafHash(data []byte) Hash {
+	h := sha256.New()
+	h.Write([]byte{leafPrefix})
+	h.Write(data)
+	var out Hash
+	h.Sum(out[:0])
+	return out
+}
+
+// MTH(D[n]) = SHA-256(0x01 || MTH(D[0:k]) || MTH(D[k:n]))
+func NodeHash(left, right Hash) Hash {
+	var buf [1 + 2*sha256.Size]byte
+	buf[0] = nodePrefix
+	copy(buf[1:], left[:])
+	copy(buf[1+sha256.Size:], right[:])
+	return sha256.Sum256(buf[:])
+}
+
+// 流式计算根哈希，只保存每个完整子树的根，内存占用为O(log n)
+// 与二进制计数器类似: 追加叶子时，从低位开始合并高度相同的子树
+type Builder struct {
+	size  uint64
+	stack []Hash
+}
+
+func (b *Builder) Append(data []byte) {
+	b.AppendHash(LeafHash(data))
+}
+
+func (b *Builder) AppendHash(leaf Hash) {
+	h := leaf
+	// size的二进制中末尾的每个1都代表一个可以与新子树合并的同高度子树
+	for s := b.size; s&1 == 1; s >>= 1 {
+		h = NodeHash(b.stack[len(b.stack)-1], h)
+		b.stack = b.stack[:len(b.stack)-1]
+	}
+	b.stack = append(b.stack, h)
+	b.size++
+}
+
+func (b *Builder) Size() uint64 {
+	return b.size
+}
+
+// 从右向左合并剩余的子树
+func (b *Builder) Root() Hash {
+	if b.size == 0 {
+		return EmptyRoot
+	}
+	root := b.stack[len(b.stack)-1]
+	for i := len(b.stack) - 2; i >= 0; i-- {
+		root = NodeHash(b.stack[i], root)
+	}
+	return root
+}
+
+// 保存全部叶子哈希的Merkle树，可以为任意历史大小生成证明
+type Tree struct {
+	leaves []Hash
+}
+
+func (t *Tree) Append(data []byte) uint64 {
+	return t.AppendHash(LeafHash(data))
+}
+
+// 返回叶子的索引
+func (t *Tree) AppendHash(leaf Hash) uint64 {
+	t.leaves = append(t.leaves, leaf)
+	return uint64(len(t.leaves) - 1)
+}
+
+func (t *Tree) Size() uint64 {
+	return uint64(len(t.leaves))
+}
+
+func (t *Tree) Root() Hash {
+	return t.RootAt(t.Size())
+}
+
+// 树大小为size时的根哈希
+func (t *Tree) RootAt(size uint64) Hash {
+	if size == 0 || size > t.Size() {
+		return EmptyRoot
+	}
+	return mth(t.leaves[:size])
+}
+
+// RFC 6962 2.1.1 叶子index在大小为size的树中的审计路径
+func (t *Tree) InclusionProof(index, size uint64) (*InclusionProof, error) {
+	if size > t.Size() || index >= size {
+		return nil, ErrIndexOutOfRange
+	}
+	return &InclusionProof{
+		LeafIndex: index,
+		TreeSize:  size,
+		Hashes:    path(index, t.leaves[:size]),
+	}, nil
+}
+
+// RFC 6962 2.1.2 证明大小为oldSize的树是大小为newSize的树的前缀
+func (t *Tree) ConsistencyProof(oldSize, newSize uint64) (*ConsistencyProof, error) {
+	if newSize > t.Size() || oldSize > newSize {
+		return nil, ErrIndexOutOfRange
+	}
+	proof := &ConsistencyProof{OldSize: oldSize, NewSize: newSize}
+	if oldSize > 0 && oldSize < newSize {
+		proof.Hashes = subproof(oldSize, t.leaves[:newSize], true)
+	}
+	return proof, nil
+}
+
+func mth(leaves []Hash) Hash {
+	n := len(leaves)
+	if n == 1 {
+		return leaves[0]
+	}
+	k := split(n)
+	return NodeHash(mth(leaves[:k]), mth(leaves[k:]))
+}
+
+// PATH(m, D[n])
+func path(m uint64, leaves []Hash) []Hash {
+	n := len(leaves)
+	if n <= 1 {
+		return nil
+	}
+	k := split(n)
+	if m < uint64(k) {
+		return append(path(m, leaves[:k]), mth(leaves[k:]))
+	}
+	return append(path(m-uint64(k), leaves[k:]), mth(leaves[:k]))
+}
+
+// SUBPROOF(m, D[n], b)
+func subproof(m uint64, leaves []Hash, b bool) []Hash {
+	n := uint64(len(leaves))
+	if m == n {
+		if b {
+			return nil
+		}
+		return []Hash{mth(leaves)}
+	}
+	k := uint64(split(int(n)))
+	if m <= k {
+		return append(subproof(m, leaves[:k], b), mth(leaves[k:]))
+	}
+	return append(subproof(m-k, leaves[k:], false), mth(leaves[:k]))
+}
+
+// 小于n的最大的2的幂
+func split(n int) int {
+	k := 1
+	for k<<1 < n {
+		k <<= 1
+	}
+	return k
+}
+
+// RFC 9162 2.1.3.2 使用审计路径验证叶子属于根为root的树
+func VerifyInclusion(leaf Hash, proof *InclusionProof, root Hash) error {
+
+	if proof.LeafIndex >= proof.TreeSize {
+		return ErrIndexOutOfRange
+	}
+	fn, sn := proof.LeafIndex, proof.TreeSize-1
+	r := leaf
+	for _, p := range proof.Hashes {
+		if sn == 0 {
+			return ErrInvalidProof
+		}
+		if fn&1 == 1 || fn == sn {
+			r = NodeHash(p, r)
+			// 跳过右侧没有兄弟节点的层
+			for fn&1 == 0 && fn != 0 {
+				fn >>= 1
+				sn >>= 1
+			}
+		} else {
+			r = NodeHash(r, p)
+		}
+		fn >>= 1
+		sn >>= 1
+	}
+	if sn != 0 {
+		return ErrInvalidProof
+	}
+	if r != root {
+		return ErrRootMismatch
+	}
+	return nil
+}
+
+// RFC 9162 2.1.4.2 验证oldRoot对应的树是newRoot对应的树的前缀
+func VerifyConsistency(proof *ConsistencyProof, oldRoot, newRoot Hash) error {
+
+	first, second := proof.OldSize, proof.NewSize
+	hashes := proof.Hashes
+	switch {
+	case first > second:
+		return ErrInvalidProof
+	case first == second:
+		if len(hashes) != 0 {
+			return ErrInvalidProof
+		}
+		if oldRoot != newRoot {
+			return ErrRootMismatch
+		}
+		return nil
+	case first == 0:
+		// 空树是任何树的前缀
+		if len(hashes) != 0 {
+			return ErrInvalidProof
+		}
+		return nil
+	}
+	if len(hashes) == 0 {
+		return ErrInvalidProof
+	}
+
+	// 旧树大小为2的幂时，旧树本身就是新树的一个完整子树，证明中省略了它的根
+	if first&(first-1) == 0 {
+		hashes = append([]Hash{oldRoot}, hashes...)
+	}
+	fn, sn := first-1, second-1
+	for fn&1 == 1 {
+		fn >>= 1
+		sn >>= 1
+	}
+	fr, sr := hashes[0], hashes[0]
+	for _, c := range hashes[1:] {
+		if sn == 0 {
+			return ErrInvalidProof
+		}
+		if fn&1 == 1 || fn == sn {
+			fr = NodeHash(c, fr)
+			sr = NodeHash(c, sr)
+			for fn&1 == 0 && fn != 0 {
+				fn >>= 1
+				sn >>= 1
+			}
+		} else {
+			sr = NodeHash(sr, c)
+		}
+		fn >>= 1
+		sn >>= 1
+	}
+	if sn != 0 {
+		return ErrInvalidProof
+	}
+	if fr != oldRoot || sr != newRoot {
+		return ErrRootMismatch
+	}
+	return nil
+}
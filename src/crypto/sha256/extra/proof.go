@@ -0,0 +1,87 @@
+package extra
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+// 证明中哈希数量的上限，64层即可覆盖2^64个叶子
+const maxProofHashes = 64
+
+// 包含证明
+type InclusionProof struct {
+	LeafIndex uint64
+	TreeSize  uint64
+	Hashes    []Hash
+}
+
+// 一致性证明
+type ConsistencyProof struct {
+	OldSize uint64
+	NewSize uint64
+	Hashes  []Hash
+}
+
+// 二进制格式(大端): 8字节 | 8字节 | 1字节哈希数量 | 哈希...
+type proofHeader struct {
+	A, B  uint64
+	Count uint8
+}
+
+func (p *InclusionProof) MarshalBinary() ([]byte, error) {
+	return marshalProof(p.LeafIndex, p.TreeSize, p.Hashes)
+}
+
+func (p *InclusionProof) UnmarshalBinary(data []byte) error {
+	var err error
+	p.LeafIndex, p.TreeSize, p.Hashes, err = unmarshalProof(data)
+	return err
+}
+
+func (p *ConsistencyProof) MarshalBinary() ([]byte, error) {
+	return marshalProof(p.OldSize, p.NewSize, p.Hashes)
+}
+
+func (p *ConsistencyProof) UnmarshalBinary(data []byte) error {
+	var err error
+	p.OldSize, p.NewSize, p.Hashes, err = unmarshalProof(data)
+	return err
+}
+
+func marshalProof(a, b uint64, hashes []Hash) ([]byte, error) {
+
+	if len(hashes) > maxProofHashes {
+		return nil, errors.New("merkle: too many hashes in proof")
+	}
+	var buf bytes.Buffer
+	if err := binary.Write(&buf, binary.BigEndian, proofHeader{a, b, uint8(len(hashes))}); err != nil {
+		return nil, err
+	}
+	// [32]byte数组切片可以直接写入
+	if err := binary.Write(&buf, binary.BigEndian, hashes); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func unmarshalProof(data []byte) (uint64, uint64, []Hash, error) {
+
+	r := bytes.NewReader(data)
+	var h proofHeader
+	if err := binary.Read(r, binary.BigEndian, &h); err != nil {
+		return 0, 0, nil, errors.New("merkle: proof is too short")
+	}
+	if h.Count > maxProofHashes {
+		return 0, 0, nil, errors.New("merkle: too many hashes in proof")
+	}
+	hashes := make([]Hash, h.Count)
+	if err := binary.Read(r, binary.BigEndian, hashes); err != nil {
+		return 0, 0, nil, errors.New("merkle: proof is truncated")
+	}
+	if _, err := r.ReadByte(); err != io.EOF {
+		return 0, 0, nil, errors.New("merkle: trailing data after proof")
+	}
+	return h.A, h.B, hashes, nil
+}
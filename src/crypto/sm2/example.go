@@ -0,0 +1,252 @@
+package main
+
+import (
+	"bytes"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"github.com/zc2638/go-standard/src/crypto/sm2/extra"
+	"io"
+	"log"
+	"math/big"
+	"strings"
+)
+
+// 实现了GM/T 0003-2012规定的SM2椭圆曲线公钥密码算法
+func main() {
+
+	// 签名/验签
+	SignAndVerify()
+	// 公钥加密/私钥解密
+	EncryptAndDecrypt()
+	// 密钥交换
+	KeyExchange()
+	// GB/T 32918 附录A示例
+	KnownAnswer()
+	// PEM编码与解析
+	PEM()
+}
+
+func SignAndVerify() {
+
+	// 生成一对公钥/私钥
+	priv, err := extra.GenerateKey(rand.Reader)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// 声明签名内容及用户身份标识，uid为nil时应使用extra.DefaultUID
+	msg := []byte("hello, world")
+	uid := []byte("alice@example.com")
+
+	// 签名时先计算 e = SM3(ZA || M)，ZA包含了用户身份标识、曲线参数及公钥
+	sig, err := extra.SignASN1(rand.Reader, priv, uid, msg)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println("SM2签名: ", hex.EncodeToString(sig))
+
+	// 验签需使用相同的uid
+	fmt.Println("SM2验签: ", extra.VerifyASN1(&priv.PublicKey, uid, msg, sig))
+	fmt.Println("SM2验签(错误uid): ", extra.VerifyASN1(&priv.PublicKey, extra.DefaultUID, msg, sig))
+}
+
+func EncryptAndDecrypt() {
+
+	priv, err := extra.GenerateKey(nil)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// 声明一个随意长度的 需加密内容
+	var origin = []byte("need to sm2 encode test text")
+
+	// 分别使用 C1C3C2、C1C2C3 顺序加密
+	for _, mode := range []extra.Mode{extra.C1C3C2, extra.C1C2C3} {
+		cipherText, err := extra.Encrypt(rand.Reader, &priv.PublicKey, origin, mode)
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println("SM2加密内容: ", hex.EncodeToString(cipherText))
+
+		originText, err := extra.Decrypt(priv, cipherText, mode)
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println("SM2解密内容: ", string(originText))
+	}
+
+	// GmSSL/Tongsuo/OpenSSL使用的ASN.1密文格式
+	cipherText, err := extra.EncryptASN1(rand.Reader, &priv.PublicKey, origin)
+	if err != nil {
+		log.Fatal(err)
+	}
+	originText, err := extra.DecryptASN1(priv, cipherText)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println("SM2 ASN.1解密内容: ", string(originText))
+}
+
+func KeyExchange() {
+
+	// 双方的长期密钥对
+	alice, err := extra.GenerateKey(nil)
+	if err != nil {
+		log.Fatal(err)
+	}
+	bob, err := extra.GenerateKey(nil)
+	if err != nil {
+		log.Fatal(err)
+	}
+	aliceID, bobID := []byte("alice"), []byte("bob")
+
+	// 发起方A与响应方B，协商16字节密钥
+	a, err := extra.NewKeyExchange(alice, &bob.PublicKey, aliceID, bobID, 16, true)
+	if err != nil {
+		log.Fatal(err)
+	}
+	b, err := extra.NewKeyExchange(bob, &alice.PublicKey, bobID, aliceID, 16, false)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// A -> B: RA
+	ra, err := a.Init(rand.Reader)
+	if err != nil {
+		log.Fatal(err)
+	}
+	// B -> A: RB, SB
+	rb, sb, err := b.Respond(rand.Reader, ra)
+	if err != nil {
+		log.Fatal(err)
+	}
+	// A -> B: SA
+	sa, err := a.ConfirmResponder(rb, sb)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := b.ConfirmInitiator(sa); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println("SM2密钥交换: ", hex.EncodeToString(a.Key()), bytes.Equal(a.Key(), b.Key()))
+}
+
+func PEM() {
+
+	priv, err := extra.GenerateKey(nil)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// 私钥编码为PKCS#8 "PRIVATE KEY"，算法为id-ecPublicKey，曲线为sm2p256v1
+	priPem, err := extra.EncodePrivateKeyPEM(priv)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(string(priPem))
+
+	// 公钥编码为PKIX "PUBLIC KEY"
+	pubPem, err := extra.EncodePublicKeyPEM(&priv.PublicKey)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(string(pubPem))
+
+	// 解析PEM
+	priv2, err := extra.BuildSM2PrivateKey(priPem)
+	if err != nil {
+		log.Fatal(err)
+	}
+	pub2, err := extra.BuildSM2PublicKey(pubPem)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println("PEM解析: ", priv.D.Cmp(priv2.D) == 0, pub2.X.Cmp(priv.X) == 0 && pub2.Y.Cmp(priv.Y) == 0)
+}
+
+func KnownAnswer() {
+
+	// GB/T 32918 附录A示例使用的Fp-256测试曲线，a ≠ p - 3
+	params := &elliptic.CurveParams{Name: "GB/T 32918 Fp-256", BitSize: 256}
+	params.P = hexInt("8542D69E 4C044F18 E8B92435 BF6FF7DE 45728391 5C45517D 722EDB8B 08F1DFC3")
+	params.B = hexInt("63E4C6D3 B23B0C84 9CF84241 484BFE48 F61D59A5 B16BA06E 6E12D1DA 27C5249A")
+	params.Gx = hexInt("421DEBD6 1B62EAB6 746434EB C3CC315E 32220B3B ADD50BDC 4C4E6C14 7FEDD43D")
+	params.Gy = hexInt("0680512B CBB42C07 D47349D2 153B70C4 E5D7FDFC BFA36EA1 A85841B9 E46E09A2")
+	params.N = hexInt("8542D69E 4C044F18 E8B92435 BF6FF7DD 29772063 0485628D 5AE74EE7 C32E79B7")
+	curve := &extra.Curve{CurveParams: params, A: hexInt("787968B4 FA32C3FD 2417842E 73BBFEFF 2F3C848B 6831D7E0 EC65228B 3937E498")}
+
+	// GB/T 32918.4 A.2: 消息"encryption standard"，随机数k，密文按C1C3C2排列
+	bob := testKey(curve, "1649AB77 A00637BD 5E2EFE28 3FBF3535 34AA7F7C B89463F2 08DDBC29 20BB0DA0")
+	cipherText, err := extra.Encrypt(fixedScalar("4C62EEFD 6ECFC2B9 5B92FD6C 3D957514 8AFA1742 5546D490 18E5388D 49DD7B4F"),
+		&bob.PublicKey, []byte("encryption standard"), extra.C1C3C2)
+	if err != nil {
+		log.Fatal(err)
+	}
+	expected := hexBytes("04" +
+		"245C26FB 68B1DDDD B12C4B6B F9F2B6D5 FE60A383 B0D18D1C 4144ABF1 7F6252E7" +
+		"76CB9264 C2A7E88E 52B19903 FDC47378 F605E368 11F5C074 23A24B84 400F01B8" +
+		"9C3D7360 C30156FA B7C80A02 76712DA9 D8094A63 4B766D3A 285E0748 0653426D" +
+		"650053A8 9B41C418 B0C3AAD0 0D886C00 286467")
+	originText, err := extra.Decrypt(bob, expected, extra.C1C3C2)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println("SM2加密已知答案: ", bytes.Equal(cipherText, expected), string(originText))
+
+	// GB/T 32918.3 A.2: 发起方A与响应方B协商128比特密钥
+	alice := testKey(curve, "6FCBA2EF 9AE0AB90 2BC3BDE3 FF915D44 BA4CC78F 88E2F8E7 F8996D3B 8CCEEDEE")
+	bob = testKey(curve, "5E35D7D3 F3C54DBA C72E6181 9E730B01 9A84208C A3A35E4C 2E353DFC CB2A3B53")
+	aliceID, bobID := []byte("ALICE123@YAHOO.COM"), []byte("BILL456@YAHOO.COM")
+	a, err := extra.NewKeyExchange(alice, &bob.PublicKey, aliceID, bobID, 16, true)
+	if err != nil {
+		log.Fatal(err)
+	}
+	b, err := extra.NewKeyExchange(bob, &alice.PublicKey, bobID, aliceID, 16, false)
+	if err != nil {
+		log.Fatal(err)
+	}
+	ra, err := a.Init(fixedScalar("83A2C9C8 B96E5AF7 0BD480B4 72409A9A 327257F1 EBB73F5B 073354B2 48668563"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	rb, sb, err := b.Respond(fixedScalar("33FE2194 0342161C 55619C4A 0C060293 D543C80A F19748CE 176D8347 7DE71C80"), ra)
+	if err != nil {
+		log.Fatal(err)
+	}
+	sa, err := a.ConfirmResponder(rb, sb)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := b.ConfirmInitiator(sa); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println("SM2密钥交换已知答案: ",
+		bytes.Equal(b.Key(), hexBytes("55B0AC62 A6B927BA 23703832 C853DED4")),
+		bytes.Equal(sb, hexBytes("284C8F19 8F141B50 2E81250F 1581C7E9 EEB4CA69 90F9E02D F388B454 71F5BC5C")),
+		bytes.Equal(sa, hexBytes("23444DAF 8ED75343 66CB901C 84B3BDBB 63504F40 65C1116C 91A4C006 97E6CF7A")))
+}
+
+func testKey(curve elliptic.Curve, d string) *extra.PrivateKey {
+	priv := &extra.PrivateKey{D: hexInt(d)}
+	priv.Curve = curve
+	priv.X, priv.Y = curve.ScalarBaseMult(priv.D.Bytes())
+	return priv
+}
+
+// 以固定值代替随机数: 包内通过rand.Int(random, n-1)+1选取[1, n-1]内的随机数，读取32字节的k-1即得到k
+func fixedScalar(k string) io.Reader {
+	v := new(big.Int).Sub(hexInt(k), big.NewInt(1))
+	return bytes.NewReader(v.FillBytes(make([]byte, 32)))
+}
+
+func hexInt(s string) *big.Int {
+	v, _ := new(big.Int).SetString(strings.ReplaceAll(s, " ", ""), 16)
+	return v
+}
+
+func hexBytes(s string) []byte {
+	b, _ := hex.DecodeString(strings.ReplaceAll(s, " ", ""))
+	return b
+}
@@ -0,0 +1,142 @@
+package extra
+
+import (
+	"crypto/elliptic"
+	"math/big"
+	"sync"
+)
+
+// GM/T 0003.5-2012 推荐的256位素数域椭圆曲线参数
+// 曲线方程 y^2 = x^3 + ax + b，其中 a = p - 3，因此可以直接使用elliptic.CurveParams的通用实现
+var (
+	initOnce sync.Once
+	sm2P256  *elliptic.CurveParams
+)
+
+func initP256Sm2() {
+	sm2P256 = &elliptic.CurveParams{Name: "SM2-P-256"}
+	sm2P256.P, _ = new(big.Int).SetString("FFFFFFFEFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFF00000000FFFFFFFFFFFFFFFF", 16)
+	sm2P256.N, _ = new(big.Int).SetString("FFFFFFFEFFFFFFFFFFFFFFFFFFFFFFFF7203DF6B21C6052B53BBF40939D54123", 16)
+	sm2P256.B, _ = new(big.Int).SetString("28E9FA9E9D9F5E344D5A9E4BCF6509A7F39789F515AB8F92DDBCBD414D940E93", 16)
+	sm2P256.Gx, _ = new(big.Int).SetString("32C4AE2C1F1981195F9904466A39C9948FE30BBFF2660BE1715A4589334C74C7", 16)
+	sm2P256.Gy, _ = new(big.Int).SetString("BC3736A2F4F6779C59BDCEE36B692153D0A9877CC62A474002DF32E52139F0A0", 16)
+	sm2P256.BitSize = 256
+}
+
+// 返回一个实现了SM2推荐曲线的elliptic.Curve
+//
+// 注意: elliptic.CurveParams基于math/big实现，标量乘法的耗时与标量的取值相关(非常量时间)，
+// 签名、解密及密钥交换中涉及私钥与临时密钥的运算可能通过计时侧信道泄露密钥。
+// 本包没有常量时间实现，不应在攻击者可以精确测量运算耗时的场景(如对外提供签名、解密服务)中使用
+func P256Sm2() elliptic.Curve {
+	initOnce.Do(initP256Sm2)
+	return sm2P256
+}
+
+// 曲线参数a，计算ZA时使用。推荐曲线a = p - 3
+func curveA(curve elliptic.Curve) *big.Int {
+	if c, ok := curve.(*Curve); ok {
+		return c.A
+	}
+	return new(big.Int).Sub(curve.Params().P, big.NewInt(3))
+}
+
+// 系数a任意的素数域曲线 y^2 = x^3 + ax + b
+// GB/T 32918各部分附录A的示例使用此类曲线(a ≠ p - 3)，elliptic.CurveParams无法表示
+// 运算使用仿射坐标，同样为非常量时间，仅用于验证标准示例
+type Curve struct {
+	*elliptic.CurveParams
+	A *big.Int
+}
+
+func (c *Curve) IsOnCurve(x, y *big.Int) bool {
+
+	p := c.P
+	if x.Sign() < 0 || x.Cmp(p) >= 0 || y.Sign() < 0 || y.Cmp(p) >= 0 {
+		return false
+	}
+
+	// y^2 = x^3 + ax + b
+	y2 := new(big.Int).Mul(y, y)
+	y2.Mod(y2, p)
+	x3 := new(big.Int).Mul(x, x)
+	x3.Add(x3, c.A)
+	x3.Mul(x3, x)
+	x3.Add(x3, c.B)
+	x3.Mod(x3, p)
+	return x3.Cmp(y2) == 0
+}
+
+func (c *Curve) Add(x1, y1, x2, y2 *big.Int) (*big.Int, *big.Int) {
+
+	// 无穷远点以(0, 0)表示
+	if x1.Sign() == 0 && y1.Sign() == 0 {
+		return new(big.Int).Set(x2), new(big.Int).Set(y2)
+	}
+	if x2.Sign() == 0 && y2.Sign() == 0 {
+		return new(big.Int).Set(x1), new(big.Int).Set(y1)
+	}
+	if x1.Cmp(x2) == 0 {
+		// P + (-P) = O
+		if y1.Cmp(y2) != 0 {
+			return new(big.Int), new(big.Int)
+		}
+		return c.Double(x1, y1)
+	}
+
+	// λ = (y2 - y1) / (x2 - x1)
+	num := new(big.Int).Sub(y2, y1)
+	den := new(big.Int).Sub(x2, x1)
+	den.Mod(den, c.P)
+	return c.affine(x1, y1, x2, num.Mul(num, den.ModInverse(den, c.P)))
+}
+
+func (c *Curve) Double(x1, y1 *big.Int) (*big.Int, *big.Int) {
+
+	// y1 = 0时切线垂直，结果为无穷远点
+	if y1.Sign() == 0 {
+		return new(big.Int), new(big.Int)
+	}
+
+	// λ = (3x1^2 + a) / 2y1
+	num := new(big.Int).Mul(x1, x1)
+	num.Mul(num, big.NewInt(3))
+	num.Add(num, c.A)
+	den := new(big.Int).Lsh(y1, 1)
+	den.Mod(den, c.P)
+	return c.affine(x1, y1, x1, num.Mul(num, den.ModInverse(den, c.P)))
+}
+
+func (c *Curve) ScalarMult(x1, y1 *big.Int, k []byte) (*big.Int, *big.Int) {
+
+	// 从高位到低位依次倍点、加点
+	x, y := new(big.Int), new(big.Int)
+	for _, b := range k {
+		for i := 7; i >= 0; i-- {
+			x, y = c.Double(x, y)
+			if b>>uint(i)&1 == 1 {
+				x, y = c.Add(x, y, x1, y1)
+			}
+		}
+	}
+	return x, y
+}
+
+func (c *Curve) ScalarBaseMult(k []byte) (*big.Int, *big.Int) {
+	return c.ScalarMult(c.Gx, c.Gy, k)
+}
+
+func (c *Curve) affine(x1, y1, x2, lambda *big.Int) (*big.Int, *big.Int) {
+
+	// x3 = λ^2 - x1 - x2，y3 = λ(x1 - x3) - y1
+	lambda.Mod(lambda, c.P)
+	x3 := new(big.Int).Mul(lambda, lambda)
+	x3.Sub(x3, x1)
+	x3.Sub(x3, x2)
+	x3.Mod(x3, c.P)
+	y3 := new(big.Int).Sub(x1, x3)
+	y3.Mul(y3, lambda)
+	y3.Sub(y3, y1)
+	y3.Mod(y3, c.P)
+	return x3, y3
+}
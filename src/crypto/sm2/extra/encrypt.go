@@ -0,0 +1,187 @@
+package extra
+
+import (
+	"bytes"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/asn1"
+	"errors"
+	sm3 "github.com/zc2638/go-standard/src/crypto/sm3/extra"
+	"io"
+	"math/big"
+)
+
+// 密文排列顺序
+// C1为随机点 04 || x1 || y1，C2为密文，C3为杂凑值SM3(x2 || M || y2)
+type Mode int
+
+const (
+	// GM/T 0003-2012 现行标准的顺序
+	C1C3C2 Mode = iota
+	// 旧版标准及部分早期实现使用的顺序
+	C1C2C3
+)
+
+// GmSSL/Tongsuo/OpenSSL使用的ASN.1密文结构，GM/T 0009-2012
+type sm2Cipher struct {
+	XCoordinate *big.Int
+	YCoordinate *big.Int
+	HASH        []byte
+	CipherText  []byte
+}
+
+func Encrypt(random io.Reader, pub *PublicKey, originText []byte, mode Mode) ([]byte, error) {
+
+	c1, c2, c3, err := encrypt(random, pub, originText)
+	if err != nil {
+		return nil, err
+	}
+
+	// 按指定顺序拼接密文
+	out := make([]byte, 0, len(c1)+len(c2)+len(c3))
+	out = append(out, c1...)
+	if mode == C1C2C3 {
+		out = append(out, c2...)
+		return append(out, c3...), nil
+	}
+	out = append(out, c3...)
+	return append(out, c2...), nil
+}
+
+func Decrypt(priv *PrivateKey, cipherText []byte, mode Mode) ([]byte, error) {
+
+	// C1为65字节未压缩点，C3为32字节
+	if len(cipherText) < 65+sm3.Size || cipherText[0] != 4 {
+		return nil, errors.New("sm2: invalid cipherText")
+	}
+	c1 := cipherText[:65]
+	var c2, c3 []byte
+	if mode == C1C2C3 {
+		c2 = cipherText[65 : len(cipherText)-sm3.Size]
+		c3 = cipherText[len(cipherText)-sm3.Size:]
+	} else {
+		c3 = cipherText[65 : 65+sm3.Size]
+		c2 = cipherText[65+sm3.Size:]
+	}
+	return decrypt(priv, c1, c2, c3)
+}
+
+func EncryptASN1(random io.Reader, pub *PublicKey, originText []byte) ([]byte, error) {
+
+	c1, c2, c3, err := encrypt(random, pub, originText)
+	if err != nil {
+		return nil, err
+	}
+	return asn1.Marshal(sm2Cipher{
+		XCoordinate: new(big.Int).SetBytes(c1[1:33]),
+		YCoordinate: new(big.Int).SetBytes(c1[33:]),
+		HASH:        c3,
+		CipherText:  c2,
+	})
+}
+
+func DecryptASN1(priv *PrivateKey, cipherText []byte) ([]byte, error) {
+
+	var c sm2Cipher
+	if rest, err := asn1.Unmarshal(cipherText, &c); err != nil {
+		return nil, err
+	} else if len(rest) > 0 {
+		return nil, errors.New("sm2: trailing data after cipherText")
+	}
+
+	// 坐标来自密文，必须在[0, p)内；拼接为未压缩格式后由decrypt中的elliptic.Unmarshal校验是否在曲线上
+	p := priv.Params().P
+	if c.XCoordinate == nil || c.YCoordinate == nil ||
+		c.XCoordinate.Sign() < 0 || c.XCoordinate.Cmp(p) >= 0 ||
+		c.YCoordinate.Sign() < 0 || c.YCoordinate.Cmp(p) >= 0 {
+		return nil, errors.New("sm2: invalid C1")
+	}
+	c1 := append([]byte{4}, toBytes(c.XCoordinate)...)
+	c1 = append(c1, toBytes(c.YCoordinate)...)
+	return decrypt(priv, c1, c.CipherText, c.HASH)
+}
+
+func encrypt(random io.Reader, pub *PublicKey, msg []byte) (c1, c2, c3 []byte, err error) {
+
+	if random == nil {
+		random = rand.Reader
+	}
+	if !pub.IsOnCurve(pub.X, pub.Y) {
+		return nil, nil, nil, errors.New("sm2: invalid public key")
+	}
+
+	for {
+		// 随机数 k ∈ [1, n-1]
+		k, err := randScalar(random, pub.Params().N)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+
+		// C1 = [k]G，曲线运算非常量时间，参见P256Sm2的说明
+		x1, y1 := pub.ScalarBaseMult(k.Bytes())
+		// (x2, y2) = [k]PB
+		x2, y2 := pub.ScalarMult(pub.X, pub.Y, k.Bytes())
+		x2b, y2b := toBytes(x2), toBytes(y2)
+
+		// t = KDF(x2 || y2, klen)，t全为0时重新选取k
+		t := kdf(len(msg), x2b, y2b)
+		if isZero(t) && len(msg) > 0 {
+			continue
+		}
+
+		// C2 = M ⊕ t
+		c2 = xor(msg, t)
+
+		// C3 = SM3(x2 || M || y2)
+		h := sm3.New()
+		h.Write(x2b)
+		h.Write(msg)
+		h.Write(y2b)
+
+		return elliptic.Marshal(pub.Curve, x1, y1), c2, h.Sum(nil), nil
+	}
+}
+
+func decrypt(priv *PrivateKey, c1, c2, c3 []byte) ([]byte, error) {
+
+	// 还原C1并校验是否在曲线上
+	x1, y1 := elliptic.Unmarshal(priv.Curve, c1)
+	if x1 == nil {
+		return nil, errors.New("sm2: invalid C1")
+	}
+
+	// (x2, y2) = [dB]C1
+	x2, y2 := priv.ScalarMult(x1, y1, priv.D.Bytes())
+	x2b, y2b := toBytes(x2), toBytes(y2)
+
+	t := kdf(len(c2), x2b, y2b)
+	if isZero(t) && len(c2) > 0 {
+		return nil, errors.New("sm2: decryption error")
+	}
+
+	// M' = C2 ⊕ t
+	msg := xor(c2, t)
+
+	// 校验 u = SM3(x2 || M' || y2) 是否等于C3
+	h := sm3.New()
+	h.Write(x2b)
+	h.Write(msg)
+	h.Write(y2b)
+	if subtle.ConstantTimeCompare(h.Sum(nil), c3) != 1 {
+		return nil, errors.New("sm2: decryption error")
+	}
+	return msg, nil
+}
+
+func xor(a, b []byte) []byte {
+	out := make([]byte, len(a))
+	for i := range a {
+		out[i] = a[i] ^ b[i]
+	}
+	return out
+}
+
+func isZero(b []byte) bool {
+	return len(bytes.Trim(b, "\x00")) == 0
+}
@@ -0,0 +1,210 @@
+package extra
+
+import (
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/subtle"
+	"errors"
+	sm3 "github.com/zc2638/go-standard/src/crypto/sm3/extra"
+	"io"
+	"math/big"
+)
+
+// SM2密钥交换协议，GM/T 0003.3-2012，流程如下:
+// 1. 发起方A调用Init得到RA，发送给响应方B
+// 2. B调用Respond(RA)得到RB和SB，发送给A
+// 3. A调用ConfirmResponder(RB, SB)校验SB并得到SA，发送给B
+// 4. B调用ConfirmInitiator(SA)校验SA
+// 完成后双方的Key()相同
+type KeyExchange struct {
+	initiator bool
+	keyLen    int
+	priv      *PrivateKey
+	peerPub   *PublicKey
+	z         []byte // 己方ZA/ZB
+	peerZ     []byte // 对方ZA/ZB
+	r         *big.Int
+	rx, ry    *big.Int // 己方临时公钥R
+	key       []byte
+	s2        []byte // 响应方等待校验的S2
+}
+
+func NewKeyExchange(priv *PrivateKey, peerPub *PublicKey, uid, peerUID []byte, keyLen int, initiator bool) (*KeyExchange, error) {
+
+	if keyLen <= 0 {
+		return nil, errors.New("sm2: invalid key length")
+	}
+	if !peerPub.IsOnCurve(peerPub.X, peerPub.Y) {
+		return nil, errors.New("sm2: invalid peer public key")
+	}
+	z, err := ZA(&priv.PublicKey, uid)
+	if err != nil {
+		return nil, err
+	}
+	peerZ, err := ZA(peerPub, peerUID)
+	if err != nil {
+		return nil, err
+	}
+	return &KeyExchange{
+		initiator: initiator,
+		keyLen:    keyLen,
+		priv:      priv,
+		peerPub:   peerPub,
+		z:         z,
+		peerZ:     peerZ,
+	}, nil
+}
+
+// 发起方: 生成临时密钥 rA，返回 RA = [rA]G
+func (ke *KeyExchange) Init(random io.Reader) ([]byte, error) {
+
+	if !ke.initiator {
+		return nil, errors.New("sm2: Init must be called by initiator")
+	}
+	if err := ke.ephemeral(random); err != nil {
+		return nil, err
+	}
+	return elliptic.Marshal(ke.priv.Curve, ke.rx, ke.ry), nil
+}
+
+// 响应方: 生成临时密钥 rB，计算共享密钥KB，返回 RB 以及可选确认值 SB
+func (ke *KeyExchange) Respond(random io.Reader, ra []byte) (rb, sb []byte, err error) {
+
+	if ke.initiator {
+		return nil, nil, errors.New("sm2: Respond must be called by responder")
+	}
+	x1, y1 := elliptic.Unmarshal(ke.priv.Curve, ra)
+	if x1 == nil {
+		return nil, nil, errors.New("sm2: invalid RA")
+	}
+	if err := ke.ephemeral(random); err != nil {
+		return nil, nil, err
+	}
+
+	// V = [h·tB](PA + [x̄1]RA)，SM2推荐曲线余因子h = 1
+	vx, vy, err := ke.agree(x1, y1)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// KB = KDF(xV || yV || ZA || ZB, klen)
+	ke.key = kdf(ke.keyLen, toBytes(vx), toBytes(vy), ke.peerZ, ke.z)
+
+	// SB = Hash(0x02 || yV || Hash(xV || ZA || ZB || x1 || y1 || x2 || y2))
+	inner := confirmHash(vx, ke.peerZ, ke.z, x1, y1, ke.rx, ke.ry)
+	sb = confirm(0x02, vy, inner)
+	ke.s2 = confirm(0x03, vy, inner)
+
+	return elliptic.Marshal(ke.priv.Curve, ke.rx, ke.ry), sb, nil
+}
+
+// 发起方: 计算共享密钥KA，校验SB，返回确认值SA
+func (ke *KeyExchange) ConfirmResponder(rb, sb []byte) ([]byte, error) {
+
+	if !ke.initiator || ke.r == nil {
+		return nil, errors.New("sm2: Init must be called first")
+	}
+	x2, y2 := elliptic.Unmarshal(ke.priv.Curve, rb)
+	if x2 == nil {
+		return nil, errors.New("sm2: invalid RB")
+	}
+
+	// U = [h·tA](PB + [x̄2]RB)
+	ux, uy, err := ke.agree(x2, y2)
+	if err != nil {
+		return nil, err
+	}
+
+	// S1 = Hash(0x02 || yU || Hash(xU || ZA || ZB || x1 || y1 || x2 || y2))，需等于SB
+	inner := confirmHash(ux, ke.z, ke.peerZ, ke.rx, ke.ry, x2, y2)
+	if sb != nil && subtle.ConstantTimeCompare(confirm(0x02, uy, inner), sb) != 1 {
+		return nil, errors.New("sm2: responder confirmation failed")
+	}
+
+	// KA = KDF(xU || yU || ZA || ZB, klen)
+	ke.key = kdf(ke.keyLen, toBytes(ux), toBytes(uy), ke.z, ke.peerZ)
+
+	// SA = Hash(0x03 || yU || Hash(xU || ZA || ZB || x1 || y1 || x2 || y2))
+	return confirm(0x03, uy, inner), nil
+}
+
+// 响应方: 校验SA是否等于S2
+func (ke *KeyExchange) ConfirmInitiator(sa []byte) error {
+
+	if ke.initiator || ke.s2 == nil {
+		return errors.New("sm2: Respond must be called first")
+	}
+	if subtle.ConstantTimeCompare(ke.s2, sa) != 1 {
+		return errors.New("sm2: initiator confirmation failed")
+	}
+	return nil
+}
+
+// 返回协商得到的共享密钥
+func (ke *KeyExchange) Key() []byte {
+	return ke.key
+}
+
+func (ke *KeyExchange) ephemeral(random io.Reader) error {
+
+	if random == nil {
+		random = rand.Reader
+	}
+	r, err := randScalar(random, ke.priv.Params().N)
+	if err != nil {
+		return err
+	}
+	ke.r = r
+	ke.rx, ke.ry = ke.priv.ScalarBaseMult(r.Bytes())
+	return nil
+}
+
+func (ke *KeyExchange) agree(peerRx, peerRy *big.Int) (*big.Int, *big.Int, error) {
+
+	curve := ke.priv.Curve
+	n := curve.Params().N
+
+	// t = (d + x̄ · r) mod n
+	t := new(big.Int).Mul(reduceX(ke.rx), ke.r)
+	t.Add(t, ke.priv.D)
+	t.Mod(t, n)
+
+	// P = Ppeer + [x̄peer]Rpeer，结果为 [t]P
+	px, py := curve.ScalarMult(peerRx, peerRy, reduceX(peerRx).Bytes())
+	px, py = curve.Add(ke.peerPub.X, ke.peerPub.Y, px, py)
+	x, y := curve.ScalarMult(px, py, t.Bytes())
+	if x.Sign() == 0 && y.Sign() == 0 {
+		return nil, nil, errors.New("sm2: key exchange failed")
+	}
+	return x, y, nil
+}
+
+func reduceX(x *big.Int) *big.Int {
+
+	// x̄ = 2^w + (x & (2^w - 1))，w = ⌈⌈log2(n)⌉/2⌉ - 1 = 127
+	w := uint(127)
+	mask := new(big.Int).Lsh(big.NewInt(1), w)
+	r := new(big.Int).Sub(mask, big.NewInt(1))
+	r.And(r, x)
+	return r.Add(r, mask)
+}
+
+func confirmHash(x *big.Int, za, zb []byte, x1, y1, x2, y2 *big.Int) []byte {
+	h := sm3.New()
+	h.Write(toBytes(x))
+	h.Write(za)
+	h.Write(zb)
+	h.Write(toBytes(x1))
+	h.Write(toBytes(y1))
+	h.Write(toBytes(x2))
+	h.Write(toBytes(y2))
+	return h.Sum(nil)
+}
+
+func confirm(prefix byte, y *big.Int, inner []byte) []byte {
+	h := sm3.New()
+	h.Write([]byte{prefix})
+	h.Write(toBytes(y))
+	h.Write(inner)
+	return h.Sum(nil)
+}
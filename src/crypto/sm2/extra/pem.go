@@ -0,0 +1,205 @@
+package extra
+
+import (
+	"crypto/elliptic"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/pem"
+	"errors"
+	"math/big"
+)
+
+// GmSSL/Tongsuo 使用的对象标识
+var (
+	// id-ecPublicKey
+	oidPublicKeyEC = asn1.ObjectIdentifier{1, 2, 840, 10045, 2, 1}
+	// SM2推荐曲线 sm2p256v1
+	oidNamedCurveSM2 = asn1.ObjectIdentifier{1, 2, 156, 10197, 1, 301}
+	// SM2签名算法 SM2-with-SM3
+	OIDSignatureSM2WithSM3 = asn1.ObjectIdentifier{1, 2, 156, 10197, 1, 501}
+)
+
+// SEC 1 ECPrivateKey
+type ecPrivateKey struct {
+	Version       int
+	PrivateKey    []byte
+	NamedCurveOID asn1.ObjectIdentifier `asn1:"optional,explicit,tag:0"`
+	PublicKey     asn1.BitString        `asn1:"optional,explicit,tag:1"`
+}
+
+// PKCS#8 PrivateKeyInfo
+type pkcs8 struct {
+	Version    int
+	Algo       pkix.AlgorithmIdentifier
+	PrivateKey []byte
+}
+
+// X.509 SubjectPublicKeyInfo
+type pkixPublicKey struct {
+	Algo      pkix.AlgorithmIdentifier
+	PublicKey asn1.BitString
+}
+
+func MarshalSM2PrivateKey(priv *PrivateKey) ([]byte, error) {
+
+	point := elliptic.Marshal(priv.Curve, priv.X, priv.Y)
+	return asn1.Marshal(ecPrivateKey{
+		Version:       1,
+		PrivateKey:    toBytes(priv.D),
+		NamedCurveOID: oidNamedCurveSM2,
+		PublicKey:     asn1.BitString{Bytes: point, BitLength: 8 * len(point)},
+	})
+}
+
+func ParseSM2PrivateKey(der []byte) (*PrivateKey, error) {
+
+	var key ecPrivateKey
+	if _, err := asn1.Unmarshal(der, &key); err != nil {
+		return nil, err
+	}
+	if key.Version != 1 {
+		return nil, errors.New("sm2: unknown EC private key version")
+	}
+	if len(key.NamedCurveOID) > 0 && !key.NamedCurveOID.Equal(oidNamedCurveSM2) {
+		return nil, errors.New("sm2: not an SM2 private key")
+	}
+
+	// d ∈ [1, n-2]
+	d := new(big.Int).SetBytes(key.PrivateKey)
+	nMinus1 := new(big.Int).Sub(P256Sm2().Params().N, big.NewInt(1))
+	if d.Sign() <= 0 || d.Cmp(nMinus1) >= 0 {
+		return nil, errors.New("sm2: invalid private key")
+	}
+	return newPrivateKey(d), nil
+}
+
+func MarshalPKCS8PrivateKey(priv *PrivateKey) ([]byte, error) {
+
+	sec1, err := MarshalSM2PrivateKey(priv)
+	if err != nil {
+		return nil, err
+	}
+	params, err := asn1.Marshal(oidNamedCurveSM2)
+	if err != nil {
+		return nil, err
+	}
+
+	// 算法为id-ecPublicKey，参数为SM2曲线OID
+	return asn1.Marshal(pkcs8{
+		Version:    0,
+		Algo:       pkix.AlgorithmIdentifier{Algorithm: oidPublicKeyEC, Parameters: asn1.RawValue{FullBytes: params}},
+		PrivateKey: sec1,
+	})
+}
+
+func ParsePKCS8PrivateKey(der []byte) (*PrivateKey, error) {
+
+	var key pkcs8
+	if _, err := asn1.Unmarshal(der, &key); err != nil {
+		return nil, err
+	}
+	if err := checkAlgorithm(key.Algo); err != nil {
+		return nil, err
+	}
+	return ParseSM2PrivateKey(key.PrivateKey)
+}
+
+func MarshalPKIXPublicKey(pub *PublicKey) ([]byte, error) {
+
+	params, err := asn1.Marshal(oidNamedCurveSM2)
+	if err != nil {
+		return nil, err
+	}
+	point := elliptic.Marshal(pub.Curve, pub.X, pub.Y)
+	return asn1.Marshal(pkixPublicKey{
+		Algo:      pkix.AlgorithmIdentifier{Algorithm: oidPublicKeyEC, Parameters: asn1.RawValue{FullBytes: params}},
+		PublicKey: asn1.BitString{Bytes: point, BitLength: 8 * len(point)},
+	})
+}
+
+func ParsePKIXPublicKey(der []byte) (*PublicKey, error) {
+
+	var key pkixPublicKey
+	if _, err := asn1.Unmarshal(der, &key); err != nil {
+		return nil, err
+	}
+	if err := checkAlgorithm(key.Algo); err != nil {
+		return nil, err
+	}
+
+	// 未压缩点 04 || x || y，Unmarshal会校验点是否在曲线上
+	curve := P256Sm2()
+	x, y := elliptic.Unmarshal(curve, key.PublicKey.RightAlign())
+	if x == nil {
+		return nil, errors.New("sm2: invalid public key")
+	}
+	return &PublicKey{Curve: curve, X: x, Y: y}, nil
+}
+
+func EncodePrivateKeyPEM(priv *PrivateKey) ([]byte, error) {
+
+	der, err := MarshalPKCS8PrivateKey(priv)
+	if err != nil {
+		return nil, err
+	}
+
+	// 返回PEM编码的"PRIVATE KEY"块
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil
+}
+
+func EncodePublicKeyPEM(pub *PublicKey) ([]byte, error) {
+
+	der, err := MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return nil, err
+	}
+
+	// 返回PEM编码的"PUBLIC KEY"块
+	return pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), nil
+}
+
+func BuildSM2PublicKey(publicKey []byte) (*PublicKey, error) {
+
+	// 返回解码得到的pem.Block和剩余未解码的数据。如果未发现PEM数据，返回(nil, data)
+	block, _ := pem.Decode(publicKey)
+	if block == nil {
+		return nil, errors.New("public key error")
+	}
+
+	// 解析一个DER编码的公钥。这些公钥一般在以"BEGIN PUBLIC KEY"出现的PEM块中
+	return ParsePKIXPublicKey(block.Bytes)
+}
+
+func BuildSM2PrivateKey(privateKey []byte) (*PrivateKey, error) {
+
+	// 返回解码得到的pem.Block和剩余未解码的数据。如果未发现PEM数据，返回(nil, data)
+	block, _ := pem.Decode(privateKey)
+	if block == nil {
+		return nil, errors.New("private key error")
+	}
+
+	// "EC PRIVATE KEY"为SEC 1格式，其余按PKCS#8解析
+	if block.Type == "EC PRIVATE KEY" {
+		return ParseSM2PrivateKey(block.Bytes)
+	}
+	return ParsePKCS8PrivateKey(block.Bytes)
+}
+
+func checkAlgorithm(algo pkix.AlgorithmIdentifier) error {
+
+	// 部分实现直接以SM2曲线OID作为算法标识
+	if algo.Algorithm.Equal(oidNamedCurveSM2) {
+		return nil
+	}
+	if !algo.Algorithm.Equal(oidPublicKeyEC) {
+		return errors.New("sm2: not an EC key")
+	}
+	var curve asn1.ObjectIdentifier
+	if _, err := asn1.Unmarshal(algo.Parameters.FullBytes, &curve); err != nil {
+		return err
+	}
+	if !curve.Equal(oidNamedCurveSM2) {
+		return errors.New("sm2: not an SM2 key")
+	}
+	return nil
+}
@@ -0,0 +1,240 @@
+package extra
+
+import (
+	"crypto"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/asn1"
+	"encoding/binary"
+	"errors"
+	sm3 "github.com/zc2638/go-standard/src/crypto/sm3/extra"
+	"io"
+	"math/big"
+)
+
+// 签名时未指定用户身份标识时使用的默认ID，GM/T 0009-2012
+var DefaultUID = []byte("1234567812345678")
+
+type PublicKey struct {
+	elliptic.Curve
+	X, Y *big.Int
+}
+
+type PrivateKey struct {
+	PublicKey
+	D *big.Int
+}
+
+// SM2签名参数，实现crypto.SignerOpts
+type SignerOpts struct {
+	UID []byte
+}
+
+func (*SignerOpts) HashFunc() crypto.Hash { return crypto.Hash(0) }
+
+type sm2Signature struct {
+	R, S *big.Int
+}
+
+func (priv *PrivateKey) Public() crypto.PublicKey {
+	return &priv.PublicKey
+}
+
+// 实现crypto.Signer接口，对原始消息签名，签名为DER编码的(r, s)。opts为*SignerOpts时使用其中的UID
+func (priv *PrivateKey) Sign(random io.Reader, msg []byte, opts crypto.SignerOpts) ([]byte, error) {
+	uid := DefaultUID
+	if o, ok := opts.(*SignerOpts); ok && o.UID != nil {
+		uid = o.UID
+	}
+	r, s, err := Sign(random, priv, uid, msg)
+	if err != nil {
+		return nil, err
+	}
+	return asn1.Marshal(sm2Signature{r, s})
+}
+
+func GenerateKey(random io.Reader) (*PrivateKey, error) {
+
+	if random == nil {
+		random = rand.Reader
+	}
+	curve := P256Sm2()
+
+	// SM2要求私钥 d ∈ [1, n-2]
+	d, err := randScalar(random, new(big.Int).Sub(curve.Params().N, big.NewInt(1)))
+	if err != nil {
+		return nil, err
+	}
+	return newPrivateKey(d), nil
+}
+
+func newPrivateKey(d *big.Int) *PrivateKey {
+	curve := P256Sm2()
+	priv := &PrivateKey{D: d}
+	priv.Curve = curve
+	priv.X, priv.Y = curve.ScalarBaseMult(d.Bytes())
+	return priv
+}
+
+// 计算用户杂凑值 ZA = SM3(ENTLA || IDA || a || b || xG || yG || xA || yA)
+func ZA(pub *PublicKey, uid []byte) ([]byte, error) {
+
+	if len(uid) >= 8192 {
+		return nil, errors.New("sm2: uid too long")
+	}
+	params := pub.Curve.Params()
+
+	h := sm3.New()
+	// ENTLA为ID的比特长度，两个字节
+	var entla [2]byte
+	binary.BigEndian.PutUint16(entla[:], uint16(len(uid)*8))
+	h.Write(entla[:])
+	h.Write(uid)
+	h.Write(toBytes(curveA(pub.Curve)))
+	h.Write(toBytes(params.B))
+	h.Write(toBytes(params.Gx))
+	h.Write(toBytes(params.Gy))
+	h.Write(toBytes(pub.X))
+	h.Write(toBytes(pub.Y))
+	return h.Sum(nil), nil
+}
+
+func Sign(random io.Reader, priv *PrivateKey, uid, msg []byte) (r, s *big.Int, err error) {
+
+	if random == nil {
+		random = rand.Reader
+	}
+	e, err := msgDigest(&priv.PublicKey, uid, msg)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	n := priv.Params().N
+	// (1 + d)^-1 mod n
+	dInv := new(big.Int).Add(priv.D, big.NewInt(1))
+	dInv.ModInverse(dInv, n)
+
+	for {
+		// 随机数 k ∈ [1, n-1]
+		k, err := randScalar(random, n)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		// (x1, y1) = [k]G，r = (e + x1) mod n
+		x1, _ := priv.ScalarBaseMult(k.Bytes())
+		r = new(big.Int).Add(e, x1)
+		r.Mod(r, n)
+		if r.Sign() == 0 || new(big.Int).Add(r, k).Cmp(n) == 0 {
+			continue
+		}
+
+		// s = ((1 + d)^-1 * (k - r*d)) mod n
+		s = new(big.Int).Mul(r, priv.D)
+		s.Sub(k, s)
+		s.Mul(s, dInv)
+		s.Mod(s, n)
+		if s.Sign() == 0 {
+			continue
+		}
+		return r, s, nil
+	}
+}
+
+func Verify(pub *PublicKey, uid, msg []byte, r, s *big.Int) bool {
+
+	n := pub.Params().N
+	one := big.NewInt(1)
+	// r, s ∈ [1, n-1]
+	if r.Cmp(one) < 0 || s.Cmp(one) < 0 || r.Cmp(n) >= 0 || s.Cmp(n) >= 0 {
+		return false
+	}
+	if !pub.IsOnCurve(pub.X, pub.Y) {
+		return false
+	}
+
+	e, err := msgDigest(pub, uid, msg)
+	if err != nil {
+		return false
+	}
+
+	// t = (r + s) mod n，t不能为0
+	t := new(big.Int).Add(r, s)
+	t.Mod(t, n)
+	if t.Sign() == 0 {
+		return false
+	}
+
+	// (x1, y1) = [s]G + [t]P，R = (e + x1) mod n
+	sx, sy := pub.ScalarBaseMult(s.Bytes())
+	tx, ty := pub.ScalarMult(pub.X, pub.Y, t.Bytes())
+	x1, _ := pub.Add(sx, sy, tx, ty)
+	x1.Add(x1, e)
+	x1.Mod(x1, n)
+	return x1.Cmp(r) == 0
+}
+
+func SignASN1(random io.Reader, priv *PrivateKey, uid, msg []byte) ([]byte, error) {
+	r, s, err := Sign(random, priv, uid, msg)
+	if err != nil {
+		return nil, err
+	}
+	return asn1.Marshal(sm2Signature{r, s})
+}
+
+func VerifyASN1(pub *PublicKey, uid, msg, sig []byte) bool {
+	var rs sm2Signature
+	if rest, err := asn1.Unmarshal(sig, &rs); err != nil || len(rest) > 0 {
+		return false
+	}
+	return Verify(pub, uid, msg, rs.R, rs.S)
+}
+
+func msgDigest(pub *PublicKey, uid, msg []byte) (*big.Int, error) {
+
+	// e = SM3(ZA || M)
+	za, err := ZA(pub, uid)
+	if err != nil {
+		return nil, err
+	}
+	h := sm3.New()
+	h.Write(za)
+	h.Write(msg)
+	return new(big.Int).SetBytes(h.Sum(nil)), nil
+}
+
+func randScalar(random io.Reader, max *big.Int) (*big.Int, error) {
+
+	// 返回 [1, max-1] 内均匀分布的随机数
+	k, err := rand.Int(random, new(big.Int).Sub(max, big.NewInt(1)))
+	if err != nil {
+		return nil, err
+	}
+	return k.Add(k, big.NewInt(1)), nil
+}
+
+func toBytes(n *big.Int) []byte {
+
+	// 按32字节左侧补0
+	b := make([]byte, 32)
+	nb := n.Bytes()
+	copy(b[32-len(nb):], nb)
+	return b
+}
+
+// SM2密钥派生函数，GM/T 0003.4-2012 5.4.3
+// K = SM3(Z || ct)，ct为从1开始的32位大端计数器
+func kdf(length int, z ...[]byte) []byte {
+	var ct [4]byte
+	out := make([]byte, 0, length+sm3.Size)
+	for i := uint32(1); len(out) < length; i++ {
+		binary.BigEndian.PutUint32(ct[:], i)
+		h := sm3.New()
+		for _, v := range z {
+			h.Write(v)
+		}
+		h.Write(ct[:])
+		out = h.Sum(out)
+	}
+	return out[:length]
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"crypto/hmac"
+	"encoding/hex"
+	"fmt"
+	"github.com/zc2638/go-standard/src/crypto/sm3/extra"
+	"log"
+	"strings"
+)
+
+// 实现了GM/T 0004-2012规定的SM3密码杂凑算法
+func main() {
+
+	// 返回一个新的使用SM3校验的hash.Hash
+	h := extra.New()
+	// 写入
+	h.Write([]byte("Hello World"))
+	// 返回添加b到当前的hash值后的新切片，不会改变底层的hash状态
+	m := h.Sum(nil)
+	fmt.Println(hex.EncodeToString(m))
+
+	// 直接使用extra.Sum
+	m2 := extra.Sum([]byte("Hello World"))
+	fmt.Println(hex.EncodeToString(m2[:]))
+
+	// 作为hmac的底层hash使用，即HMAC-SM3
+	mac := hmac.New(extra.New, []byte("test hmac key"))
+	mac.Write([]byte("Hello World"))
+	fmt.Println(hex.EncodeToString(mac.Sum(nil)))
+
+	// GM/T 0004-2012 附录A 测试向量
+	TestVectors()
+}
+
+func TestVectors() {
+
+	var vectors = []struct {
+		msg, sum string
+	}{
+		// 示例1: "abc"
+		{"abc", "66c7f0f462eeedd9d1f2d46bdc10e4e24167c4875cf2f7a2297da02b8f4ba8e0"},
+		// 示例2: "abcd"重复16次，共512比特
+		{strings.Repeat("abcd", 16), "debe9ff92275b8a138604889c18e5a4d6fdb70e5387e5765293dcba39c0c5732"},
+	}
+
+	for i, v := range vectors {
+		sum := extra.Sum([]byte(v.msg))
+		if hex.EncodeToString(sum[:]) != v.sum {
+			log.Fatalf("vector %d: sm3 mismatch", i+1)
+		}
+
+		// 分段写入结果应一致
+		h := extra.New()
+		for _, c := range []byte(v.msg) {
+			h.Write([]byte{c})
+		}
+		if hex.EncodeToString(h.Sum(nil)) != v.sum {
+			log.Fatalf("vector %d: sm3 streaming mismatch", i+1)
+		}
+	}
+	fmt.Println("SM3 测试向量校验通过")
+}
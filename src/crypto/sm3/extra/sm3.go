@@ -0,0 +1,168 @@
+package extra
+
+import (
+	"encoding/binary"
+	"hash"
+	"math/bits"
+)
+
+// SM3杂凑算法，GM/T 0004-2012
+const (
+	// SM3校验和的字节数
+	Size = 32
+	// SM3的块大小
+	BlockSize = 64
+)
+
+// 初始值IV
+var iv = [8]uint32{
+	0x7380166f, 0x4914b2b9, 0x172442d7, 0xda8a0600,
+	0xa96f30bc, 0x163138aa, 0xe38dee4d, 0xb0fb0e4e,
+}
+
+type digest struct {
+	h   [8]uint32
+	x   [BlockSize]byte
+	nx  int
+	len uint64
+}
+
+// 返回一个新的使用SM3校验的hash.Hash，可用于hmac.New(extra.New, key)
+func New() hash.Hash {
+	d := new(digest)
+	d.Reset()
+	return d
+}
+
+// 返回数据的SM3校验和
+func Sum(data []byte) [Size]byte {
+	d := new(digest)
+	d.Reset()
+	d.Write(data)
+
+	var out [Size]byte
+	copy(out[:], d.Sum(nil))
+	return out
+}
+
+func (d *digest) Reset() {
+	d.h = iv
+	d.nx = 0
+	d.len = 0
+}
+
+func (d *digest) Size() int { return Size }
+
+func (d *digest) BlockSize() int { return BlockSize }
+
+func (d *digest) Write(p []byte) (int, error) {
+
+	n := len(p)
+	d.len += uint64(n)
+
+	// 先补满缓存中未处理的块
+	if d.nx > 0 {
+		c := copy(d.x[d.nx:], p)
+		d.nx += c
+		if d.nx == BlockSize {
+			block(&d.h, d.x[:])
+			d.nx = 0
+		}
+		p = p[c:]
+	}
+
+	// 按64字节分组处理
+	for len(p) >= BlockSize {
+		block(&d.h, p[:BlockSize])
+		p = p[BlockSize:]
+	}
+
+	// 剩余部分放入缓存
+	if len(p) > 0 {
+		d.nx = copy(d.x[:], p)
+	}
+	return n, nil
+}
+
+func (d *digest) Sum(in []byte) []byte {
+
+	// 复制一份状态，Sum不改变底层hash状态
+	d0 := *d
+
+	// 填充: 追加比特1，补0至长度 ≡ 448 (mod 512)，再追加64位大端消息比特长度
+	bitLen := d0.len << 3
+	var tmp [BlockSize + 8]byte
+	tmp[0] = 0x80
+	padLen := 56 - int(d0.len%BlockSize)
+	if padLen <= 0 {
+		padLen += BlockSize
+	}
+	binary.BigEndian.PutUint64(tmp[padLen:], bitLen)
+	d0.Write(tmp[:padLen+8])
+
+	var out [Size]byte
+	for i, v := range d0.h {
+		binary.BigEndian.PutUint32(out[i*4:], v)
+	}
+	return append(in, out[:]...)
+}
+
+func p0(x uint32) uint32 {
+	return x ^ bits.RotateLeft32(x, 9) ^ bits.RotateLeft32(x, 17)
+}
+
+func p1(x uint32) uint32 {
+	return x ^ bits.RotateLeft32(x, 15) ^ bits.RotateLeft32(x, 23)
+}
+
+func block(h *[8]uint32, p []byte) {
+
+	// 消息扩展，生成W[0..67]与W'[0..63]
+	var w [68]uint32
+	for i := 0; i < 16; i++ {
+		w[i] = binary.BigEndian.Uint32(p[i*4:])
+	}
+	for j := 16; j < 68; j++ {
+		w[j] = p1(w[j-16]^w[j-9]^bits.RotateLeft32(w[j-3], 15)) ^ bits.RotateLeft32(w[j-13], 7) ^ w[j-6]
+	}
+
+	a, b, c, d, e, f, g, hh := h[0], h[1], h[2], h[3], h[4], h[5], h[6], h[7]
+
+	// 压缩函数，共64轮
+	for j := 0; j < 64; j++ {
+		var t, ff, gg uint32
+		if j < 16 {
+			t = 0x79cc4519
+			ff = a ^ b ^ c
+			gg = e ^ f ^ g
+		} else {
+			t = 0x7a879d8a
+			ff = (a & b) | (a & c) | (b & c)
+			gg = (e & f) | (^e & g)
+		}
+
+		a12 := bits.RotateLeft32(a, 12)
+		ss1 := bits.RotateLeft32(a12+e+bits.RotateLeft32(t, j%32), 7)
+		ss2 := ss1 ^ a12
+		tt1 := ff + d + ss2 + (w[j] ^ w[j+4])
+		tt2 := gg + hh + ss1 + w[j]
+
+		d = c
+		c = bits.RotateLeft32(b, 9)
+		b = a
+		a = tt1
+		hh = g
+		g = bits.RotateLeft32(f, 19)
+		f = e
+		e = p0(tt2)
+	}
+
+	h[0] ^= a
+	h[1] ^= b
+	h[2] ^= c
+	h[3] ^= d
+	h[4] ^= e
+	h[5] ^= f
+	h[6] ^= g
+	h[7] ^= hh
+}
@@ -0,0 +1,270 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	aesExtra "github.com/zc2638/go-standard/src/crypto/aes/extra"
+	"github.com/zc2638/go-standard/src/crypto/sm4/extra"
+	"io"
+	"log"
+)
+
+// 实现了GM/T 0002-2012规定的SM4分组密码算法
+// SM4实现了cipher.Block接口，各工作模式直接使用aes/extra中以cipher.Block为参数的函数
+func main() {
+
+	// GM/T 0002-2012 附录A 测试向量
+	TestVectors()
+
+	// SM4-CBC加密/解密
+	CBC()
+	// SM4-GCM加密/解密
+	GCM()
+	// SM4-CFB加密/解密
+	CFB()
+	// SM4-CTR加密/解密
+	CTR()
+	// SM4-OFB加密/解密
+	OFB()
+	// SM4-OFB加密/解密，使用cipher的StreamReader加密、cipher的StreamWriter解密
+	OFBStream()
+}
+
+func TestVectors() {
+
+	key, _ := hex.DecodeString("0123456789abcdeffedcba9876543210")
+	plain, _ := hex.DecodeString("0123456789abcdeffedcba9876543210")
+
+	// 创建一个cipher.Block，参数key为16字节密钥
+	block, err := extra.NewCipher(key)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// 示例1: 对一组明文加密一次
+	dst := make([]byte, extra.BlockSize)
+	block.Encrypt(dst, plain)
+	if hex.EncodeToString(dst) != "681edf34d206965e86b3e94f536e4246" {
+		log.Fatal("sm4 encrypt mismatch")
+	}
+	block.Decrypt(dst, dst)
+	if !bytes.Equal(dst, plain) {
+		log.Fatal("sm4 decrypt mismatch")
+	}
+
+	// 示例2: 使用同一密钥对明文反复加密1000000次
+	copy(dst, plain)
+	for i := 0; i < 1000000; i++ {
+		block.Encrypt(dst, dst)
+	}
+	if hex.EncodeToString(dst) != "595298c7c6fd271f0402f804c33d3f66" {
+		log.Fatal("sm4 1000000 rounds mismatch")
+	}
+	fmt.Println("SM4 测试向量校验通过")
+}
+
+func CBC() {
+
+	// 声明一个16字节的key
+	var key = []byte("example key 1234")
+	// 声明一个随意长度的 需加密内容
+	var origin = []byte("need to sm4-cbc encode test text")
+	// 声明一个16字节的iv
+	var iv = []byte("example iv tests")
+
+	// 创建SM4的cipher.Block，参数key为16字节密钥
+	block, err := extra.NewCipher(key)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// 加密
+	cipherText, err := aesExtra.CBCEncryptWithBlock(block, origin, iv)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// byte转base64字符串
+	cipherTextStr := base64.StdEncoding.EncodeToString(cipherText)
+	fmt.Println("SM4-CBC加密内容: ", cipherTextStr)
+
+	// 解密
+	originText, err := aesExtra.CBCDecryptWithBlock(block, cipherText, iv)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println("SM4-CBC解密内容: ", string(originText))
+}
+
+func GCM() {
+
+	// 声明一个16字节的key
+	var key = []byte("0123456789ABCDEF")
+	// 声明一个随意长度的 需加密内容
+	var origin = []byte("need to sm4-gcm encode test text")
+
+	// 初始化一个长度为12字节的空的[]byte，不要使用超过2^32个随机非字符，因为存在重复的风险
+	nonce := make([]byte, 12)
+	// 使用rand随机生成数据
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		log.Fatal(err)
+	}
+
+	// 创建SM4的cipher.Block，参数key为16字节密钥
+	block, err := extra.NewCipher(key)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// 加密
+	cipherText, err := aesExtra.GCMEncryptWithBlock(block, origin, nonce)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// byte转十六进制字符串
+	cipherTextStr := hex.EncodeToString(cipherText)
+	fmt.Println("SM4-GCM加密内容: ", cipherTextStr)
+
+	// 解密
+	originText, err := aesExtra.GCMDecryptWithBlock(block, cipherText, nonce)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println("SM4-GCM解密内容: ", string(originText))
+}
+
+func CFB() {
+
+	// 声明一个16字节的key
+	var key = []byte("0123456789ABCDEF")
+	// 声明一个随意长度的 需加密内容
+	var origin = []byte("need to sm4-cfb encode test text")
+	// 声明一个16字节的iv
+	var iv = []byte("example iv tests")
+
+	// 创建SM4的cipher.Block，参数key为16字节密钥
+	block, err := extra.NewCipher(key)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// 加密
+	cipherText, err := aesExtra.CFBEncryptWithBlock(block, origin, iv)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// byte转十六进制字符串
+	cipherTextStr := hex.EncodeToString(cipherText)
+	fmt.Println("SM4-CFB加密内容: ", cipherTextStr)
+
+	// 解密
+	originText, err := aesExtra.CFBDecryptWithBlock(block, cipherText, iv)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println("SM4-CFB解密内容: ", string(originText))
+}
+
+func CTR() {
+
+	// 声明一个16字节的key
+	var key = []byte("0123456789ABCDEF")
+	// 声明一个随意长度的 需加密内容
+	var origin = []byte("need to sm4-ctr encode test text")
+	// 声明一个16字节的iv
+	var iv = []byte("example iv tests")
+
+	// 创建SM4的cipher.Block，参数key为16字节密钥
+	block, err := extra.NewCipher(key)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// 加密
+	cipherText, err := aesExtra.CTREncryptWithBlock(block, origin, iv)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// byte转十六进制字符串
+	cipherTextStr := hex.EncodeToString(cipherText)
+	fmt.Println("SM4-CTR加密内容: ", cipherTextStr)
+
+	// 解密
+	originText, err := aesExtra.CTRDecryptWithBlock(block, cipherText, iv)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println("SM4-CTR解密内容: ", string(originText))
+}
+
+func OFB() {
+
+	// 声明一个16字节的key
+	var key = []byte("0123456789ABCDEF")
+	// 声明一个随意长度的 需加密内容
+	var origin = []byte("need to sm4-ofb encode test text")
+	// 声明一个16字节的iv
+	var iv = []byte("example iv tests")
+
+	// 创建SM4的cipher.Block，参数key为16字节密钥
+	block, err := extra.NewCipher(key)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// 加密
+	cipherText, err := aesExtra.OFBEncryptWithBlock(block, origin, iv)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// byte转十六进制字符串
+	cipherTextStr := hex.EncodeToString(cipherText)
+	fmt.Println("SM4-OFB加密内容: ", cipherTextStr)
+
+	// 解密
+	originText, err := aesExtra.OFBDecryptWithBlock(block, cipherText, iv)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println("SM4-OFB解密内容: ", string(originText))
+}
+
+func OFBStream() {
+
+	// 声明一个16字节的key
+	var key = []byte("0123456789ABCDEF")
+	// 声明一个随意长度的 需加密内容
+	var origin = []byte("need to sm4-ofb-stream encode test text")
+	// 声明一个16字节的iv
+	var iv = []byte("example iv tests")
+
+	// 创建SM4的cipher.Block，参数key为16字节密钥
+	block, err := extra.NewCipher(key)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// StreamReader方式加密
+	cipherText, err := aesExtra.OFBEncryptStreamReaderWithBlock(block, origin, iv)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// byte转十六进制字符串
+	cipherTextStr := hex.EncodeToString(cipherText)
+	fmt.Println("SM4-OFB-Stream方式加密内容: ", cipherTextStr)
+
+	// StreamWriter方式解密
+	originText, err := aesExtra.OFBDecryptStreamWriterWithBlock(block, cipherText, iv)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println("SM4-OFB-Stream方式解密内容: ", string(originText))
+}
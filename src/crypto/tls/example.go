@@ -1,11 +1,21 @@
 package main
 
 import (
+	"crypto/rand"
 	"crypto/tls"
 	"crypto/x509"
+	"crypto/x509/pkix"
 	"fmt"
+	tlsExtra "github.com/zc2638/go-standard/src/crypto/tls/extra"
+	x509Extra "github.com/zc2638/go-standard/src/crypto/x509/extra"
+	"io/ioutil"
 	"log"
 	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
 	"time"
 )
 
@@ -46,20 +56,31 @@ func main() {
 		ServerName:               "",                     // 用于认证返回证书的主机名（除非设置了InsecureSkipVerify）。也被用在客户端的握手里，以支持虚拟主机。
 		ClientAuth:               tls.NoClientCert,        // 决定服务端的认证策略，默认是NoClientCert
 		ClientCAs:                nil,                     // 定义权威根证书，服务端会在采用ClientAuth策略时使用它来认证客户端证书
-		InsecureSkipVerify:       true,                    // 控制客户端是否跳过认证服务端的证书链和主机名。如果InsecureSkipVerify为true，TLS连接会接受服务端提供的任何证书和该证书中的任何主机名。此时，TLS连接容易遭受中间人攻击，这种设置只应用于测试
+		InsecureSkipVerify:       false,                   // 控制客户端是否跳过认证服务端的证书链和主机名。如果InsecureSkipVerify为true，TLS连接会接受服务端提供的任何证书和该证书中的任何主机名。此时，TLS连接容易遭受中间人攻击，这种设置只应用于测试
 		CipherSuites:             nil,                     // 支持的加密组合列表。如果CipherSuites为nil，TLS连接会使用本包的实现支持的密码组合列表
 		PreferServerCipherSuites: false,                   // 本字段控制服务端是选择客户端最期望的密码组合还是服务端最期望的密码组合。如果本字段为true，服务端会优先选择CipherSuites字段中靠前的密码组合使用
 		SessionTicketsDisabled:   false,                   // 可以设为false以关闭会话恢复支持
 		SessionTicketKey:         [32]byte{},                     // 被TLS服务端用于提供会话恢复服务。如果本字段为零值，它会在第一次服务端握手之前填写上随机数据。如果多个服务端都在终止和同一主机的连接，它们应拥有相同的SessionTicketKey。如果SessionTicketKey泄露了，使用该键的之前的记录和未来的TLS连接可能会被盗用
 		ClientSessionCache:       nil,                     // 是ClientSessionState的缓存，用于恢复TLS会话
-		MinVersion:               0,                       // 可接受的最低SSL/TLS版本。如果为0，会将SSLv3作为最低版本
-		MaxVersion:               0,                       // 可接受的最高SSL/TLS版本。如果为0，会将本包使用的版本作为最高版本，目前是TLS 1.2
+		MinVersion:               0,                       // 可接受的最低SSL/TLS版本。如果为0，当前版本的Go客户端与服务端都使用TLS 1.2（早期版本为SSLv3/TLS 1.0），推荐使用extra中的配置档案设置
+		MaxVersion:               0,                       // 可接受的最高SSL/TLS版本。如果为0，会将本包使用的版本作为最高版本，目前是TLS 1.3
 		CurvePreferences:         nil,                     // 用于ECDHE握手的椭圆曲线的ID，按优先度排序。如为空，会使用默认值
 	}
 
-	// 客户端。使用证书访问tls网络
-	client(roots)
-	clientWithDialer(roots)
+	// 证书文件变化后自动重新加载的服务端
+	reloadServer()
+	// 双向TLS，服务端校验客户端证书并按身份授权
+	mutualTLS()
+	// 使用配置档案的服务端及握手审计
+	auditServer()
+	// 客户端校验证书链后再检查公钥pin
+	pinnedClient()
+
+	// 客户端。使用证书访问本地的tls服务
+	local, localRoots := localServer()
+	client(local.Listener.Addr().String(), localRoots)
+	clientWithDialer(local.Listener.Addr().String(), localRoots)
+	local.Close()
 	// 服务端。创建一个认证证书的监听
 	server(cert)
 
@@ -117,10 +138,27 @@ EKTcWGekdmdDPsHloRNtsiCa697B2O9IFA==
 -----END EC PRIVATE KEY-----
 `
 
-func client(roots *x509.CertPool) {
+// 本地的tls服务及校验其证书的根证书池，示例不依赖外部网络
+func localServer() (*httptest.Server, *x509.CertPool) {
+
+	pki, err := tlsExtra.NewTestPKI()
+	if err != nil {
+		log.Fatal(err)
+	}
+	cert, err := pki.ServerCert("127.0.0.1")
+	if err != nil {
+		log.Fatal(err)
+	}
+	srv := httptest.NewUnstartedServer(http.NotFoundHandler())
+	srv.TLS = &tls.Config{Certificates: []tls.Certificate{cert}}
+	srv.StartTLS()
+	return srv, pki.Pool()
+}
+
+func client(addr string, roots *x509.CertPool) {
 
 	// 使用net.Dial连接指定的网络和地址，然后发起TLS握手，返回生成的TLS连接。Dial会将nil的配置视为零值的配置
-	conn, err := tls.Dial("tcp", "www.baidu.com:443", &tls.Config{RootCAs: roots})
+	conn, err := tls.Dial("tcp", addr, &tls.Config{RootCAs: roots})
 	if err != nil {
 		panic("failed to connect: " + err.Error())
 	}
@@ -131,7 +169,7 @@ func client(roots *x509.CertPool) {
 	}
 }
 
-func clientWithDialer(roots *x509.CertPool) {
+func clientWithDialer(addr string, roots *x509.CertPool) {
 
 	// 初始化一个net.Dialer,地址建立连接时的参数
 	dialer := new(net.Dialer)
@@ -139,7 +177,7 @@ func clientWithDialer(roots *x509.CertPool) {
 	dialer.Deadline = time.Now().Add(time.Minute * 2) // 一个具体的时间点期限，超过该期限后，dial操作就会失败
 
 	// 使用dialer.Dial连接指定的网络和地址，然后发起TLS握手，返回生成的TLS连接。dialer中的超时和期限设置会将连接和TLS握手作为一个整体来应用
-	conns, err := tls.DialWithDialer(dialer, "tcp", "www.baidu.com:443", &tls.Config{RootCAs: roots})
+	conns, err := tls.DialWithDialer(dialer, "tcp", addr, &tls.Config{RootCAs: roots})
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -188,3 +226,279 @@ func server(cert tls.Certificate) {
 		}(conn)
 	}
 }
+
+func reloadServer() {
+
+	dir, err := ioutil.TempDir("", "tls-reload")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// 使用临时CA签发default与api两个证书
+	caKey, _ := x509Extra.GenerateKey("p256")
+	ca, err := x509Extra.NewRootCA(pkix.Name{CommonName: "Reload Example CA"}, caKey, 24*time.Hour, 0, nil)
+	if err != nil {
+		log.Fatal(err)
+	}
+	issueFiles(ca, dir, "default", "localhost")
+	issueFiles(ca, dir, "api", "api.internal")
+
+	// 按SNI从目录中选择证书
+	m, err := tlsExtra.NewDirCertManager(dir)
+	if err != nil {
+		log.Fatal(err)
+	}
+	m.OnReload = func(certFile string, err error) {
+		fmt.Println("reload", filepath.Base(certFile), err)
+	}
+	// 实际使用时在后台定时检查: go m.Watch(ctx)，这里直接调用Reload
+
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", m.TLSConfig())
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			conn.(*tls.Conn).Handshake()
+			conn.Close()
+		}
+	}()
+
+	roots, _ := ca.Pools()
+	serial := func(serverName string) string {
+		conn, err := tls.Dial("tcp", listener.Addr().String(), &tls.Config{RootCAs: roots, ServerName: serverName})
+		if err != nil {
+			return err.Error()
+		}
+		defer conn.Close()
+		return conn.ConnectionState().PeerCertificates[0].SerialNumber.Text(16)
+	}
+	fmt.Println("api.internal:", serial("api.internal"))
+	fmt.Println("localhost:", serial("localhost"))
+
+	// 轮换证书，无需重启即可生效
+	issueFiles(ca, dir, "api", "api.internal")
+	m.Reload()
+	fmt.Println("api.internal after rotation:", serial("api.internal"))
+
+	// 私钥与证书不匹配时拒绝加载，继续使用旧证书
+	key, _ := x509Extra.GenerateKey("p256")
+	keyPEM, _ := x509Extra.EncodeKeyPEM(key)
+	ioutil.WriteFile(filepath.Join(dir, "api.key"), keyPEM, 0600)
+	m.Reload()
+	fmt.Println("api.internal after bad key:", serial("api.internal"))
+
+	// 证书到期时间，用于告警
+	for _, s := range m.Status() {
+		fmt.Println(filepath.Base(s.CertFile), s.DNSNames, s.NotAfter.Format(time.RFC3339), s.Error)
+	}
+	fmt.Println("expiring within 30 days:", len(m.ExpiringWithin(30*24*time.Hour)))
+}
+
+// 签发证书并写入<name>.crt与<name>.key
+func issueFiles(ca *x509Extra.CA, dir, name string, hosts ...string) {
+
+	key, err := x509Extra.GenerateKey("p256")
+	if err != nil {
+		log.Fatal(err)
+	}
+	der, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+		Subject:  pkix.Name{CommonName: hosts[0]},
+		DNSNames: hosts,
+	}, key)
+	if err != nil {
+		log.Fatal(err)
+	}
+	csr, err := x509.ParseCertificateRequest(der)
+	if err != nil {
+		log.Fatal(err)
+	}
+	cert, err := ca.SignCSR(csr, x509Extra.LeafOptions{Validity: time.Hour})
+	if err != nil {
+		log.Fatal(err)
+	}
+	keyPEM, err := x509Extra.EncodeKeyPEM(key)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, name+".crt"), ca.Bundle(cert), 0644); err != nil {
+		log.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, name+".key"), keyPEM, 0600); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func mutualTLS() {
+
+	// 临时CA签发服务端与客户端证书
+	pki, err := tlsExtra.NewTestPKI()
+	if err != nil {
+		log.Fatal(err)
+	}
+	serverCert, err := pki.ServerCert("localhost", "127.0.0.1")
+	if err != nil {
+		log.Fatal(err)
+	}
+	webCert, err := pki.ClientCert("spiffe://example.org/ns/prod/sa/web")
+	if err != nil {
+		log.Fatal(err)
+	}
+	batchCert, err := pki.ClientCert("spiffe://example.org/ns/dev/sa/batch")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// 只允许prod命名空间的服务访问
+	allow := &tlsExtra.AllowList{URIs: []string{"spiffe://example.org/ns/prod/*"}}
+	handler := tlsExtra.RequireClientCert(allow, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, _ := tlsExtra.IdentityFromContext(r.Context())
+		fmt.Fprintf(w, "hello %s", id.SPIFFEID)
+	}))
+	srv := httptest.NewUnstartedServer(handler)
+	srv.TLS = pki.ServerConfig(serverCert)
+	srv.StartTLS()
+	defer srv.Close()
+
+	get := func(cfg *tls.Config) {
+		client := &http.Client{Transport: &http.Transport{TLSClientConfig: cfg}}
+		resp, err := client.Get(srv.URL)
+		if err != nil {
+			fmt.Println("mtls:", err)
+			return
+		}
+		defer resp.Body.Close()
+		body, _ := ioutil.ReadAll(resp.Body)
+		fmt.Println("mtls:", resp.Status, string(body))
+	}
+	get(pki.ClientConfig(webCert, "127.0.0.1"))
+	get(pki.ClientConfig(batchCert, "127.0.0.1"))
+	// 不提供客户端证书时握手失败
+	get(&tls.Config{RootCAs: pki.Pool()})
+}
+
+func auditServer() {
+
+	pki, err := tlsExtra.NewTestPKI()
+	if err != nil {
+		log.Fatal(err)
+	}
+	ecdsaCert, err := pki.ServerCert("127.0.0.1")
+	if err != nil {
+		log.Fatal(err)
+	}
+	// 仓库中已过期的RSA自签名证书
+	rsaCert, err := tls.LoadX509KeyPair("testdata/x509_cert.pem", "testdata/x509_key.pem")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	for _, c := range []struct {
+		profile tlsExtra.Profile
+		cert    tls.Certificate
+	}{
+		{tlsExtra.Modern, ecdsaCert},
+		{tlsExtra.Intermediate, ecdsaCert},
+		{tlsExtra.Compatible, rsaCert},
+	} {
+		srv := httptest.NewUnstartedServer(handler)
+		// 审计时的失败握手会产生大量日志
+		srv.Config.ErrorLog = log.New(ioutil.Discard, "", 0)
+		srv.TLS = c.profile.Config()
+		srv.TLS.Certificates = []tls.Certificate{c.cert}
+		srv.StartTLS()
+
+		auditor := &tlsExtra.Auditor{Roots: pki.Pool()}
+		report, err := auditor.Audit(srv.Listener.Addr().String())
+		srv.Close()
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("profile %s: negotiated %s %s\n", c.profile.Name, report.Version, report.CipherSuite)
+		fmt.Println("  versions:", strings.Join(report.Versions, ", "))
+		fmt.Println("  cipher suites:", len(report.CipherSuites), "weak:", len(report.WeakCipherSuites))
+		for _, cert := range report.Chain {
+			fmt.Println("  cert:", cert.Subject, cert.PublicKey, cert.Signature)
+		}
+		for _, p := range report.Problems {
+			fmt.Println("  problem:", p)
+		}
+	}
+}
+
+func pinnedClient() {
+
+	pki, err := tlsExtra.NewTestPKI()
+	if err != nil {
+		log.Fatal(err)
+	}
+	cert, err := pki.ServerCert("127.0.0.1")
+	if err != nil {
+		log.Fatal(err)
+	}
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("pinned"))
+	}))
+	srv.Config.ErrorLog = log.New(ioutil.Discard, "", 0)
+	srv.TLS = &tls.Config{Certificates: []tls.Certificate{cert}}
+	srv.StartTLS()
+	defer srv.Close()
+	addr := srv.Listener.Addr().String()
+	roots := pki.Pool()
+
+	// 当前证书的pin与一个离线保存的备用密钥的pin
+	backupKey, _ := x509Extra.GenerateKey("p256")
+	backup, _ := tlsExtra.PublicKeyPin(backupKey.Public())
+	pins, err := tlsExtra.NewPinSet(tlsExtra.SPKIHash(cert.Leaf), backup)
+	if err != nil {
+		log.Fatal(err)
+	}
+	pins.Scope = tlsExtra.PinLeaf
+
+	// 与client(roots)相同的连接方式，增加pin检查
+	conn, err := tls.Dial("tcp", addr, pins.Apply(&tls.Config{RootCAs: roots}))
+	if err != nil {
+		log.Fatal(err)
+	}
+	conn.Close()
+	fmt.Println("pinned dial: ok")
+
+	// 自行校验证书链
+	opts := x509.VerifyOptions{Roots: roots, DNSName: "127.0.0.1"}
+	if _, err := pins.Verify(cert.Leaf, opts); err != nil {
+		log.Fatal(err)
+	}
+
+	// 用于http.Transport
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: pins.Apply(&tls.Config{RootCAs: roots})}}
+	if resp, err := client.Get(srv.URL); err == nil {
+		body, _ := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		fmt.Println("pinned transport:", resp.Status, string(body))
+	}
+
+	// 证书被替换为同一CA签发的其他证书时，证书链校验通过但pin不匹配
+	caPin := tlsExtra.SPKIHash(pki.CA.Cert)
+	wrong, _ := tlsExtra.NewPinSet(backup, caPin)
+	wrong.Scope = tlsExtra.PinLeaf
+	wrong.Report = func(v *tlsExtra.PinViolation) {
+		fmt.Println("violation:", v.Subject, v.Chain[0])
+	}
+	_, err = tls.Dial("tcp", addr, wrong.Apply(&tls.Config{RootCAs: roots}))
+	fmt.Println("wrong pin:", err)
+
+	// 只报告不断开，用于上线前观察
+	wrong.ReportOnly = true
+	conn, err = tls.Dial("tcp", addr, wrong.Apply(&tls.Config{RootCAs: roots}))
+	fmt.Println("report only:", err)
+	if err == nil {
+		conn.Close()
+	}
+}
@@ -0,0 +1,226 @@
+package extra
+
+import (
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+)
+
+// TLS服务端审计器，逐个版本和密码套件发起握手，检查服务端接受的配置与证书链
+//
+//	report, err := (&extra.Auditor{}).Audit("example.com:443")
+type Auditor struct {
+	// 校验证书链的根证书，为nil时使用系统根证书
+	Roots *x509.CertPool
+	// SNI与校验使用的主机名，为空时使用地址中的主机部分
+	ServerName string
+	// 每次握手的超时时间，为0时使用5秒
+	Timeout time.Duration
+	// 证书剩余有效期少于该值时报告，为0时使用30天
+	ExpiryWarning time.Duration
+}
+
+// 审计结果
+type AuditReport struct {
+	Address    string `json:"address"`
+	ServerName string `json:"server_name"`
+	// 默认配置握手协商的版本与套件
+	Version     string `json:"version"`
+	CipherSuite string `json:"cipher_suite"`
+	// 服务端接受的协议版本
+	Versions []string `json:"versions"`
+	// 服务端在TLS 1.2及以下接受的密码套件
+	CipherSuites []string `json:"cipher_suites"`
+	// 接受的弱套件及原因
+	WeakCipherSuites []string    `json:"weak_cipher_suites,omitempty"`
+	Chain            []ChainCert `json:"chain"`
+	// 发现的全部问题，为空时表示未发现问题
+	Problems []string `json:"problems,omitempty"`
+}
+
+// 服务端发送的证书
+type ChainCert struct {
+	Subject   string    `json:"subject"`
+	Issuer    string    `json:"issuer"`
+	NotAfter  time.Time `json:"not_after"`
+	PublicKey string    `json:"public_key"`
+	Signature string    `json:"signature"`
+}
+
+// 连接addr(host:port)进行审计，只有默认配置也无法握手时返回错误
+func (a *Auditor) Audit(addr string) (*AuditReport, error) {
+
+	serverName := a.ServerName
+	if serverName == "" {
+		host, _, err := net.SplitHostPort(addr)
+		if err != nil {
+			return nil, err
+		}
+		serverName = host
+	}
+	report := &AuditReport{Address: addr, ServerName: serverName}
+
+	// 跳过校验完成握手，之后单独校验证书链，这样即使证书有问题也能得到完整报告
+	state, err := a.handshake(addr, serverName, 0, nil)
+	if err != nil {
+		return nil, err
+	}
+	report.Version = VersionName(state.Version)
+	report.CipherSuite = tls.CipherSuiteName(state.CipherSuite)
+	a.checkChain(report, state.PeerCertificates, serverName)
+
+	for _, v := range []uint16{tls.VersionTLS10, tls.VersionTLS11, tls.VersionTLS12, tls.VersionTLS13} {
+		if _, err := a.handshake(addr, serverName, v, nil); err == nil {
+			report.Versions = append(report.Versions, VersionName(v))
+			if v < tls.VersionTLS12 {
+				report.Problems = append(report.Problems, VersionName(v)+" is enabled")
+			}
+		}
+	}
+	if len(report.Versions) > 0 && report.Versions[0] == VersionName(tls.VersionTLS13) {
+		return report, nil
+	}
+
+	// 逐个尝试TLS 1.2及以下的套件
+	for _, suite := range append(tls.CipherSuites(), tls.InsecureCipherSuites()...) {
+		if !supportsPreTLS13(suite) {
+			continue
+		}
+		if _, err := a.handshake(addr, serverName, 0, []uint16{suite.ID}); err != nil {
+			continue
+		}
+		report.CipherSuites = append(report.CipherSuites, suite.Name)
+		if reason := weakness(suite); reason != "" {
+			report.WeakCipherSuites = append(report.WeakCipherSuites, suite.Name+": "+reason)
+			report.Problems = append(report.Problems, "weak cipher suite "+suite.Name+" is accepted ("+reason+")")
+		}
+	}
+	return report, nil
+}
+
+// version为0时不限制版本，suites为nil时使用默认套件
+func (a *Auditor) handshake(addr, serverName string, version uint16, suites []uint16) (tls.ConnectionState, error) {
+
+	timeout := a.Timeout
+	if timeout == 0 {
+		timeout = 5 * time.Second
+	}
+	cfg := &tls.Config{
+		ServerName:         serverName,
+		InsecureSkipVerify: true,
+		MinVersion:         tls.VersionTLS10,
+		CipherSuites:       suites,
+	}
+	if version != 0 {
+		cfg.MinVersion, cfg.MaxVersion = version, version
+	}
+	// 指定套件时只测试TLS 1.2及以下
+	if suites != nil {
+		cfg.MaxVersion = tls.VersionTLS12
+	}
+	conn, err := tls.DialWithDialer(&net.Dialer{Timeout: timeout}, "tcp", addr, cfg)
+	if err != nil {
+		return tls.ConnectionState{}, err
+	}
+	defer conn.Close()
+	return conn.ConnectionState(), nil
+}
+
+func (a *Auditor) checkChain(report *AuditReport, certs []*x509.Certificate, serverName string) {
+
+	problem := func(format string, args ...interface{}) {
+		report.Problems = append(report.Problems, fmt.Sprintf(format, args...))
+	}
+	if len(certs) == 0 {
+		problem("server sent no certificate")
+		return
+	}
+	warning := a.ExpiryWarning
+	if warning == 0 {
+		warning = 30 * 24 * time.Hour
+	}
+	now := time.Now()
+	intermediates := x509.NewCertPool()
+	for i, cert := range certs {
+		report.Chain = append(report.Chain, ChainCert{
+			Subject:   cert.Subject.String(),
+			Issuer:    cert.Issuer.String(),
+			NotAfter:  cert.NotAfter,
+			PublicKey: publicKeyName(cert),
+			Signature: cert.SignatureAlgorithm.String(),
+		})
+		if i > 0 {
+			intermediates.AddCert(cert)
+		}
+		switch {
+		case now.After(cert.NotAfter):
+			problem("certificate %d (%s) expired at %s", i, cert.Subject, cert.NotAfter.Format(time.RFC3339))
+		case now.Add(warning).After(cert.NotAfter):
+			problem("certificate %d (%s) expires at %s", i, cert.Subject, cert.NotAfter.Format(time.RFC3339))
+		}
+		if key, ok := cert.PublicKey.(*rsa.PublicKey); ok && key.N.BitLen() < 2048 {
+			problem("certificate %d (%s) uses a %d bit RSA key", i, cert.Subject, key.N.BitLen())
+		}
+		// 根证书的自签名不参与校验，不检查其签名算法
+		selfSigned := cert.CheckSignatureFrom(cert) == nil
+		switch cert.SignatureAlgorithm {
+		case x509.MD5WithRSA, x509.SHA1WithRSA, x509.ECDSAWithSHA1, x509.DSAWithSHA1:
+			if !selfSigned {
+				problem("certificate %d (%s) is signed with %s", i, cert.Subject, cert.SignatureAlgorithm)
+			}
+		}
+		if i > 0 && selfSigned {
+			problem("server sends the root certificate %s, which is unnecessary", cert.Subject)
+		}
+		if i+1 < len(certs) && cert.CheckSignatureFrom(certs[i+1]) != nil {
+			problem("certificate %d is not signed by certificate %d, the chain is out of order", i, i+1)
+		}
+	}
+	_, err := certs[0].Verify(x509.VerifyOptions{
+		Roots:         a.Roots,
+		Intermediates: intermediates,
+		DNSName:       serverName,
+	})
+	if err != nil {
+		problem("chain verification failed: %v", err)
+	}
+}
+
+// TLS 1.3套件不能通过CipherSuites配置
+func supportsPreTLS13(suite *tls.CipherSuite) bool {
+	for _, v := range suite.SupportedVersions {
+		if v < tls.VersionTLS13 {
+			return true
+		}
+	}
+	return false
+}
+
+func weakness(suite *tls.CipherSuite) string {
+	var reasons []string
+	if suite.Insecure {
+		reasons = append(reasons, "known to be insecure")
+	}
+	if strings.HasPrefix(suite.Name, "TLS_RSA_") {
+		reasons = append(reasons, "no forward secrecy")
+	}
+	if strings.Contains(suite.Name, "_CBC_") {
+		reasons = append(reasons, "CBC mode")
+	}
+	return strings.Join(reasons, ", ")
+}
+
+func publicKeyName(cert *x509.Certificate) string {
+	switch key := cert.PublicKey.(type) {
+	case *rsa.PublicKey:
+		return fmt.Sprintf("RSA %d", key.N.BitLen())
+	case *ecdsa.PublicKey:
+		return "ECDSA " + key.Curve.Params().Name
+	}
+	return cert.PublicKeyAlgorithm.String()
+}
@@ -0,0 +1,245 @@
+package extra
+
+import (
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	x509Extra "github.com/zc2638/go-standard/src/crypto/x509/extra"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// 开发证书目录中的文件
+const (
+	DevCertFile = "cert.pem"
+	DevKeyFile  = "key.pem"
+	// 本地CA所在的子目录，可将其中的ca.pem加入系统信任以消除浏览器警告
+	DevCADir = "ca"
+)
+
+// 本地开发用的HTTPS证书，首次使用时生成本地CA与终端证书并缓存到Dir，之后复用直到临近过期
+//
+//	cfg, err := extra.DevTLSConfig()
+//	srv := &http.Server{Addr: ":8443", Handler: mux, TLSConfig: cfg}
+//	srv.ListenAndServeTLS("", "")
+type DevCert struct {
+	// 缓存目录，为空时使用用户缓存目录下的go-standard-devcert
+	Dir string
+	// 除localhost、127.0.0.1与::1外额外包含的主机名或IP
+	Hosts []string
+	// 终端证书有效期，为0时使用90天
+	Validity time.Duration
+	// 剩余有效期少于该值时重新签发，为0时使用30天
+	RenewBefore time.Duration
+}
+
+// 使用默认缓存目录的开发证书配置
+func DevTLSConfig(hosts ...string) (*tls.Config, error) {
+	return (&DevCert{Hosts: hosts}).TLSConfig()
+}
+
+func (d *DevCert) TLSConfig() (*tls.Config, error) {
+	cert, err := d.Certificate()
+	if err != nil {
+		return nil, err
+	}
+	return &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12}, nil
+}
+
+// 返回证书与私钥文件路径，可直接用于http.ListenAndServeTLS
+func (d *DevCert) Files() (certFile, keyFile string, err error) {
+	if _, err := d.Certificate(); err != nil {
+		return "", "", err
+	}
+	dir, err := d.dir()
+	if err != nil {
+		return "", "", err
+	}
+	return filepath.Join(dir, DevCertFile), filepath.Join(dir, DevKeyFile), nil
+}
+
+// 本地CA证书文件，客户端校验开发证书时使用
+func (d *DevCert) CAFile() (string, error) {
+	dir, err := d.dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, DevCADir, x509Extra.CertFile), nil
+}
+
+// 加载缓存的证书，不存在、即将过期、缺少主机名或不是由当前CA签发时重新签发
+func (d *DevCert) Certificate() (tls.Certificate, error) {
+
+	dir, err := d.dir()
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	ca, err := d.loadCA(filepath.Join(dir, DevCADir))
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	certFile, keyFile := filepath.Join(dir, DevCertFile), filepath.Join(dir, DevKeyFile)
+	if cert, err := tls.LoadX509KeyPair(certFile, keyFile); err == nil && d.reusable(cert, ca) {
+		return cert, nil
+	}
+
+	key, err := x509Extra.GenerateKey("p256")
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	template := &x509.CertificateRequest{Subject: pkix.Name{CommonName: "localhost"}}
+	for _, h := range d.hosts() {
+		if ip := net.ParseIP(h); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else {
+			template.DNSNames = append(template.DNSNames, h)
+		}
+	}
+	der, err := x509.CreateCertificateRequest(rand.Reader, template, key)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	csr, err := x509.ParseCertificateRequest(der)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	leaf, err := ca.SignCSR(csr, x509Extra.LeafOptions{Validity: d.validity(), ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}})
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	keyPEM, err := x509Extra.EncodeKeyPEM(key)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	if err := ioutil.WriteFile(keyFile, keyPEM, 0600); err != nil {
+		return tls.Certificate{}, err
+	}
+	if err := ioutil.WriteFile(certFile, ca.Bundle(leaf), 0644); err != nil {
+		return tls.Certificate{}, err
+	}
+	return tls.Certificate{Certificate: [][]byte{leaf.Raw}, PrivateKey: key, Leaf: leaf}, nil
+}
+
+// 加载本地CA，不存在、有效期不足以签发新证书或名称约束不包含全部主机名时重新生成
+// CA只能为localhost、回环地址及Hosts签发证书；重新生成CA后需要重新信任新的ca.pem
+func (d *DevCert) loadCA(dir string) (*x509Extra.CA, error) {
+
+	if ca, err := x509Extra.Load(dir); err == nil && time.Now().Add(d.validity()).Before(ca.Cert.NotAfter) && d.permitted(ca.Cert) {
+		return ca, nil
+	}
+	key, err := x509Extra.GenerateKey("p256")
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	db, err := x509Extra.OpenDB(filepath.Join(dir, x509Extra.DBFile))
+	if err != nil {
+		return nil, err
+	}
+	hostname, _ := os.Hostname()
+	subject := pkix.Name{CommonName: "Development CA " + hostname, Organization: []string{"Development"}}
+	ca, err := x509Extra.NewConstrainedRootCA(subject, key, 10*365*24*time.Hour, 0, db, d.constraints())
+	if err != nil {
+		return nil, err
+	}
+	return ca, ca.Save(dir)
+}
+
+// 本地CA的名称约束: localhost、127.0.0.0/8、::1及Hosts
+func (d *DevCert) constraints() x509Extra.NameConstraints {
+
+	_, v4, _ := net.ParseCIDR("127.0.0.0/8")
+	_, v6, _ := net.ParseCIDR("::1/128")
+	c := x509Extra.NameConstraints{DNSDomains: []string{"localhost"}, IPRanges: []*net.IPNet{v4, v6}}
+	for _, h := range d.Hosts {
+		if ip := net.ParseIP(h); ip == nil {
+			c.DNSDomains = append(c.DNSDomains, h)
+		} else if v4 := ip.To4(); v4 != nil {
+			c.IPRanges = append(c.IPRanges, &net.IPNet{IP: v4, Mask: net.CIDRMask(32, 32)})
+		} else {
+			c.IPRanges = append(c.IPRanges, &net.IPNet{IP: ip, Mask: net.CIDRMask(128, 128)})
+		}
+	}
+	return c
+}
+
+// CA带有名称约束且约束包含全部主机名，旧版本生成的无约束CA会被替换
+func (d *DevCert) permitted(ca *x509.Certificate) bool {
+
+	if !ca.PermittedDNSDomainsCritical || len(ca.PermittedDNSDomains) == 0 || len(ca.PermittedIPRanges) == 0 {
+		return false
+	}
+	for _, h := range d.hosts() {
+		ok := false
+		if ip := net.ParseIP(h); ip != nil {
+			for _, n := range ca.PermittedIPRanges {
+				ok = ok || n.Contains(ip)
+			}
+		} else {
+			for _, domain := range ca.PermittedDNSDomains {
+				ok = ok || strings.EqualFold(h, domain) || strings.HasSuffix(strings.ToLower(h), "."+strings.ToLower(domain))
+			}
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func (d *DevCert) reusable(cert tls.Certificate, ca *x509Extra.CA) bool {
+
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		return false
+	}
+	if time.Now().Add(d.renewBefore()).After(leaf.NotAfter) {
+		return false
+	}
+	roots, _ := ca.Pools()
+	if _, err := leaf.Verify(x509.VerifyOptions{Roots: roots}); err != nil {
+		return false
+	}
+	for _, h := range d.hosts() {
+		if leaf.VerifyHostname(h) != nil {
+			return false
+		}
+	}
+	return true
+}
+
+func (d *DevCert) hosts() []string {
+	return append([]string{"localhost", "127.0.0.1", "::1"}, d.Hosts...)
+}
+
+func (d *DevCert) dir() (string, error) {
+	if d.Dir != "" {
+		return d.Dir, nil
+	}
+	cache, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(cache, "go-standard-devcert"), nil
+}
+
+func (d *DevCert) validity() time.Duration {
+	if d.Validity == 0 {
+		return 90 * 24 * time.Hour
+	}
+	return d.Validity
+}
+
+func (d *DevCert) renewBefore() time.Duration {
+	if d.RenewBefore == 0 {
+		return 30 * 24 * time.Hour
+	}
+	return d.RenewBefore
+}
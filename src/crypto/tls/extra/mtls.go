@@ -0,0 +1,226 @@
+package extra
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	x509Extra "github.com/zc2638/go-standard/src/crypto/x509/extra"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// 双向TLS服务端配置，要求客户端提供由clientCAs签发的证书
+func ServerTLSConfig(cert tls.Certificate, clientCAs *x509.CertPool) *tls.Config {
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    clientCAs,
+		MinVersion:   tls.VersionTLS12,
+	}
+}
+
+// 双向TLS客户端配置，使用cert证明身份并用roots校验服务端证书
+func ClientTLSConfig(cert tls.Certificate, roots *x509.CertPool, serverName string) *tls.Config {
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		RootCAs:      roots,
+		ServerName:   serverName,
+		MinVersion:   tls.VersionTLS12,
+	}
+}
+
+// 测试用的临时PKI，一个根CA同时签发服务端与客户端证书，证书只在内存中
+//
+//	pki, _ := extra.NewTestPKI()
+//	serverCert, _ := pki.ServerCert("localhost", "127.0.0.1")
+//	clientCert, _ := pki.ClientCert("spiffe://example.org/ns/prod/sa/web")
+//	srv.TLS = pki.ServerConfig(serverCert)
+//	client.Transport = &http.Transport{TLSClientConfig: pki.ClientConfig(clientCert, "localhost")}
+type TestPKI struct {
+	CA *x509Extra.CA
+	// 签发证书的有效期，为0时使用24小时
+	Validity time.Duration
+}
+
+func NewTestPKI() (*TestPKI, error) {
+
+	key, err := x509Extra.GenerateKey("p256")
+	if err != nil {
+		return nil, err
+	}
+	ca, err := x509Extra.NewRootCA(pkix.Name{CommonName: "Test mTLS CA"}, key, 7*24*time.Hour, 0, nil)
+	if err != nil {
+		return nil, err
+	}
+	// 客户端身份使用URI SAN(SPIFFE ID)，服务端证书可包含回环地址
+	ca.Policy.AllowURIs = true
+	ca.Policy.AllowedIPNets = []string{"127.0.0.0/8", "::1/128"}
+	return &TestPKI{CA: ca}, nil
+}
+
+// 包含根证书的证书池
+func (p *TestPKI) Pool() *x509.CertPool {
+	roots, _ := p.CA.Pools()
+	return roots
+}
+
+// 签发服务端证书，hosts可以是域名或IP地址
+func (p *TestPKI) ServerCert(hosts ...string) (tls.Certificate, error) {
+
+	template := &x509.CertificateRequest{}
+	if len(hosts) > 0 {
+		template.Subject.CommonName = hosts[0]
+	}
+	for _, h := range hosts {
+		if ip := net.ParseIP(h); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else {
+			template.DNSNames = append(template.DNSNames, h)
+		}
+	}
+	return p.issue(template, x509.ExtKeyUsageServerAuth)
+}
+
+// 签发客户端证书，uris为证书中的URI SAN，例如SPIFFE ID
+// 身份只由SAN表示，证书不包含CommonName
+func (p *TestPKI) ClientCert(uris ...string) (tls.Certificate, error) {
+
+	template := &x509.CertificateRequest{}
+	for _, raw := range uris {
+		u, err := url.Parse(raw)
+		if err != nil {
+			return tls.Certificate{}, err
+		}
+		template.URIs = append(template.URIs, u)
+	}
+	return p.issue(template, x509.ExtKeyUsageClientAuth)
+}
+
+func (p *TestPKI) ServerConfig(cert tls.Certificate) *tls.Config {
+	return ServerTLSConfig(cert, p.Pool())
+}
+
+func (p *TestPKI) ClientConfig(cert tls.Certificate, serverName string) *tls.Config {
+	return ClientTLSConfig(cert, p.Pool(), serverName)
+}
+
+func (p *TestPKI) issue(template *x509.CertificateRequest, usage x509.ExtKeyUsage) (tls.Certificate, error) {
+
+	key, err := x509Extra.GenerateKey("p256")
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	der, err := x509.CreateCertificateRequest(rand.Reader, template, key)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	csr, err := x509.ParseCertificateRequest(der)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	validity := p.Validity
+	if validity == 0 {
+		validity = 24 * time.Hour
+	}
+	cert, err := p.CA.SignCSR(csr, x509Extra.LeafOptions{Validity: validity, ExtKeyUsage: []x509.ExtKeyUsage{usage}})
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	return tls.Certificate{Certificate: [][]byte{cert.Raw}, PrivateKey: key, Leaf: cert}, nil
+}
+
+// 从已校验的客户端证书中提取的身份
+type Identity struct {
+	// 仅用于显示和日志；CA通常不校验CommonName，不能用于授权
+	CommonName string   `json:"common_name"`
+	DNSNames   []string `json:"dns_names,omitempty"`
+	URIs       []string `json:"uris,omitempty"`
+	// 证书中唯一的spiffe://URI，没有或有多个时为空
+	SPIFFEID    string            `json:"spiffe_id,omitempty"`
+	Certificate *x509.Certificate `json:"-"`
+}
+
+func IdentityFromCertificate(cert *x509.Certificate) *Identity {
+
+	id := &Identity{CommonName: cert.Subject.CommonName, DNSNames: cert.DNSNames, Certificate: cert}
+	var spiffe []string
+	for _, u := range cert.URIs {
+		id.URIs = append(id.URIs, u.String())
+		if strings.EqualFold(u.Scheme, "spiffe") {
+			spiffe = append(spiffe, u.String())
+		}
+	}
+	// SPIFFE规范要求X.509-SVID只包含一个SPIFFE ID
+	if len(spiffe) == 1 {
+		id.SPIFFEID = spiffe[0]
+	}
+	return id
+}
+
+// 只使用校验通过的证书链，未校验的对端证书不可信
+func IdentityFromRequest(r *http.Request) (*Identity, bool) {
+	if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+		return nil, false
+	}
+	return IdentityFromCertificate(r.TLS.VerifiedChains[0][0]), true
+}
+
+type identityKey struct{}
+
+func IdentityFromContext(ctx context.Context) (*Identity, bool) {
+	id, ok := ctx.Value(identityKey{}).(*Identity)
+	return id, ok
+}
+
+// 允许访问的客户端身份，满足任意一项即可
+// 只匹配SAN，不匹配CommonName
+// URI以/*结尾时按前缀匹配，例如spiffe://example.org/ns/prod/*
+type AllowList struct {
+	DNSNames []string `json:"dns_names,omitempty"`
+	URIs     []string `json:"uris,omitempty"`
+}
+
+func (a *AllowList) Allowed(id *Identity) bool {
+
+	// 未配置时允许所有通过校验的客户端
+	if a == nil {
+		return true
+	}
+	for _, allowed := range a.DNSNames {
+		for _, name := range id.DNSNames {
+			if strings.EqualFold(allowed, name) {
+				return true
+			}
+		}
+	}
+	for _, allowed := range a.URIs {
+		for _, uri := range id.URIs {
+			if allowed == uri || strings.HasSuffix(allowed, "/*") && strings.HasPrefix(uri, allowed[:len(allowed)-1]) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// 提取客户端身份放入请求上下文并按allow校验
+// 没有校验通过的客户端证书时返回401，身份不在允许列表中时返回403
+func RequireClientCert(allow *AllowList, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, ok := IdentityFromRequest(r)
+		if !ok {
+			http.Error(w, "client certificate required", http.StatusUnauthorized)
+			return
+		}
+		if !allow.Allowed(id) {
+			http.Error(w, "client identity is not allowed", http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), identityKey{}, id)))
+	})
+}
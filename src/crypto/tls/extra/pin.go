@@ -0,0 +1,174 @@
+package extra
+
+import (
+	"crypto"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+)
+
+var (
+	ErrPinFormat    = errors.New("tls: pin must be a base64 encoded SHA-256 hash")
+	ErrNoBackupPin  = errors.New("tls: at least two pins are required, one of them a backup pin")
+	ErrPinsNotFound = errors.New("tls: no verified chains to check pins against")
+)
+
+// 证书的SPKI SHA-256指纹，格式与HPKP的pin-sha256相同
+func SPKIHash(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// 公钥的SPKI SHA-256指纹，用于由备用密钥或证书请求计算备用pin
+func PublicKeyPin(pub crypto.PublicKey) (string, error) {
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(der)
+	return base64.StdEncoding.EncodeToString(sum[:]), nil
+}
+
+// pin匹配的证书位置
+type PinScope int
+
+const (
+	// 链上任意证书
+	PinAnyCert PinScope = iota
+	// 只匹配终端证书
+	PinLeaf
+	// 只匹配中间证书与根证书
+	PinCA
+)
+
+// 违反pin的连接
+type PinViolation struct {
+	// 终端证书的主题
+	Subject string
+	// 校验通过的证书链中各证书的指纹
+	Chain []string
+	Pins  []string
+}
+
+func (v *PinViolation) Error() string {
+	return fmt.Sprintf("tls: certificate pin mismatch for %q: chain %s does not match pins %s",
+		v.Subject, strings.Join(v.Chain, ","), strings.Join(v.Pins, ","))
+}
+
+// SPKI pin集合，任意一个pin匹配即通过，应至少包含一个不在当前证书链中的备用pin
+// 在正常的证书链校验之后执行，不能替代证书链校验
+//
+//	pins, _ := extra.NewPinSet(current, backup)
+//	cfg := pins.Apply(&tls.Config{RootCAs: roots})
+//	client := &http.Client{Transport: &http.Transport{TLSClientConfig: cfg}}
+type PinSet struct {
+	Pins  []string
+	Scope PinScope
+	// 为true时只报告违反pin的连接而不断开，用于上线前观察
+	ReportOnly bool
+	// 报告违反pin的连接，为nil时使用log.Printf
+	Report func(v *PinViolation)
+}
+
+// pin可以带有sha256/前缀
+func NewPinSet(pins ...string) (*PinSet, error) {
+
+	set := &PinSet{}
+	for _, pin := range pins {
+		pin = strings.TrimPrefix(pin, "sha256/")
+		sum, err := base64.StdEncoding.DecodeString(pin)
+		if err != nil || len(sum) != sha256.Size {
+			return nil, fmt.Errorf("%v: %q", ErrPinFormat, pin)
+		}
+		set.Pins = append(set.Pins, pin)
+	}
+	// 只有一个pin时证书换钥会导致客户端无法连接
+	if len(set.Pins) < 2 {
+		return nil, ErrNoBackupPin
+	}
+	return set, nil
+}
+
+// 任意一条已校验的证书链在Scope范围内包含pin即通过
+func (p *PinSet) Check(chains [][]*x509.Certificate) error {
+
+	if len(chains) == 0 || len(chains[0]) == 0 {
+		return ErrPinsNotFound
+	}
+	for _, chain := range chains {
+		for i, cert := range chain {
+			if p.Scope == PinLeaf && i > 0 || p.Scope == PinCA && i == 0 {
+				continue
+			}
+			if p.contains(SPKIHash(cert)) {
+				return nil
+			}
+		}
+	}
+
+	v := &PinViolation{Subject: chains[0][0].Subject.String(), Pins: p.Pins}
+	for _, cert := range chains[0] {
+		v.Chain = append(v.Chain, SPKIHash(cert))
+	}
+	if p.Report != nil {
+		p.Report(v)
+	} else {
+		log.Printf("%v (report only: %t)", v, p.ReportOnly)
+	}
+	if p.ReportOnly {
+		return nil
+	}
+	return v
+}
+
+// 校验证书链后检查pin，用于自行调用x509.Certificate.Verify的场景
+func (p *PinSet) Verify(cert *x509.Certificate, opts x509.VerifyOptions) ([][]*x509.Certificate, error) {
+	chains, err := cert.Verify(opts)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.Check(chains); err != nil {
+		return nil, err
+	}
+	return chains, nil
+}
+
+// 作为tls.Config.VerifyPeerCertificate使用，InsecureSkipVerify必须为false以获得已校验的证书链
+// 恢复会话时不会调用VerifyPeerCertificate，需要同时覆盖恢复的连接时使用VerifyConnection或Apply
+func (p *PinSet) VerifyPeerCertificate(rawCerts [][]byte, verifiedChains [][]*x509.Certificate) error {
+	return p.Check(verifiedChains)
+}
+
+// 作为tls.Config.VerifyConnection使用，完整握手与恢复会话时都会调用
+func (p *PinSet) VerifyConnection(cs tls.ConnectionState) error {
+	return p.Check(cs.VerifiedChains)
+}
+
+// 在cfg中启用pin检查并返回cfg，已有的VerifyConnection会先执行
+// 使用VerifyConnection而不是VerifyPeerCertificate，恢复的会话同样会检查pin
+func (p *PinSet) Apply(cfg *tls.Config) *tls.Config {
+	prev := cfg.VerifyConnection
+	cfg.VerifyConnection = func(cs tls.ConnectionState) error {
+		if prev != nil {
+			if err := prev(cs); err != nil {
+				return err
+			}
+		}
+		return p.VerifyConnection(cs)
+	}
+	return cfg
+}
+
+func (p *PinSet) contains(pin string) bool {
+	for _, pp := range p.Pins {
+		if pp == pin {
+			return true
+		}
+	}
+	return false
+}
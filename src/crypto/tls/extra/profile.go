@@ -0,0 +1,112 @@
+package extra
+
+import (
+	"crypto/tls"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// TLS配置档案，参考Mozilla服务端TLS配置指南
+// TLS 1.3的密码套件由Go自动选择，CipherSuites只作用于TLS 1.2及以下版本
+type Profile struct {
+	Name             string
+	MinVersion       uint16
+	MaxVersion       uint16
+	CipherSuites     []uint16
+	CurvePreferences []tls.CurveID
+}
+
+var (
+	// 只允许TLS 1.3，适用于只需支持新客户端的服务
+	Modern = Profile{
+		Name:             "modern",
+		MinVersion:       tls.VersionTLS13,
+		MaxVersion:       tls.VersionTLS13,
+		CurvePreferences: []tls.CurveID{tls.X25519, tls.CurveP256, tls.CurveP384},
+	}
+	// 推荐的通用配置: TLS 1.2与1.3，只使用前向安全的AEAD套件
+	Intermediate = Profile{
+		Name:       "intermediate",
+		MinVersion: tls.VersionTLS12,
+		MaxVersion: tls.VersionTLS13,
+		CipherSuites: []uint16{
+			tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+			tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+			tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+			tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+			tls.TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305,
+			tls.TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305,
+		},
+		CurvePreferences: []tls.CurveID{tls.X25519, tls.CurveP256, tls.CurveP384},
+	}
+	// 兼容旧客户端: 允许TLS 1.0与CBC、RSA密钥交换套件，只应在必须支持旧系统时使用
+	Compatible = Profile{
+		Name:       "compatible",
+		MinVersion: tls.VersionTLS10,
+		MaxVersion: tls.VersionTLS13,
+		CipherSuites: append(append([]uint16(nil), Intermediate.CipherSuites...),
+			tls.TLS_ECDHE_ECDSA_WITH_AES_128_CBC_SHA256,
+			tls.TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA256,
+			tls.TLS_ECDHE_ECDSA_WITH_AES_128_CBC_SHA,
+			tls.TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA,
+			tls.TLS_ECDHE_ECDSA_WITH_AES_256_CBC_SHA,
+			tls.TLS_ECDHE_RSA_WITH_AES_256_CBC_SHA,
+			tls.TLS_RSA_WITH_AES_128_GCM_SHA256,
+			tls.TLS_RSA_WITH_AES_256_GCM_SHA384,
+			tls.TLS_RSA_WITH_AES_128_CBC_SHA256,
+			tls.TLS_RSA_WITH_AES_128_CBC_SHA,
+			tls.TLS_RSA_WITH_AES_256_CBC_SHA,
+			tls.TLS_RSA_WITH_3DES_EDE_CBC_SHA,
+		),
+		CurvePreferences: []tls.CurveID{tls.X25519, tls.CurveP256, tls.CurveP384},
+	}
+)
+
+var profiles = map[string]Profile{
+	Modern.Name:       Modern,
+	Intermediate.Name: Intermediate,
+	Compatible.Name:   Compatible,
+}
+
+// 按名称查找配置档案，不区分大小写
+func LookupProfile(name string) (Profile, error) {
+	if p, ok := profiles[strings.ToLower(name)]; ok {
+		return p, nil
+	}
+	names := make([]string, 0, len(profiles))
+	for n := range profiles {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return Profile{}, fmt.Errorf("tls: unknown profile %q, expected one of %s", name, strings.Join(names, ", "))
+}
+
+// 返回使用该档案的新配置
+func (p Profile) Config() *tls.Config {
+	cfg := &tls.Config{}
+	p.Apply(cfg)
+	return cfg
+}
+
+// 将档案中的版本、套件与曲线写入已有配置，证书等其他字段保持不变
+func (p Profile) Apply(cfg *tls.Config) {
+	cfg.MinVersion = p.MinVersion
+	cfg.MaxVersion = p.MaxVersion
+	cfg.CipherSuites = append([]uint16(nil), p.CipherSuites...)
+	cfg.CurvePreferences = append([]tls.CurveID(nil), p.CurvePreferences...)
+}
+
+var versionNames = map[uint16]string{
+	tls.VersionTLS10: "TLS 1.0",
+	tls.VersionTLS11: "TLS 1.1",
+	tls.VersionTLS12: "TLS 1.2",
+	tls.VersionTLS13: "TLS 1.3",
+}
+
+func VersionName(v uint16) string {
+	if name, ok := versionNames[v]; ok {
+		return name
+	}
+	return fmt.Sprintf("0x%04x", v)
+}
@@ -0,0 +1,324 @@
+package extra
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var ErrNoCertificate = errors.New("tls: no certificate available")
+
+// 证书管理器，作为tls.Config.GetCertificate使用
+// 定时检查证书文件，文件变化后重新加载并校验，校验通过才替换正在使用的证书，证书轮换时无需重启服务
+//
+//	m, err := extra.NewCertManager("cert.pem", "key.pem")
+//	go m.Watch(ctx)
+//	srv := &http.Server{TLSConfig: m.TLSConfig()}
+type CertManager struct {
+	// 检查文件变化的间隔，为0时使用10秒
+	Interval time.Duration
+	// 每次加载证书后调用，err不为nil时表示新文件无效，继续使用原有证书
+	OnReload func(certFile string, err error)
+	// 返回当前时间，为nil时使用time.Now
+	Now func() time.Time
+
+	// 串行化Reload
+	mu      sync.Mutex
+	files   [][2]string
+	dirs    []string
+	entries map[string]*certEntry
+	// 当前使用的*certSnapshot，GetCertificate无锁读取
+	current atomic.Value
+}
+
+type certEntry struct {
+	certFile, keyFile string
+	cert              *tls.Certificate
+	loadedAt          time.Time
+	// 已加载的文件版本与加载失败的文件版本，避免重复加载同一版本
+	stamp, failed string
+	err           error
+}
+
+type certSnapshot struct {
+	byName map[string]*tls.Certificate
+	def    *tls.Certificate
+	status []CertStatus
+}
+
+// 证书状态，用于监控证书到期与加载失败
+type CertStatus struct {
+	CertFile string    `json:"cert_file"`
+	KeyFile  string    `json:"key_file"`
+	DNSNames []string  `json:"dns_names,omitempty"`
+	NotAfter time.Time `json:"not_after"`
+	LoadedAt time.Time `json:"loaded_at"`
+	// 最近一次加载失败的原因，成功后清空
+	Error string `json:"error,omitempty"`
+}
+
+// 管理一对证书与私钥文件，首次加载失败时返回错误
+func NewCertManager(certFile, keyFile string) (*CertManager, error) {
+	m := &CertManager{files: [][2]string{{certFile, keyFile}}}
+	if err := m.Reload(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// 管理目录中的全部证书，按客户端请求的SNI选择证书
+// 证书文件为<name>.crt与<name>.key，或<name>.pem与<name>-key.pem
+// 名为default的证书在没有匹配的SNI时使用，不存在时使用文件名排序后的第一个证书
+func NewDirCertManager(dir string) (*CertManager, error) {
+	m := &CertManager{dirs: []string{dir}}
+	if err := m.Reload(); err != nil {
+		return nil, err
+	}
+	if m.snapshot().def == nil {
+		return nil, fmt.Errorf("%v in %s", ErrNoCertificate, dir)
+	}
+	return m, nil
+}
+
+func (m *CertManager) TLSConfig() *tls.Config {
+	return &tls.Config{GetCertificate: m.GetCertificate}
+}
+
+// 依次匹配完整主机名、通配符证书与默认证书
+func (m *CertManager) GetCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+
+	s := m.snapshot()
+	name := strings.ToLower(strings.TrimSuffix(hello.ServerName, "."))
+	if cert, ok := s.byName[name]; ok {
+		return cert, nil
+	}
+	// 通配符只匹配一级子域名
+	if i := strings.IndexByte(name, '.'); i > 0 {
+		if cert, ok := s.byName["*"+name[i:]]; ok {
+			return cert, nil
+		}
+	}
+	if s.def == nil {
+		return nil, ErrNoCertificate
+	}
+	return s.def, nil
+}
+
+// 检查文件变化并重新加载，返回第一个加载失败的错误
+// 加载失败的证书继续使用旧版本，直到文件再次变化
+func (m *CertManager) Reload() error {
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	pairs, firstErr := m.pairs()
+	now := m.now()
+	entries := make(map[string]*certEntry, len(pairs))
+	for _, p := range pairs {
+		e := m.entries[p[0]]
+		if e == nil {
+			e = &certEntry{certFile: p[0], keyFile: p[1]}
+		}
+		entries[p[0]] = e
+
+		stamp, err := fileStamp(p[0], p[1])
+		if err == nil && (stamp == e.stamp || stamp == e.failed) {
+			continue
+		}
+		if err == nil {
+			var cert *tls.Certificate
+			if cert, err = loadPair(p[0], p[1], now); err == nil {
+				e.cert, e.loadedAt, e.stamp, e.failed = cert, now, stamp, ""
+			} else {
+				e.failed = stamp
+			}
+		}
+		e.err = err
+		if err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("%s: %v", p[0], err)
+		}
+		if m.OnReload != nil {
+			m.OnReload(p[0], err)
+		}
+	}
+	m.entries = entries
+	m.publish(pairs)
+	return firstErr
+}
+
+// 按Interval定时调用Reload，直到ctx结束，错误通过OnReload报告
+func (m *CertManager) Watch(ctx context.Context) {
+
+	interval := m.Interval
+	if interval == 0 {
+		interval = 10 * time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m.Reload()
+		}
+	}
+}
+
+// 当前全部证书的状态
+func (m *CertManager) Status() []CertStatus {
+	return m.snapshot().status
+}
+
+// 在d时间内到期或加载失败的证书，用于告警
+func (m *CertManager) ExpiringWithin(d time.Duration) []CertStatus {
+	deadline := m.now().Add(d)
+	var list []CertStatus
+	for _, s := range m.Status() {
+		if s.Error != "" || s.NotAfter.Before(deadline) {
+			list = append(list, s)
+		}
+	}
+	return list
+}
+
+func (m *CertManager) snapshot() *certSnapshot {
+	if s, ok := m.current.Load().(*certSnapshot); ok {
+		return s
+	}
+	return &certSnapshot{}
+}
+
+func (m *CertManager) now() time.Time {
+	if m.Now != nil {
+		return m.Now()
+	}
+	return time.Now()
+}
+
+// 生成新的快照并原子替换
+func (m *CertManager) publish(pairs [][2]string) {
+
+	s := &certSnapshot{byName: make(map[string]*tls.Certificate)}
+	for _, p := range pairs {
+		e := m.entries[p[0]]
+		status := CertStatus{CertFile: e.certFile, KeyFile: e.keyFile, LoadedAt: e.loadedAt}
+		if e.err != nil {
+			status.Error = e.err.Error()
+		}
+		if e.cert != nil {
+			leaf := e.cert.Leaf
+			status.DNSNames = leaf.DNSNames
+			status.NotAfter = leaf.NotAfter
+			names := leaf.DNSNames
+			if len(names) == 0 && leaf.Subject.CommonName != "" {
+				names = []string{leaf.Subject.CommonName}
+			}
+			// 多个证书包含同一主机名时使用先出现的
+			for _, name := range names {
+				name = strings.ToLower(name)
+				if _, ok := s.byName[name]; !ok {
+					s.byName[name] = e.cert
+				}
+			}
+			if s.def == nil || pairName(e.certFile) == "default" {
+				s.def = e.cert
+			}
+		}
+		s.status = append(s.status, status)
+	}
+	m.current.Store(s)
+}
+
+// 列出需要加载的证书与私钥文件
+func (m *CertManager) pairs() ([][2]string, error) {
+
+	pairs := append([][2]string(nil), m.files...)
+	var firstErr error
+	for _, dir := range m.dirs {
+		infos, err := ioutil.ReadDir(dir)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			if m.OnReload != nil {
+				m.OnReload(dir, err)
+			}
+			// 目录暂时无法读取时保留之前找到的证书
+			for _, e := range m.entries {
+				if filepath.Dir(e.certFile) == dir {
+					pairs = append(pairs, [2]string{e.certFile, e.keyFile})
+				}
+			}
+			continue
+		}
+		var found [][2]string
+		for _, info := range infos {
+			name := info.Name()
+			var key string
+			switch {
+			case strings.HasSuffix(name, ".crt"):
+				key = strings.TrimSuffix(name, ".crt") + ".key"
+			case strings.HasSuffix(name, ".pem") && !strings.HasSuffix(name, "-key.pem"):
+				key = strings.TrimSuffix(name, ".pem") + "-key.pem"
+			default:
+				continue
+			}
+			if _, err := os.Stat(filepath.Join(dir, key)); err == nil {
+				found = append(found, [2]string{filepath.Join(dir, name), filepath.Join(dir, key)})
+			}
+		}
+		sort.Slice(found, func(i, j int) bool { return found[i][0] < found[j][0] })
+		pairs = append(pairs, found...)
+	}
+	return pairs, firstErr
+}
+
+func pairName(certFile string) string {
+	name := filepath.Base(certFile)
+	return strings.TrimSuffix(name, filepath.Ext(name))
+}
+
+// 文件的修改时间与大小，任一文件变化都会重新加载
+func fileStamp(certFile, keyFile string) (string, error) {
+	var stamp string
+	for _, file := range []string{certFile, keyFile} {
+		info, err := os.Stat(file)
+		if err != nil {
+			return "", err
+		}
+		stamp += fmt.Sprintf("%d:%d;", info.ModTime().UnixNano(), info.Size())
+	}
+	return stamp, nil
+}
+
+// 加载并校验证书，私钥必须与证书匹配且证书在有效期内
+func loadPair(certFile, keyFile string, now time.Time) (*tls.Certificate, error) {
+
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, err
+	}
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		return nil, err
+	}
+	if now.Before(leaf.NotBefore) {
+		return nil, fmt.Errorf("tls: certificate is not valid until %s", leaf.NotBefore.Format(time.RFC3339))
+	}
+	if now.After(leaf.NotAfter) {
+		return nil, fmt.Errorf("tls: certificate expired at %s", leaf.NotAfter.Format(time.RFC3339))
+	}
+	cert.Leaf = leaf
+	return &cert, nil
+}
@@ -0,0 +1,561 @@
+package main
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"flag"
+	"fmt"
+	x509Extra "github.com/zc2638/go-standard/src/crypto/x509/extra"
+	"io/ioutil"
+	"log"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// 用于内部服务身份的小型CA
+//
+//	go run ./src/crypto/x509/ca init -dir pki/root -cn "Example Root CA" -pathlen 1
+//	go run ./src/crypto/x509/ca intermediate -ca pki/root -dir pki/issuing -cn "Example Issuing CA" -pathlen 0 \
+//		-allow-ip 127.0.0.0/8 -ocsp http://localhost:8889/ocsp -crl http://localhost:8889/crl
+//	go run ./src/crypto/x509/ca request -cn api.internal -dns api.internal,api -ip 127.0.0.1 -key api-key.pem -out api.csr
+//	go run ./src/crypto/x509/ca sign -ca pki/issuing -csr api.csr -days 90 -eku server,client -out api.pem
+//	go run ./src/crypto/x509/ca list -ca pki/issuing
+//	go run ./src/crypto/x509/ca revoke -ca pki/issuing -serial 3f2a... -reason keyCompromise
+//	go run ./src/crypto/x509/ca crl -ca pki/issuing -out issuing.crl
+//	go run ./src/crypto/x509/ca serve -ca pki/issuing -addr :8889
+//
+// 不带参数运行时在临时目录中演示完整流程
+func main() {
+
+	if len(os.Args) < 2 {
+		Demo()
+		return
+	}
+	var err error
+	switch cmd, args := os.Args[1], os.Args[2:]; cmd {
+	case "init":
+		err = Init(args)
+	case "intermediate":
+		err = Intermediate(args)
+	case "request":
+		err = Request(args)
+	case "sign":
+		err = Sign(args)
+	case "list":
+		err = List(args)
+	case "revoke":
+		err = Revoke(args)
+	case "crl":
+		err = CRL(args)
+	case "serve":
+		err = Serve(args)
+	default:
+		err = fmt.Errorf("unknown command %q, expected init, intermediate, request, sign, list, revoke, crl or serve", cmd)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// 创建根CA
+func Init(args []string) error {
+
+	fs := flag.NewFlagSet("init", flag.ExitOnError)
+	dir := fs.String("dir", "", "CA目录")
+	cn := fs.String("cn", "Root CA", "CommonName")
+	org := fs.String("org", "", "Organization")
+	keyType := fs.String("key", "p384", "密钥类型: "+strings.Join(x509Extra.KeyTypes, ","))
+	days := fs.Int("days", 3650, "有效天数")
+	pathLen := fs.Int("pathlen", 1, "其下中间CA的最大层数，-1不限制")
+	policy := policyFlags(fs)
+	urls := urlFlags(fs)
+	fs.Parse(args)
+	if *dir == "" {
+		return fmt.Errorf("init: -dir is required")
+	}
+
+	p, err := policy()
+	if err != nil {
+		return err
+	}
+	key, err := x509Extra.GenerateKey(*keyType)
+	if err != nil {
+		return err
+	}
+	db, err := openDB(*dir)
+	if err != nil {
+		return err
+	}
+	ca, err := x509Extra.NewRootCA(name(*cn, *org), key, days2duration(*days), *pathLen, db)
+	if err != nil {
+		return err
+	}
+	ca.Policy, ca.URLs = p, urls()
+	if err := ca.Save(*dir); err != nil {
+		return err
+	}
+	fmt.Printf("root CA %q created in %s\n", ca.Cert.Subject.CommonName, *dir)
+	return nil
+}
+
+// 由上级CA签发中间CA
+func Intermediate(args []string) error {
+
+	fs := flag.NewFlagSet("intermediate", flag.ExitOnError)
+	parentDir := fs.String("ca", "", "上级CA目录")
+	dir := fs.String("dir", "", "新CA目录")
+	cn := fs.String("cn", "Intermediate CA", "CommonName")
+	org := fs.String("org", "", "Organization")
+	keyType := fs.String("key", "p256", "密钥类型: "+strings.Join(x509Extra.KeyTypes, ","))
+	days := fs.Int("days", 1825, "有效天数")
+	pathLen := fs.Int("pathlen", 0, "其下中间CA的最大层数")
+	policy := policyFlags(fs)
+	urls := urlFlags(fs)
+	fs.Parse(args)
+	if *parentDir == "" || *dir == "" {
+		return fmt.Errorf("intermediate: -ca and -dir are required")
+	}
+
+	p, err := policy()
+	if err != nil {
+		return err
+	}
+	parent, err := x509Extra.Load(*parentDir)
+	if err != nil {
+		return err
+	}
+	key, err := x509Extra.GenerateKey(*keyType)
+	if err != nil {
+		return err
+	}
+	cert, err := parent.IssueIntermediate(name(*cn, *org), key.Public(), days2duration(*days), *pathLen)
+	if err != nil {
+		return err
+	}
+	db, err := openDB(*dir)
+	if err != nil {
+		return err
+	}
+	ca := &x509Extra.CA{Cert: cert, Key: key, Parents: parent.Chain(), Policy: p, URLs: urls(), DB: db}
+	if err := ca.Save(*dir); err != nil {
+		return err
+	}
+	fmt.Printf("intermediate CA %q issued by %q, serial %s\n", cert.Subject.CommonName, cert.Issuer.CommonName, cert.SerialNumber.Text(16))
+	return nil
+}
+
+// 生成私钥及证书请求
+func Request(args []string) error {
+
+	fs := flag.NewFlagSet("request", flag.ExitOnError)
+	cn := fs.String("cn", "", "CommonName")
+	org := fs.String("org", "", "Organization")
+	dns := fs.String("dns", "", "逗号分隔的DNS名称")
+	ips := fs.String("ip", "", "逗号分隔的IP地址")
+	keyType := fs.String("key-type", "p256", "密钥类型: "+strings.Join(x509Extra.KeyTypes, ","))
+	keyOut := fs.String("key", "key.pem", "私钥输出文件")
+	out := fs.String("out", "request.csr", "证书请求输出文件")
+	fs.Parse(args)
+
+	key, err := x509Extra.GenerateKey(*keyType)
+	if err != nil {
+		return err
+	}
+	template := &x509.CertificateRequest{Subject: name(*cn, *org), DNSNames: split(*dns)}
+	for _, s := range split(*ips) {
+		ip := net.ParseIP(s)
+		if ip == nil {
+			return fmt.Errorf("request: invalid IP address %q", s)
+		}
+		template.IPAddresses = append(template.IPAddresses, ip)
+	}
+	der, err := x509.CreateCertificateRequest(nil, template, key)
+	if err != nil {
+		return err
+	}
+	csr, err := x509.ParseCertificateRequest(der)
+	if err != nil {
+		return err
+	}
+	keyPEM, err := x509Extra.EncodeKeyPEM(key)
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(*keyOut, keyPEM, 0600); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(*out, x509Extra.EncodeCSR(csr), 0644)
+}
+
+// 签发证书请求，输出包含上级CA的证书链
+func Sign(args []string) error {
+
+	fs := flag.NewFlagSet("sign", flag.ExitOnError)
+	dir := fs.String("ca", "", "CA目录")
+	csrFile := fs.String("csr", "", "证书请求文件(PEM或DER)")
+	days := fs.Int("days", 90, "有效天数")
+	eku := fs.String("eku", "server,client", "扩展密钥用途: server,client")
+	out := fs.String("out", "", "证书链输出文件，为空时输出到标准输出")
+	fs.Parse(args)
+	if *dir == "" || *csrFile == "" {
+		return fmt.Errorf("sign: -ca and -csr are required")
+	}
+
+	ca, err := x509Extra.Load(*dir)
+	if err != nil {
+		return err
+	}
+	data, err := ioutil.ReadFile(*csrFile)
+	if err != nil {
+		return err
+	}
+	csr, err := x509Extra.ParseCSR(data)
+	if err != nil {
+		return err
+	}
+	opts := x509Extra.LeafOptions{Validity: days2duration(*days)}
+	for _, u := range split(*eku) {
+		switch u {
+		case "server":
+			opts.ExtKeyUsage = append(opts.ExtKeyUsage, x509.ExtKeyUsageServerAuth)
+		case "client":
+			opts.ExtKeyUsage = append(opts.ExtKeyUsage, x509.ExtKeyUsageClientAuth)
+		default:
+			return fmt.Errorf("sign: unknown extended key usage %q", u)
+		}
+	}
+	cert, err := ca.SignCSR(csr, opts)
+	if err != nil {
+		return err
+	}
+	bundle := ca.Bundle(cert)
+	if *out == "" {
+		_, err = os.Stdout.Write(bundle)
+		return err
+	}
+	fmt.Printf("issued %q, serial %s, expires %s\n", cert.Subject.CommonName, cert.SerialNumber.Text(16), cert.NotAfter.Format(time.RFC3339))
+	return ioutil.WriteFile(*out, bundle, 0644)
+}
+
+// 列出CA签发的证书
+func List(args []string) error {
+
+	fs := flag.NewFlagSet("list", flag.ExitOnError)
+	dir := fs.String("ca", "", "CA目录")
+	fs.Parse(args)
+
+	ca, err := x509Extra.Load(*dir)
+	if err != nil {
+		return err
+	}
+	for _, r := range ca.DB.Records() {
+		kind := "leaf"
+		if r.IsCA {
+			kind = "CA"
+		}
+		status := "valid"
+		if r.Revoked() {
+			status = "revoked(" + r.Reason.String() + ")"
+		}
+		fmt.Printf("%-34s %-4s %s  %-26s %s  %s\n", r.Serial, kind, r.NotAfter.Format("2006-01-02"), status, r.Subject, strings.Join(r.DNSNames, ","))
+	}
+	return nil
+}
+
+// 吊销证书
+func Revoke(args []string) error {
+
+	fs := flag.NewFlagSet("revoke", flag.ExitOnError)
+	dir := fs.String("ca", "", "CA目录")
+	serial := fs.String("serial", "", "十六进制序列号")
+	reason := fs.String("reason", "unspecified", "吊销原因，如keyCompromise、superseded、cessationOfOperation")
+	fs.Parse(args)
+
+	ca, err := x509Extra.Load(*dir)
+	if err != nil {
+		return err
+	}
+	n, ok := new(big.Int).SetString(strings.TrimPrefix(strings.ToLower(*serial), "0x"), 16)
+	if !ok {
+		return fmt.Errorf("revoke: invalid serial %q", *serial)
+	}
+	r, err := x509Extra.ParseReason(*reason)
+	if err != nil {
+		return err
+	}
+	return ca.Revoke(n, r)
+}
+
+// 生成CRL
+func CRL(args []string) error {
+
+	fs := flag.NewFlagSet("crl", flag.ExitOnError)
+	dir := fs.String("ca", "", "CA目录")
+	hours := fs.Int("hours", 24, "距下次更新的小时数")
+	out := fs.String("out", "", "PEM输出文件，为空时输出到标准输出")
+	fs.Parse(args)
+
+	ca, err := x509Extra.Load(*dir)
+	if err != nil {
+		return err
+	}
+	der, err := ca.CreateCRL(time.Duration(*hours) * time.Hour)
+	if err != nil {
+		return err
+	}
+	if *out == "" {
+		_, err = os.Stdout.Write(x509Extra.EncodeCRL(der))
+		return err
+	}
+	return ioutil.WriteFile(*out, x509Extra.EncodeCRL(der), 0644)
+}
+
+// 提供OCSP(/ocsp)与CRL(/crl)服务
+func Serve(args []string) error {
+
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	dir := fs.String("ca", "", "CA目录")
+	addr := fs.String("addr", ":8889", "监听地址")
+	fs.Parse(args)
+
+	ca, err := x509Extra.Load(*dir)
+	if err != nil {
+		return err
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/ocsp", x509Extra.NewOCSPResponder(ca))
+	mux.Handle("/ocsp/", x509Extra.NewOCSPResponder(ca))
+	mux.Handle("/crl", ca.CRLHandler(24*time.Hour))
+	fmt.Printf("serving OCSP and CRL for %q on %s\n", ca.Cert.Subject.CommonName, *addr)
+	return http.ListenAndServe(*addr, mux)
+}
+
+func urlFlags(fs *flag.FlagSet) func() x509Extra.DistributionURLs {
+	ocsp := fs.String("ocsp", "", "写入签发证书的OCSP地址，逗号分隔")
+	crl := fs.String("crl", "", "写入签发证书的CRL分发点，逗号分隔")
+	return func() x509Extra.DistributionURLs {
+		return x509Extra.DistributionURLs{OCSPServer: split(*ocsp), CRLDistributionPoints: split(*crl)}
+	}
+}
+
+func policyFlags(fs *flag.FlagSet) func() (x509Extra.Policy, error) {
+
+	domains := fs.String("allow-domain", "", "逗号分隔的允许签发的域名，为空时不限制")
+	nets := fs.String("allow-ip", "", "逗号分隔的允许签发的IP网段，为空时不允许IP地址")
+	wildcard := fs.Bool("allow-wildcard", false, "允许通配符域名")
+	maxDays := fs.Int("max-days", 397, "终端证书的最长有效天数")
+	return func() (x509Extra.Policy, error) {
+		p := x509Extra.DefaultPolicy()
+		p.AllowedDomains = split(*domains)
+		p.AllowedIPNets = split(*nets)
+		p.AllowWildcard = *wildcard
+		p.MaxValidityDays = *maxDays
+		for _, cidr := range p.AllowedIPNets {
+			if _, _, err := net.ParseCIDR(cidr); err != nil {
+				return p, err
+			}
+		}
+		return p, nil
+	}
+}
+
+func openDB(dir string) (*x509Extra.DB, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	return x509Extra.OpenDB(filepath.Join(dir, x509Extra.DBFile))
+}
+
+func name(cn, org string) pkix.Name {
+	n := pkix.Name{CommonName: cn}
+	if org != "" {
+		n.Organization = []string{org}
+	}
+	return n
+}
+
+func split(s string) []string {
+	var out []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+func days2duration(days int) time.Duration {
+	return time.Duration(days) * 24 * time.Hour
+}
+
+func Demo() {
+
+	dir, err := ioutil.TempDir("", "ca-example")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	root, issuing := filepath.Join(dir, "root"), filepath.Join(dir, "issuing")
+	csrFile, keyFile, bundleFile := filepath.Join(dir, "api.csr"), filepath.Join(dir, "api-key.pem"), filepath.Join(dir, "api.pem")
+
+	steps := [][]string{
+		{"init", "-dir", root, "-cn", "Example Root CA", "-org", "Example", "-pathlen", "1"},
+		{"intermediate", "-ca", root, "-dir", issuing, "-cn", "Example Issuing CA", "-pathlen", "0", "-allow-domain", "internal", "-allow-ip", "127.0.0.0/8"},
+		{"request", "-cn", "api.internal", "-dns", "api.internal,api.svc.internal", "-ip", "127.0.0.1", "-key", keyFile, "-out", csrFile},
+		{"sign", "-ca", issuing, "-csr", csrFile, "-days", "30", "-out", bundleFile},
+	}
+	for _, step := range steps {
+		fmt.Println("$ ca", strings.Join(step[:1], " "))
+		var err error
+		switch step[0] {
+		case "init":
+			err = Init(step[1:])
+		case "intermediate":
+			err = Intermediate(step[1:])
+		case "request":
+			err = Request(step[1:])
+		case "sign":
+			err = Sign(step[1:])
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	// 使用根证书校验签发的证书链
+	data, err := ioutil.ReadFile(bundleFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+	chain, err := x509Extra.ParseCertificates(data)
+	if err != nil {
+		log.Fatal(err)
+	}
+	rootCA, err := x509Extra.Load(root)
+	if err != nil {
+		log.Fatal(err)
+	}
+	roots, intermediates := x509.NewCertPool(), x509.NewCertPool()
+	roots.AddCert(rootCA.Cert)
+	for _, c := range chain[1:] {
+		intermediates.AddCert(c)
+	}
+	verified, err := chain[0].Verify(x509.VerifyOptions{
+		DNSName:       "api.internal",
+		Roots:         roots,
+		Intermediates: intermediates,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	for i, c := range verified[0] {
+		fmt.Printf("%s%s (pathlen %d)\n", strings.Repeat("  ", i), c.Subject.CommonName, c.MaxPathLen)
+	}
+
+	// 违反策略的请求
+	issuingCA, err := x509Extra.Load(issuing)
+	if err != nil {
+		log.Fatal(err)
+	}
+	data, _ = ioutil.ReadFile(csrFile)
+	csr, err := x509Extra.ParseCSR(data)
+	if err != nil {
+		log.Fatal(err)
+	}
+	_, err = issuingCA.SignCSR(csr, x509Extra.LeafOptions{Validity: 2 * 365 * 24 * time.Hour})
+	fmt.Println("too long:", err)
+
+	if err := Request([]string{"-cn", "www.example.com", "-key", keyFile, "-out", csrFile}); err != nil {
+		log.Fatal(err)
+	}
+	data, _ = ioutil.ReadFile(csrFile)
+	if csr, err = x509Extra.ParseCSR(data); err != nil {
+		log.Fatal(err)
+	}
+	_, err = issuingCA.SignCSR(csr, x509Extra.LeafOptions{})
+	fmt.Println("outside allowed domains:", err)
+
+	// 路径长度为0的中间CA不能再签发CA
+	_, err = issuingCA.IssueIntermediate(pkix.Name{CommonName: "Sub CA"}, issuingCA.Key.Public(), 24*time.Hour, 0)
+	fmt.Println("sub CA:", err)
+
+	fmt.Println("issued by root:")
+	List([]string{"-ca", root})
+	fmt.Println("issued by issuing CA:")
+	List([]string{"-ca", issuing})
+
+	Revocation(issuing)
+}
+
+// 在本地启动OCSP及CRL服务，吊销一张证书后分别通过OCSP和CRL检查
+func Revocation(dir string) {
+
+	ca, err := x509Extra.Load(dir)
+	if err != nil {
+		log.Fatal(err)
+	}
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	mux.Handle("/ocsp", x509Extra.NewOCSPResponder(ca))
+	mux.Handle("/crl", ca.CRLHandler(time.Hour))
+	ca.URLs = x509Extra.DistributionURLs{
+		OCSPServer:            []string{server.URL + "/ocsp"},
+		CRLDistributionPoints: []string{server.URL + "/crl"},
+	}
+
+	issue := func(cn string) *x509.Certificate {
+		key, err := x509Extra.GenerateKey("p256")
+		if err != nil {
+			log.Fatal(err)
+		}
+		der, err := x509.CreateCertificateRequest(nil, &x509.CertificateRequest{Subject: pkix.Name{CommonName: cn}}, key)
+		if err != nil {
+			log.Fatal(err)
+		}
+		csr, err := x509.ParseCertificateRequest(der)
+		if err != nil {
+			log.Fatal(err)
+		}
+		cert, err := ca.SignCSR(csr, x509Extra.LeafOptions{})
+		if err != nil {
+			log.Fatal(err)
+		}
+		return cert
+	}
+	good, bad := issue("good.internal"), issue("bad.internal")
+	if err := ca.Revoke(bad.SerialNumber, x509Extra.KeyCompromise); err != nil {
+		log.Fatal(err)
+	}
+
+	roots, intermediates := ca.Pools()
+	checker := &x509Extra.RevocationChecker{}
+	for _, cert := range []*x509.Certificate{good, bad} {
+		chains, err := cert.Verify(x509.VerifyOptions{Roots: roots, Intermediates: intermediates})
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("%s: %v\n", cert.Subject.CommonName, checker.CheckChains(chains))
+	}
+
+	// 没有OCSP地址时回退到CRL
+	crlOnly := *bad
+	crlOnly.OCSPServer = nil
+	fmt.Println("crl:", checker.CheckCert(&crlOnly, ca.Cert))
+
+	der, err := ca.CreateCRL(time.Hour)
+	if err != nil {
+		log.Fatal(err)
+	}
+	crl, err := x509Extra.ParseCRL(der, ca.Cert)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("CRL number %s, %d revoked, next update %s\n", crl.Number, len(crl.RevokedCertificateEntries), crl.NextUpdate.Format(time.RFC3339))
+}
@@ -45,6 +45,7 @@ func main() {
 	roots.Subjects()
 
 	// 生成证书及密钥
+	// 根CA、中间CA及按证书请求签发证书链参考ca示例
 	createCertificate()
 
 	// 通过root证书认证客户端证书
@@ -0,0 +1,362 @@
+package extra
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// CA目录中的文件
+const (
+	CertFile   = "ca.pem"
+	KeyFile    = "ca-key.pem"
+	ChainFile  = "chain.pem"
+	PolicyFile = "policy.json"
+	URLsFile   = "urls.json"
+	DBFile     = "db.json"
+)
+
+// 签发时将NotBefore提前，容忍客户端的时钟偏差
+const backdate = 5 * time.Minute
+
+var (
+	ErrNotCA       = errors.New("x509: certificate is not a CA")
+	ErrPathLength  = errors.New("x509: path length constraint does not allow a subordinate CA")
+	ErrKeyMismatch = errors.New("x509: private key does not match certificate")
+	ErrOutlivesCA  = errors.New("x509: validity exceeds issuer certificate")
+	ErrNoDB        = errors.New("x509: CA has no certificate database")
+)
+
+// 证书颁发机构，可以是根CA或中间CA
+type CA struct {
+	Cert *x509.Certificate
+	Key  crypto.Signer
+	// 上级证书，从签发Cert的证书直到根证书，根CA为空
+	Parents []*x509.Certificate
+	Policy  Policy
+	// 写入签发证书的OCSP、CRL地址
+	URLs DistributionURLs
+	// 为nil时不记录签发的证书
+	DB *DB
+}
+
+// 创建自签名的根CA
+// maxPathLen限制其下中间CA的层数，0表示只能签发终端证书，-1表示不限制
+func NewRootCA(subject pkix.Name, key crypto.Signer, validity time.Duration, maxPathLen int, db *DB) (*CA, error) {
+	return newRootCA(subject, key, validity, maxPathLen, db, nil)
+}
+
+// 名称约束(RFC 5280 4.2.1.10)，其下证书的DNS名称与IP地址必须位于其中
+type NameConstraints struct {
+	// "example.com"允许example.com及其子域名
+	DNSDomains []string
+	IPRanges   []*net.IPNet
+}
+
+// 创建带名称约束的根CA，约束扩展标记为critical
+// 即使CA私钥泄露，签发的其他名称也无法通过校验，适合加入本机信任的开发CA
+func NewConstrainedRootCA(subject pkix.Name, key crypto.Signer, validity time.Duration, maxPathLen int, db *DB, constraints NameConstraints) (*CA, error) {
+	return newRootCA(subject, key, validity, maxPathLen, db, &constraints)
+}
+
+func newRootCA(subject pkix.Name, key crypto.Signer, validity time.Duration, maxPathLen int, db *DB, constraints *NameConstraints) (*CA, error) {
+
+	serial, err := db.NewSerial()
+	if err != nil {
+		return nil, err
+	}
+	template, err := caTemplate(subject, key.Public(), validity, maxPathLen)
+	if err != nil {
+		return nil, err
+	}
+	if constraints != nil {
+		template.PermittedDNSDomainsCritical = true
+		template.PermittedDNSDomains = constraints.DNSDomains
+		template.PermittedIPRanges = constraints.IPRanges
+	}
+	template.SerialNumber = serial
+	template.NotBefore = template.NotBefore.Add(-backdate)
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	if err != nil {
+		return nil, err
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, err
+	}
+	if db != nil {
+		if err := db.Add(cert); err != nil {
+			return nil, err
+		}
+	}
+	ca := &CA{Cert: cert, Key: key, Policy: DefaultPolicy(), DB: db}
+	// 带名称约束的CA允许签发约束内的IP地址
+	if constraints != nil {
+		for _, n := range constraints.IPRanges {
+			ca.Policy.AllowedIPNets = append(ca.Policy.AllowedIPNets, n.String())
+		}
+	}
+	return ca, nil
+}
+
+// 签发中间CA证书，maxPathLen必须小于本CA的限制
+func (ca *CA) IssueIntermediate(subject pkix.Name, pub crypto.PublicKey, validity time.Duration, maxPathLen int) (*x509.Certificate, error) {
+
+	parent := ca.Cert
+	if !parent.IsCA {
+		return nil, ErrNotCA
+	}
+	// 解析得到的证书没有路径长度限制时MaxPathLen为-1
+	if parent.MaxPathLen >= 0 && (maxPathLen < 0 || maxPathLen >= parent.MaxPathLen) {
+		return nil, ErrPathLength
+	}
+	template, err := caTemplate(subject, pub, validity, maxPathLen)
+	if err != nil {
+		return nil, err
+	}
+	return ca.issue(template, pub)
+}
+
+// 按证书请求签发的终端证书参数
+type LeafOptions struct {
+	// 为0时使用90天
+	Validity time.Duration
+	// 为0时RSA密钥使用DigitalSignature|KeyEncipherment，其他密钥使用DigitalSignature
+	KeyUsage x509.KeyUsage
+	// 为空时使用ServerAuth与ClientAuth
+	ExtKeyUsage []x509.ExtKeyUsage
+}
+
+// 校验证书请求的签名，按策略检查SAN、用途、有效期和密钥强度后签发终端证书
+// 请求中没有SAN时使用CommonName作为DNS名称
+// 证书主体只保留CommonName，请求中的O、OU等其他属性未经校验，不会写入证书
+func (ca *CA) SignCSR(csr *x509.CertificateRequest, opts LeafOptions) (*x509.Certificate, error) {
+
+	if err := csr.CheckSignature(); err != nil {
+		return nil, err
+	}
+	if opts.Validity == 0 {
+		opts.Validity = 90 * 24 * time.Hour
+	}
+	if opts.KeyUsage == 0 {
+		opts.KeyUsage = x509.KeyUsageDigitalSignature
+		if _, ok := csr.PublicKey.(*rsa.PublicKey); ok {
+			opts.KeyUsage |= x509.KeyUsageKeyEncipherment
+		}
+	}
+	if len(opts.ExtKeyUsage) == 0 {
+		opts.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth}
+	}
+
+	now := time.Now()
+	template := &x509.Certificate{
+		Subject:               pkix.Name{CommonName: csr.Subject.CommonName},
+		DNSNames:              csr.DNSNames,
+		IPAddresses:           csr.IPAddresses,
+		EmailAddresses:        csr.EmailAddresses,
+		URIs:                  csr.URIs,
+		NotBefore:             now,
+		NotAfter:              now.Add(opts.Validity),
+		KeyUsage:              opts.KeyUsage,
+		ExtKeyUsage:           opts.ExtKeyUsage,
+		BasicConstraintsValid: true,
+	}
+	if len(template.DNSNames)+len(template.IPAddresses)+len(template.EmailAddresses)+len(template.URIs) == 0 && csr.Subject.CommonName != "" {
+		template.DNSNames = []string{csr.Subject.CommonName}
+	}
+	if err := ca.Policy.Check(template, csr.PublicKey); err != nil {
+		return nil, err
+	}
+	return ca.issue(template, csr.PublicKey)
+}
+
+// 本CA及其上级证书
+func (ca *CA) Chain() []*x509.Certificate {
+	return append([]*x509.Certificate{ca.Cert}, ca.Parents...)
+}
+
+// 根证书，即链中的最后一个
+func (ca *CA) Root() *x509.Certificate {
+	chain := ca.Chain()
+	return chain[len(chain)-1]
+}
+
+// 终端证书及其上级CA组成的PEM证书链，不包含根证书
+// 根证书应通过其他途径分发给校验方，握手时发送也不会被信任
+func (ca *CA) Bundle(leaf *x509.Certificate) []byte {
+	chain := ca.Chain()
+	return EncodeCertificates(append([]*x509.Certificate{leaf}, chain[:len(chain)-1]...)...)
+}
+
+// 包含根证书的证书池，可用于x509.VerifyOptions
+func (ca *CA) Pools() (roots, intermediates *x509.CertPool) {
+	roots, intermediates = x509.NewCertPool(), x509.NewCertPool()
+	chain := ca.Chain()
+	roots.AddCert(chain[len(chain)-1])
+	for _, cert := range chain[:len(chain)-1] {
+		intermediates.AddCert(cert)
+	}
+	return roots, intermediates
+}
+
+func (ca *CA) issue(template *x509.Certificate, pub crypto.PublicKey) (*x509.Certificate, error) {
+
+	if template.NotAfter.After(ca.Cert.NotAfter) {
+		return nil, ErrOutlivesCA
+	}
+	serial, err := ca.DB.NewSerial()
+	if err != nil {
+		return nil, err
+	}
+	template.SerialNumber = serial
+	template.NotBefore = template.NotBefore.Add(-backdate)
+	template.OCSPServer = ca.URLs.OCSPServer
+	template.IssuingCertificateURL = ca.URLs.IssuingCertificateURL
+	template.CRLDistributionPoints = ca.URLs.CRLDistributionPoints
+	if template.SubjectKeyId == nil {
+		if template.SubjectKeyId, err = subjectKeyID(pub); err != nil {
+			return nil, err
+		}
+	}
+
+	// AuthorityKeyId取自上级证书的SubjectKeyId
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.Cert, pub, ca.Key)
+	if err != nil {
+		return nil, err
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, err
+	}
+	if ca.DB != nil {
+		if err := ca.DB.Add(cert); err != nil {
+			return nil, err
+		}
+	}
+	return cert, nil
+}
+
+func caTemplate(subject pkix.Name, pub crypto.PublicKey, validity time.Duration, maxPathLen int) (*x509.Certificate, error) {
+
+	ski, err := subjectKeyID(pub)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	return &x509.Certificate{
+		Subject:               subject,
+		NotBefore:             now,
+		NotAfter:              now.Add(validity),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		MaxPathLen:            maxPathLen,
+		MaxPathLenZero:        maxPathLen == 0,
+		SubjectKeyId:          ski,
+	}, nil
+}
+
+// 将CA写入目录: 证书、私钥(0600)、上级证书链、策略和吊销信息地址；数据库由DB自行维护
+func (ca *CA) Save(dir string) error {
+
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	key, err := EncodeKeyPEM(ca.Key)
+	if err != nil {
+		return err
+	}
+	policy, err := json.MarshalIndent(ca.Policy, "", "  ")
+	if err != nil {
+		return err
+	}
+	urls, err := json.MarshalIndent(ca.URLs, "", "  ")
+	if err != nil {
+		return err
+	}
+	files := []struct {
+		name string
+		data []byte
+		perm os.FileMode
+	}{
+		{CertFile, EncodeCertificates(ca.Cert), 0644},
+		{KeyFile, key, 0600},
+		{ChainFile, EncodeCertificates(ca.Parents...), 0644},
+		{PolicyFile, append(policy, '\n'), 0644},
+		{URLsFile, append(urls, '\n'), 0644},
+	}
+	for _, f := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, f.name), f.data, f.perm); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// 从目录加载CA，并打开其中的签发数据库
+func Load(dir string) (*CA, error) {
+
+	data, err := ioutil.ReadFile(filepath.Join(dir, CertFile))
+	if err != nil {
+		return nil, err
+	}
+	certs, err := ParseCertificates(data)
+	if err != nil {
+		return nil, err
+	}
+	if data, err = ioutil.ReadFile(filepath.Join(dir, KeyFile)); err != nil {
+		return nil, err
+	}
+	key, err := ParseKeyPEM(data)
+	if err != nil {
+		return nil, err
+	}
+	ca := &CA{Cert: certs[0], Key: key, Policy: DefaultPolicy()}
+	if !publicKeyEqual(ca.Cert.PublicKey, key.Public()) {
+		return nil, ErrKeyMismatch
+	}
+
+	// 根CA的上级证书链为空
+	if data, err = ioutil.ReadFile(filepath.Join(dir, ChainFile)); err == nil && len(data) > 0 {
+		if ca.Parents, err = ParseCertificates(data); err != nil {
+			return nil, err
+		}
+	}
+	if data, err = ioutil.ReadFile(filepath.Join(dir, PolicyFile)); err == nil {
+		if err := json.Unmarshal(data, &ca.Policy); err != nil {
+			return nil, err
+		}
+	}
+	if data, err = ioutil.ReadFile(filepath.Join(dir, URLsFile)); err == nil {
+		if err := json.Unmarshal(data, &ca.URLs); err != nil {
+			return nil, err
+		}
+	}
+	if ca.DB, err = OpenDB(filepath.Join(dir, DBFile)); err != nil {
+		return nil, err
+	}
+	return ca, nil
+}
+
+// 比较DER编码，适用于全部公钥类型
+func publicKeyEqual(a, b crypto.PublicKey) bool {
+	da, err := x509.MarshalPKIXPublicKey(a)
+	if err != nil {
+		return false
+	}
+	db, err := x509.MarshalPKIXPublicKey(b)
+	if err != nil {
+		return false
+	}
+	return string(da) == string(db)
+}
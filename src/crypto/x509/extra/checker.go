@@ -0,0 +1,204 @@
+package extra
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+)
+
+// 证书已被吊销
+type RevokedError struct {
+	Cert      *x509.Certificate
+	RevokedAt time.Time
+	Reason    Reason
+	// 状态来源: ocsp或crl
+	Source string
+}
+
+func (e *RevokedError) Error() string {
+	return fmt.Sprintf("x509: certificate %q (serial %s) was revoked at %s, reason %s (%s)",
+		e.Cert.Subject.CommonName, e.Cert.SerialNumber.Text(16), e.RevokedAt.Format(time.RFC3339), e.Reason, e.Source)
+}
+
+var ErrNoRevocationInfo = errors.New("x509: revocation status could not be determined")
+
+// 在cert.Verify得到证书链后检查链上每张证书的吊销状态
+// 优先查询证书中的OCSP地址，失败时回退到CRL分发点
+//
+//	chains, err := cert.Verify(opts)
+//	if err == nil {
+//		err = checker.CheckChains(chains)
+//	}
+type RevocationChecker struct {
+	// 为nil时使用超时10秒的客户端
+	HTTPClient *http.Client
+	// 为true时无法获取吊销状态(地址不可达、响应过期等)的证书视为有效，但已吊销的证书仍会被拒绝
+	SoftFail bool
+	// 允许的时钟偏差，为0时使用5分钟
+	MaxSkew time.Duration
+}
+
+// 任意一条链通过检查即返回nil，否则返回第一条链的错误
+func (c *RevocationChecker) CheckChains(chains [][]*x509.Certificate) error {
+	var first error
+	for _, chain := range chains {
+		err := c.Check(chain)
+		if err == nil {
+			return nil
+		}
+		if first == nil {
+			first = err
+		}
+	}
+	if first == nil {
+		return errors.New("x509: no verified chains")
+	}
+	return first
+}
+
+// 检查从终端证书到根证书的链，根证书不检查
+func (c *RevocationChecker) Check(chain []*x509.Certificate) error {
+	for i := 0; i+1 < len(chain); i++ {
+		if err := c.CheckCert(chain[i], chain[i+1]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// 检查由issuer签发的cert
+func (c *RevocationChecker) CheckCert(cert, issuer *x509.Certificate) error {
+
+	if len(cert.OCSPServer) == 0 && len(cert.CRLDistributionPoints) == 0 {
+		return nil
+	}
+	var lastErr error
+	for _, server := range cert.OCSPServer {
+		resp, err := c.queryOCSP(server, cert, issuer)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		switch resp.Status {
+		case Good:
+			return nil
+		case Revoked:
+			return &RevokedError{Cert: cert, RevokedAt: resp.RevokedAt, Reason: resp.Reason, Source: "ocsp"}
+		}
+		lastErr = fmt.Errorf("x509: OCSP responder %s does not know the certificate", server)
+	}
+	for _, dp := range cert.CRLDistributionPoints {
+		revoked, err := c.checkCRL(dp, cert, issuer)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if revoked != nil {
+			return revoked
+		}
+		return nil
+	}
+	if c.SoftFail {
+		return nil
+	}
+	return fmt.Errorf("%v: %v", ErrNoRevocationInfo, lastErr)
+}
+
+func (c *RevocationChecker) queryOCSP(server string, cert, issuer *x509.Certificate) (*OCSPResponse, error) {
+
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	req, err := CreateOCSPRequest(cert, issuer, nonce)
+	if err != nil {
+		return nil, err
+	}
+	body, err := c.fetch(http.MethodPost, server, req)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := ParseOCSPResponse(body, cert, issuer)
+	if err != nil {
+		return nil, err
+	}
+	// 响应者支持nonce时必须回显请求中的值，防止重放旧响应
+	if resp.Nonce != nil && !bytes.Equal(resp.Nonce, nonce) {
+		return nil, errors.New("ocsp: response nonce does not match")
+	}
+	if err := c.checkFreshness(resp.ThisUpdate, resp.NextUpdate); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (c *RevocationChecker) checkCRL(url string, cert, issuer *x509.Certificate) (*RevokedError, error) {
+
+	body, err := c.fetch(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	crl, err := ParseCRL(body, issuer)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.checkFreshness(crl.ThisUpdate, crl.NextUpdate); err != nil {
+		return nil, err
+	}
+	for _, entry := range crl.RevokedCertificateEntries {
+		if entry.SerialNumber.Cmp(cert.SerialNumber) == 0 {
+			return &RevokedError{Cert: cert, RevokedAt: entry.RevocationTime, Reason: Reason(entry.ReasonCode), Source: "crl"}, nil
+		}
+	}
+	return nil, nil
+}
+
+func (c *RevocationChecker) checkFreshness(thisUpdate, nextUpdate time.Time) error {
+	skew := c.MaxSkew
+	if skew == 0 {
+		skew = 5 * time.Minute
+	}
+	now := time.Now()
+	if thisUpdate.After(now.Add(skew)) {
+		return errors.New("x509: revocation information is not yet valid")
+	}
+	if !nextUpdate.IsZero() && nextUpdate.Before(now.Add(-skew)) {
+		return errors.New("x509: revocation information has expired")
+	}
+	return nil
+}
+
+func (c *RevocationChecker) fetch(method, url string, body []byte) ([]byte, error) {
+
+	client := c.HTTPClient
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	var req *http.Request
+	var err error
+	if method == http.MethodPost {
+		if req, err = http.NewRequest(method, url, bytes.NewReader(body)); err == nil {
+			req.Header.Set("Content-Type", "application/ocsp-request")
+		}
+	} else {
+		req, err = http.NewRequest(method, url, nil)
+	}
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("x509: %s returned %s", url, resp.Status)
+	}
+	return ioutil.ReadAll(io.LimitReader(resp.Body, 10<<20))
+}
@@ -0,0 +1,138 @@
+package extra
+
+import (
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// 吊销原因，RFC 5280 5.3.1
+type Reason int
+
+const (
+	Unspecified          Reason = 0
+	KeyCompromise        Reason = 1
+	CACompromise         Reason = 2
+	AffiliationChanged   Reason = 3
+	Superseded           Reason = 4
+	CessationOfOperation Reason = 5
+	CertificateHold      Reason = 6
+	PrivilegeWithdrawn   Reason = 9
+	AACompromise         Reason = 10
+)
+
+var reasonNames = map[Reason]string{
+	Unspecified:          "unspecified",
+	KeyCompromise:        "keyCompromise",
+	CACompromise:         "cACompromise",
+	AffiliationChanged:   "affiliationChanged",
+	Superseded:           "superseded",
+	CessationOfOperation: "cessationOfOperation",
+	CertificateHold:      "certificateHold",
+	PrivilegeWithdrawn:   "privilegeWithdrawn",
+	AACompromise:         "aACompromise",
+}
+
+func (r Reason) String() string {
+	if name, ok := reasonNames[r]; ok {
+		return name
+	}
+	return fmt.Sprintf("Reason(%d)", int(r))
+}
+
+// 按名称解析，不区分大小写，与openssl ca -crl_reason一致
+func ParseReason(name string) (Reason, error) {
+	for r, n := range reasonNames {
+		if strings.EqualFold(n, name) {
+			return r, nil
+		}
+	}
+	return 0, fmt.Errorf("x509: unknown revocation reason %q", name)
+}
+
+// 吊销本CA签发的证书，CA没有数据库时返回ErrNoDB
+func (ca *CA) Revoke(serial *big.Int, reason Reason) error {
+	if ca.DB == nil {
+		return ErrNoDB
+	}
+	return ca.DB.Revoke(serial, reason, time.Now())
+}
+
+// 生成并签名包含全部已吊销证书的CRL，返回DER编码
+// CRL编号保存在数据库中，每次生成时递增
+func (ca *CA) CreateCRL(validity time.Duration) ([]byte, error) {
+
+	if ca.DB == nil {
+		return nil, ErrNoDB
+	}
+	number, err := ca.DB.NextCRLNumber()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	template := &x509.RevocationList{
+		Number:     number,
+		ThisUpdate: now,
+		NextUpdate: now.Add(validity),
+	}
+	for _, r := range ca.DB.Revoked() {
+		serial, ok := new(big.Int).SetString(r.Serial, 16)
+		if !ok {
+			return nil, fmt.Errorf("x509: invalid serial %q in database", r.Serial)
+		}
+		entry := x509.RevocationListEntry{SerialNumber: serial, RevocationTime: *r.RevokedAt}
+		// 原因为unspecified时应省略该扩展
+		if r.Reason != Unspecified {
+			entry.ReasonCode = int(r.Reason)
+		}
+		template.RevokedCertificateEntries = append(template.RevokedCertificateEntries, entry)
+	}
+	return x509.CreateRevocationList(rand.Reader, template, ca.Cert, ca.Key)
+}
+
+func EncodeCRL(der []byte) []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: "X509 CRL", Bytes: der})
+}
+
+// 解析PEM或DER编码的CRL并校验其签名
+func ParseCRL(data []byte, issuer *x509.Certificate) (*x509.RevocationList, error) {
+	if block, _ := pem.Decode(data); block != nil {
+		data = block.Bytes
+	}
+	crl, err := x509.ParseRevocationList(data)
+	if err != nil {
+		return nil, err
+	}
+	if issuer != nil {
+		if err := crl.CheckSignatureFrom(issuer); err != nil {
+			return nil, err
+		}
+	}
+	return crl, nil
+}
+
+// 每次请求时生成最新的CRL，validity为CRL的NextUpdate间隔
+func (ca *CA) CRLHandler(validity time.Duration) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		der, err := ca.CreateCRL(validity)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/pkix-crl")
+		w.Header().Set("Cache-Control", fmt.Sprintf("max-age=%d", int(validity.Seconds())))
+		w.Write(der)
+	})
+}
+
+// 在签发的证书中写入的吊销信息地址
+type DistributionURLs struct {
+	OCSPServer            []string `json:"ocsp_server,omitempty"`
+	IssuingCertificateURL []string `json:"issuing_certificate_url,omitempty"`
+	CRLDistributionPoints []string `json:"crl_distribution_points,omitempty"`
+}
@@ -0,0 +1,226 @@
+package extra
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+var (
+	ErrDuplicateSerial = errors.New("x509: serial number already issued")
+	ErrUnknownSerial   = errors.New("x509: serial number was not issued by this CA")
+	ErrAlreadyRevoked  = errors.New("x509: certificate is already revoked")
+)
+
+// 签发记录
+type Record struct {
+	// 十六进制序列号
+	Serial    string    `json:"serial"`
+	Subject   string    `json:"subject"`
+	DNSNames  []string  `json:"dns_names,omitempty"`
+	IsCA      bool      `json:"is_ca,omitempty"`
+	NotBefore time.Time `json:"not_before"`
+	NotAfter  time.Time `json:"not_after"`
+	IssuedAt  time.Time `json:"issued_at"`
+	// 吊销时间，未吊销时为nil
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+	Reason    Reason     `json:"reason,omitempty"`
+}
+
+func (r *Record) Revoked() bool {
+	return r.RevokedAt != nil
+}
+
+// 基于JSON文件的签发数据库，记录已使用的序列号和CRL编号，每次修改后整体写回
+type DB struct {
+	mu        sync.Mutex
+	path      string
+	records   []Record
+	serials   map[string]int
+	crlNumber uint64
+}
+
+// 数据库文件的内容
+type dbFile struct {
+	Records []Record `json:"records"`
+	// 最近一次生成的CRL编号
+	CRLNumber uint64 `json:"crl_number"`
+}
+
+// 打开数据库，文件不存在时创建空数据库
+func OpenDB(path string) (*DB, error) {
+
+	db := &DB{path: path, serials: make(map[string]int)}
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return db, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var file dbFile
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '[' {
+		// 旧版本的文件只有签发记录数组
+		err = json.Unmarshal(data, &file.Records)
+	} else {
+		err = json.Unmarshal(data, &file)
+	}
+	if err != nil {
+		return nil, err
+	}
+	db.records, db.crlNumber = file.Records, file.CRLNumber
+	for i, r := range db.records {
+		db.serials[r.Serial] = i
+	}
+	return db, nil
+}
+
+// 生成未使用过的序列号: 128位随机正整数(CA/B要求至少64位随机)
+func (db *DB) NewSerial() (*big.Int, error) {
+
+	max := new(big.Int).Lsh(big.NewInt(1), 128)
+	for {
+		serial, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return nil, err
+		}
+		if serial.Sign() == 0 {
+			continue
+		}
+		if db == nil {
+			return serial, nil
+		}
+		db.mu.Lock()
+		_, used := db.serials[serial.Text(16)]
+		db.mu.Unlock()
+		if !used {
+			return serial, nil
+		}
+	}
+}
+
+// 记录签发的证书并写回文件
+func (db *DB) Add(cert *x509.Certificate) error {
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	serial := cert.SerialNumber.Text(16)
+	if _, ok := db.serials[serial]; ok {
+		return ErrDuplicateSerial
+	}
+	db.records = append(db.records, Record{
+		Serial:    serial,
+		Subject:   cert.Subject.String(),
+		DNSNames:  cert.DNSNames,
+		IsCA:      cert.IsCA,
+		NotBefore: cert.NotBefore,
+		NotAfter:  cert.NotAfter,
+		IssuedAt:  time.Now().UTC(),
+	})
+	db.serials[serial] = len(db.records) - 1
+	if err := db.save(); err != nil {
+		db.records = db.records[:len(db.records)-1]
+		delete(db.serials, serial)
+		return err
+	}
+	return nil
+}
+
+func (db *DB) Lookup(serial *big.Int) (Record, bool) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	i, ok := db.serials[serial.Text(16)]
+	if !ok {
+		return Record{}, false
+	}
+	return db.records[i], true
+}
+
+// 吊销证书并写回文件
+func (db *DB) Revoke(serial *big.Int, reason Reason, at time.Time) error {
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	i, ok := db.serials[serial.Text(16)]
+	if !ok {
+		return ErrUnknownSerial
+	}
+	if db.records[i].Revoked() {
+		return ErrAlreadyRevoked
+	}
+	at = at.UTC().Truncate(time.Second)
+	db.records[i].RevokedAt, db.records[i].Reason = &at, reason
+	if err := db.save(); err != nil {
+		db.records[i].RevokedAt, db.records[i].Reason = nil, 0
+		return err
+	}
+	return nil
+}
+
+// 递增并保存CRL编号，RFC 5280要求同一CA的CRL编号单调递增
+func (db *DB) NextCRLNumber() (*big.Int, error) {
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	db.crlNumber++
+	if err := db.save(); err != nil {
+		db.crlNumber--
+		return nil, err
+	}
+	return new(big.Int).SetUint64(db.crlNumber), nil
+}
+
+// 已吊销的记录
+func (db *DB) Revoked() []Record {
+	var revoked []Record
+	for _, r := range db.Records() {
+		if r.Revoked() {
+			revoked = append(revoked, r)
+		}
+	}
+	return revoked
+}
+
+// 按签发时间排序的全部记录
+func (db *DB) Records() []Record {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	records := append([]Record(nil), db.records...)
+	sort.SliceStable(records, func(i, j int) bool { return records[i].IssuedAt.Before(records[j].IssuedAt) })
+	return records
+}
+
+// 先写临时文件再重命名，避免写入中断时损坏数据库
+func (db *DB) save() error {
+
+	data, err := json.MarshalIndent(dbFile{Records: db.records, CRLNumber: db.crlNumber}, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(db.path), ".db-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), db.path)
+}
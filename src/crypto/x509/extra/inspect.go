@@ -0,0 +1,481 @@
+package extra
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	rsaExtra "github.com/zc2638/go-standard/src/crypto/rsa/extra"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// 文件中的一个对象，根据类型只有一个字段不为nil
+type Object struct {
+	// certificate, csr, crl, private key, public key
+	Type        string           `json:"type"`
+	Certificate *CertificateInfo `json:"certificate,omitempty"`
+	CSR         *CSRInfo         `json:"csr,omitempty"`
+	CRL         *CRLInfo         `json:"crl,omitempty"`
+	Key         *KeyInfo         `json:"key,omitempty"`
+	// 解析得到的证书，以及证书、证书请求或密钥中的公钥
+	Cert      *x509.Certificate `json:"-"`
+	PublicKey crypto.PublicKey  `json:"-"`
+}
+
+type KeyInfo struct {
+	// RSA, ECDSA, Ed25519；无法解析的公钥为unknown及算法OID，此时Bits为0
+	Algorithm string `json:"algorithm"`
+	Bits      int    `json:"bits"`
+	Curve     string `json:"curve,omitempty"`
+	Private   bool   `json:"private,omitempty"`
+	// 公钥SubjectPublicKeyInfo的SHA-256，base64编码，即HPKP/SPKI pin
+	SPKISHA256 string `json:"spki_sha256"`
+}
+
+type ExtensionInfo struct {
+	OID      string `json:"oid"`
+	Name     string `json:"name,omitempty"`
+	Critical bool   `json:"critical"`
+}
+
+type CertificateInfo struct {
+	Subject            string    `json:"subject"`
+	Issuer             string    `json:"issuer"`
+	Serial             string    `json:"serial"`
+	NotBefore          time.Time `json:"not_before"`
+	NotAfter           time.Time `json:"not_after"`
+	SelfSigned         bool      `json:"self_signed"`
+	DNSNames           []string  `json:"dns_names,omitempty"`
+	IPAddresses        []string  `json:"ip_addresses,omitempty"`
+	EmailAddresses     []string  `json:"email_addresses,omitempty"`
+	URIs               []string  `json:"uris,omitempty"`
+	PublicKey          KeyInfo   `json:"public_key"`
+	SignatureAlgorithm string    `json:"signature_algorithm"`
+	IsCA               bool      `json:"is_ca"`
+	// 没有路径长度限制时为-1
+	MaxPathLen     int             `json:"max_path_len"`
+	KeyUsage       []string        `json:"key_usage,omitempty"`
+	ExtKeyUsage    []string        `json:"ext_key_usage,omitempty"`
+	SubjectKeyID   string          `json:"subject_key_id,omitempty"`
+	AuthorityKeyID string          `json:"authority_key_id,omitempty"`
+	OCSPServer     []string        `json:"ocsp_server,omitempty"`
+	IssuingURL     []string        `json:"issuing_certificate_url,omitempty"`
+	CRLPoints      []string        `json:"crl_distribution_points,omitempty"`
+	Extensions     []ExtensionInfo `json:"extensions,omitempty"`
+	SHA1           string          `json:"sha1_fingerprint"`
+	SHA256         string          `json:"sha256_fingerprint"`
+}
+
+type CSRInfo struct {
+	Subject            string          `json:"subject"`
+	DNSNames           []string        `json:"dns_names,omitempty"`
+	IPAddresses        []string        `json:"ip_addresses,omitempty"`
+	EmailAddresses     []string        `json:"email_addresses,omitempty"`
+	URIs               []string        `json:"uris,omitempty"`
+	PublicKey          KeyInfo         `json:"public_key"`
+	SignatureAlgorithm string          `json:"signature_algorithm"`
+	SignatureValid     bool            `json:"signature_valid"`
+	Extensions         []ExtensionInfo `json:"extensions,omitempty"`
+}
+
+type RevokedInfo struct {
+	Serial    string    `json:"serial"`
+	RevokedAt time.Time `json:"revoked_at"`
+	Reason    string    `json:"reason,omitempty"`
+}
+
+type CRLInfo struct {
+	Issuer             string          `json:"issuer"`
+	Number             string          `json:"number,omitempty"`
+	ThisUpdate         time.Time       `json:"this_update"`
+	NextUpdate         time.Time       `json:"next_update"`
+	SignatureAlgorithm string          `json:"signature_algorithm"`
+	AuthorityKeyID     string          `json:"authority_key_id,omitempty"`
+	Revoked            []RevokedInfo   `json:"revoked,omitempty"`
+	Extensions         []ExtensionInfo `json:"extensions,omitempty"`
+}
+
+// 解析PEM(可包含多个块)或DER编码的证书、证书请求、CRL与密钥
+func Inspect(data []byte) ([]Object, error) {
+
+	if !strings.Contains(string(data), "-----BEGIN") {
+		obj, err := inspectDER(data)
+		if err != nil {
+			return nil, err
+		}
+		return []Object{obj}, nil
+	}
+
+	var objects []Object
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+		obj, err := inspectBlock(block)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", block.Type, err)
+		}
+		objects = append(objects, obj)
+	}
+	if len(objects) == 0 {
+		return nil, errors.New("x509: no PEM data found")
+	}
+	return objects, nil
+}
+
+func inspectBlock(block *pem.Block) (Object, error) {
+
+	if _, encrypted := block.Headers["DEK-Info"]; encrypted {
+		return Object{}, errors.New("encrypted PEM is not supported")
+	}
+	encoded := pem.EncodeToMemory(block)
+	switch block.Type {
+	case "CERTIFICATE":
+		return certificateObject(block.Bytes)
+	case "CERTIFICATE REQUEST", "NEW CERTIFICATE REQUEST":
+		return csrObject(block.Bytes)
+	case "X509 CRL":
+		return crlObject(block.Bytes)
+	// RSA专用格式复用rsa示例中的加载函数
+	case "RSA PRIVATE KEY":
+		key, err := rsaExtra.BuildRSAPKCS1PrivateKey(encoded)
+		if err != nil {
+			return Object{}, err
+		}
+		return keyObject(key.Public(), true)
+	case "RSA PUBLIC KEY":
+		key, err := rsaExtra.BuildRSAPKCS1PublicKey(encoded)
+		if err != nil {
+			return Object{}, err
+		}
+		return keyObject(key, false)
+	case "PRIVATE KEY", "EC PRIVATE KEY":
+		key, err := ParseKeyPEM(encoded)
+		if err != nil {
+			return Object{}, err
+		}
+		return keyObject(key.Public(), true)
+	case "PUBLIC KEY":
+		key, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return Object{}, err
+		}
+		return keyObject(key, false)
+	}
+	return Object{}, errors.New("unsupported PEM block")
+}
+
+// DER没有类型信息，依次尝试各种格式
+func inspectDER(der []byte) (Object, error) {
+	if obj, err := certificateObject(der); err == nil {
+		return obj, nil
+	}
+	if obj, err := csrObject(der); err == nil {
+		return obj, nil
+	}
+	if obj, err := crlObject(der); err == nil {
+		return obj, nil
+	}
+	if key, err := x509.ParsePKCS8PrivateKey(der); err == nil {
+		if signer, ok := key.(crypto.Signer); ok {
+			return keyObject(signer.Public(), true)
+		}
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(der); err == nil {
+		return keyObject(key.Public(), true)
+	}
+	if key, err := x509.ParseECPrivateKey(der); err == nil {
+		return keyObject(key.Public(), true)
+	}
+	if key, err := x509.ParsePKIXPublicKey(der); err == nil {
+		return keyObject(key, false)
+	}
+	return Object{}, errors.New("x509: unrecognized DER data")
+}
+
+func certificateObject(der []byte) (Object, error) {
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return Object{}, err
+	}
+	info, err := DescribeCertificate(cert)
+	if err != nil {
+		return Object{}, err
+	}
+	return Object{Type: "certificate", Certificate: info, Cert: cert, PublicKey: cert.PublicKey}, nil
+}
+
+func csrObject(der []byte) (Object, error) {
+
+	csr, err := x509.ParseCertificateRequest(der)
+	if err != nil {
+		return Object{}, err
+	}
+	key, err := describePublicKey(csr.PublicKey, csr.RawSubjectPublicKeyInfo)
+	if err != nil {
+		return Object{}, err
+	}
+	info := &CSRInfo{
+		Subject:            csr.Subject.String(),
+		DNSNames:           csr.DNSNames,
+		IPAddresses:        ipStrings(csr.IPAddresses),
+		EmailAddresses:     csr.EmailAddresses,
+		URIs:               uriStrings(csr.URIs),
+		PublicKey:          *key,
+		SignatureAlgorithm: csr.SignatureAlgorithm.String(),
+		SignatureValid:     csr.CheckSignature() == nil,
+		Extensions:         describeExtensions(csr.Extensions),
+	}
+	return Object{Type: "csr", CSR: info, PublicKey: csr.PublicKey}, nil
+}
+
+func crlObject(der []byte) (Object, error) {
+
+	crl, err := x509.ParseRevocationList(der)
+	if err != nil {
+		return Object{}, err
+	}
+	info := &CRLInfo{
+		Issuer:             crl.Issuer.String(),
+		ThisUpdate:         crl.ThisUpdate,
+		NextUpdate:         crl.NextUpdate,
+		SignatureAlgorithm: crl.SignatureAlgorithm.String(),
+		AuthorityKeyID:     colonHex(crl.AuthorityKeyId),
+		Extensions:         describeExtensions(crl.Extensions),
+	}
+	if crl.Number != nil {
+		info.Number = crl.Number.String()
+	}
+	for _, entry := range crl.RevokedCertificateEntries {
+		r := RevokedInfo{Serial: colonHex(entry.SerialNumber.Bytes()), RevokedAt: entry.RevocationTime}
+		if entry.ReasonCode != 0 {
+			r.Reason = Reason(entry.ReasonCode).String()
+		}
+		info.Revoked = append(info.Revoked, r)
+	}
+	return Object{Type: "crl", CRL: info}, nil
+}
+
+func keyObject(pub crypto.PublicKey, private bool) (Object, error) {
+	info, err := DescribeKey(pub)
+	if err != nil {
+		return Object{}, err
+	}
+	info.Private = private
+	typ := "public key"
+	if private {
+		typ = "private key"
+	}
+	return Object{Type: typ, Key: info, PublicKey: pub}, nil
+}
+
+func DescribeCertificate(cert *x509.Certificate) (*CertificateInfo, error) {
+
+	key, err := describePublicKey(cert.PublicKey, cert.RawSubjectPublicKeyInfo)
+	if err != nil {
+		return nil, err
+	}
+	sha1sum, sha256sum := sha1.Sum(cert.Raw), sha256.Sum256(cert.Raw)
+	info := &CertificateInfo{
+		Subject:            cert.Subject.String(),
+		Issuer:             cert.Issuer.String(),
+		Serial:             colonHex(cert.SerialNumber.Bytes()),
+		NotBefore:          cert.NotBefore,
+		NotAfter:           cert.NotAfter,
+		SelfSigned:         string(cert.RawIssuer) == string(cert.RawSubject) && cert.CheckSignatureFrom(cert) == nil,
+		DNSNames:           cert.DNSNames,
+		IPAddresses:        ipStrings(cert.IPAddresses),
+		EmailAddresses:     cert.EmailAddresses,
+		URIs:               uriStrings(cert.URIs),
+		PublicKey:          *key,
+		SignatureAlgorithm: cert.SignatureAlgorithm.String(),
+		IsCA:               cert.IsCA,
+		MaxPathLen:         -1,
+		KeyUsage:           keyUsageNames(cert.KeyUsage),
+		SubjectKeyID:       colonHex(cert.SubjectKeyId),
+		AuthorityKeyID:     colonHex(cert.AuthorityKeyId),
+		OCSPServer:         cert.OCSPServer,
+		IssuingURL:         cert.IssuingCertificateURL,
+		CRLPoints:          cert.CRLDistributionPoints,
+		Extensions:         describeExtensions(cert.Extensions),
+		SHA1:               colonHex(sha1sum[:]),
+		SHA256:             colonHex(sha256sum[:]),
+	}
+	if cert.IsCA && (cert.MaxPathLen > 0 || cert.MaxPathLenZero) {
+		info.MaxPathLen = cert.MaxPathLen
+	}
+	for _, eku := range cert.ExtKeyUsage {
+		info.ExtKeyUsage = append(info.ExtKeyUsage, extKeyUsageName(eku))
+	}
+	for _, oid := range cert.UnknownExtKeyUsage {
+		info.ExtKeyUsage = append(info.ExtKeyUsage, oid.String())
+	}
+	return info, nil
+}
+
+func DescribeKey(pub crypto.PublicKey) (*KeyInfo, error) {
+
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return nil, err
+	}
+	pin := sha256.Sum256(der)
+	info := &KeyInfo{SPKISHA256: base64.StdEncoding.EncodeToString(pin[:])}
+	switch key := pub.(type) {
+	case *rsa.PublicKey:
+		info.Algorithm, info.Bits = "RSA", key.N.BitLen()
+	case *ecdsa.PublicKey:
+		info.Algorithm, info.Bits, info.Curve = "ECDSA", key.Curve.Params().BitSize, key.Curve.Params().Name
+	case ed25519.PublicKey:
+		info.Algorithm, info.Bits = "Ed25519", 256
+	default:
+		return nil, fmt.Errorf("x509: unsupported public key type %T", pub)
+	}
+	return info, nil
+}
+
+// 证书及证书请求中本包无法解析的公钥(例如SM2、Ed448)只记录算法OID与SPKI指纹
+func describePublicKey(pub crypto.PublicKey, spki []byte) (*KeyInfo, error) {
+
+	if info, err := DescribeKey(pub); err == nil {
+		return info, nil
+	}
+	var raw struct {
+		Algorithm pkix.AlgorithmIdentifier
+		PublicKey asn1.BitString
+	}
+	if _, err := asn1.Unmarshal(spki, &raw); err != nil {
+		return nil, err
+	}
+	pin := sha256.Sum256(spki)
+	return &KeyInfo{
+		Algorithm:  "unknown (" + raw.Algorithm.Algorithm.String() + ")",
+		SPKISHA256: base64.StdEncoding.EncodeToString(pin[:]),
+	}, nil
+}
+
+// 密钥是否与证书中的公钥匹配，私钥传入其Public()
+func KeyMatches(pub crypto.PublicKey, cert *x509.Certificate) bool {
+	return publicKeyEqual(pub, cert.PublicKey)
+}
+
+// 以certs[0]为终端证书、其余为中间证书校验证书链，与cert.Verify一致
+// roots为空时使用系统根证书；dnsName不为空时同时校验主机名
+func VerifyChain(certs, roots []*x509.Certificate, dnsName string, at time.Time) ([][]*x509.Certificate, error) {
+
+	if len(certs) == 0 {
+		return nil, errors.New("x509: no certificate to verify")
+	}
+	opts := x509.VerifyOptions{
+		DNSName:       dnsName,
+		Intermediates: x509.NewCertPool(),
+		CurrentTime:   at,
+		// 校验链本身，不限制终端证书的用途
+		KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	}
+	if len(roots) > 0 {
+		opts.Roots = x509.NewCertPool()
+		for _, root := range roots {
+			opts.Roots.AddCert(root)
+		}
+	}
+	for _, cert := range certs[1:] {
+		opts.Intermediates.AddCert(cert)
+	}
+	return certs[0].Verify(opts)
+}
+
+var extensionNames = map[string]string{
+	"2.5.29.14":               "Subject Key Identifier",
+	"2.5.29.15":               "Key Usage",
+	"2.5.29.17":               "Subject Alternative Name",
+	"2.5.29.19":               "Basic Constraints",
+	"2.5.29.20":               "CRL Number",
+	"2.5.29.21":               "CRL Reason Code",
+	"2.5.29.30":               "Name Constraints",
+	"2.5.29.31":               "CRL Distribution Points",
+	"2.5.29.32":               "Certificate Policies",
+	"2.5.29.35":               "Authority Key Identifier",
+	"2.5.29.37":               "Extended Key Usage",
+	"1.3.6.1.5.5.7.1.1":       "Authority Information Access",
+	"1.3.6.1.5.5.7.48.1.5":    "OCSP No Check",
+	"1.3.6.1.4.1.11129.2.4.2": "CT Precertificate SCTs",
+}
+
+func describeExtensions(exts []pkix.Extension) []ExtensionInfo {
+	var out []ExtensionInfo
+	for _, ext := range exts {
+		out = append(out, ExtensionInfo{OID: ext.Id.String(), Name: extensionNames[ext.Id.String()], Critical: ext.Critical})
+	}
+	return out
+}
+
+func keyUsageNames(usage x509.KeyUsage) []string {
+	names := []string{"Digital Signature", "Content Commitment", "Key Encipherment", "Data Encipherment",
+		"Key Agreement", "Certificate Sign", "CRL Sign", "Encipher Only", "Decipher Only"}
+	var out []string
+	for i, name := range names {
+		if usage&(1<<uint(i)) != 0 {
+			out = append(out, name)
+		}
+	}
+	return out
+}
+
+func extKeyUsageName(eku x509.ExtKeyUsage) string {
+	names := map[x509.ExtKeyUsage]string{
+		x509.ExtKeyUsageAny:             "Any",
+		x509.ExtKeyUsageServerAuth:      "TLS Web Server Authentication",
+		x509.ExtKeyUsageClientAuth:      "TLS Web Client Authentication",
+		x509.ExtKeyUsageCodeSigning:     "Code Signing",
+		x509.ExtKeyUsageEmailProtection: "E-mail Protection",
+		x509.ExtKeyUsageTimeStamping:    "Time Stamping",
+		x509.ExtKeyUsageOCSPSigning:     "OCSP Signing",
+	}
+	if name, ok := names[eku]; ok {
+		return name
+	}
+	return fmt.Sprintf("ExtKeyUsage(%d)", int(eku))
+}
+
+// 与openssl一致的冒号分隔大写十六进制
+func colonHex(b []byte) string {
+	if len(b) == 0 {
+		return ""
+	}
+	parts := make([]string, len(b))
+	for i, v := range b {
+		parts[i] = fmt.Sprintf("%02X", v)
+	}
+	return strings.Join(parts, ":")
+}
+
+func ipStrings(ips []net.IP) []string {
+	var out []string
+	for _, ip := range ips {
+		out = append(out, ip.String())
+	}
+	return out
+}
+
+func uriStrings(uris []*url.URL) []string {
+	var out []string
+	for _, u := range uris {
+		out = append(out, u.String())
+	}
+	return out
+}
@@ -0,0 +1,101 @@
+package extra
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// 支持的密钥类型
+var KeyTypes = []string{"rsa2048", "rsa3072", "rsa4096", "p256", "p384"}
+
+// 生成私钥，kind取值参见KeyTypes
+func GenerateKey(kind string) (crypto.Signer, error) {
+	switch strings.ToLower(kind) {
+	case "rsa2048":
+		return rsa.GenerateKey(rand.Reader, 2048)
+	case "rsa3072":
+		return rsa.GenerateKey(rand.Reader, 3072)
+	case "rsa4096":
+		return rsa.GenerateKey(rand.Reader, 4096)
+	case "p256", "ecdsa":
+		return ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case "p384":
+		return ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	}
+	return nil, fmt.Errorf("x509: unsupported key type %q", kind)
+}
+
+// 编码为PKCS#8格式的PEM
+func EncodeKeyPEM(key crypto.Signer) ([]byte, error) {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil
+}
+
+// 解析PEM编码的私钥，支持PKCS#8、PKCS#1(RSA PRIVATE KEY)与SEC 1(EC PRIVATE KEY)
+func ParseKeyPEM(data []byte) (crypto.Signer, error) {
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("x509: no PEM data found")
+	}
+	var key interface{}
+	var err error
+	switch block.Type {
+	case "PRIVATE KEY":
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+		// 部分工具会把PKCS#1或SEC1格式的私钥标记为PRIVATE KEY
+		if err != nil {
+			if k, e := x509.ParsePKCS1PrivateKey(block.Bytes); e == nil {
+				key, err = k, nil
+			} else if k, e := x509.ParseECPrivateKey(block.Bytes); e == nil {
+				key, err = k, nil
+			}
+		}
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		key, err = x509.ParseECPrivateKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("x509: unsupported PEM block %q", block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, errors.New("x509: private key cannot sign")
+	}
+	return signer, nil
+}
+
+// RFC 5280 4.2.1.2 方法1: 公钥BIT STRING的SHA-1
+func subjectKeyID(pub crypto.PublicKey) ([]byte, error) {
+
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return nil, err
+	}
+	var info struct {
+		Algorithm pkix.AlgorithmIdentifier
+		PublicKey asn1.BitString
+	}
+	if _, err := asn1.Unmarshal(der, &info); err != nil {
+		return nil, err
+	}
+	sum := sha1.Sum(info.PublicKey.Bytes)
+	return sum[:], nil
+}
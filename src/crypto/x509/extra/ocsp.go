@@ -0,0 +1,466 @@
+package extra
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// OCSP(RFC 6960)的ASN.1结构，只实现CA自身签名或委托签名的基本响应
+
+var (
+	oidSHA1             = asn1.ObjectIdentifier{1, 3, 14, 3, 2, 26}
+	oidSHA256           = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1}
+	oidOCSPBasic        = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 48, 1, 1}
+	oidOCSPNonce        = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 48, 1, 2}
+	oidSHA256WithRSA    = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 11}
+	oidSHA384WithRSA    = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 12}
+	oidSHA512WithRSA    = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 13}
+	oidECDSAWithSHA256  = asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 2}
+	oidECDSAWithSHA384  = asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 3}
+	oidECDSAWithSHA512  = asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 4}
+	signatureAlgorithms = []struct {
+		oid asn1.ObjectIdentifier
+		alg x509.SignatureAlgorithm
+	}{
+		{oidSHA256WithRSA, x509.SHA256WithRSA},
+		{oidSHA384WithRSA, x509.SHA384WithRSA},
+		{oidSHA512WithRSA, x509.SHA512WithRSA},
+		{oidECDSAWithSHA256, x509.ECDSAWithSHA256},
+		{oidECDSAWithSHA384, x509.ECDSAWithSHA384},
+		{oidECDSAWithSHA512, x509.ECDSAWithSHA512},
+	}
+)
+
+type certID struct {
+	HashAlgorithm pkix.AlgorithmIdentifier
+	NameHash      []byte
+	IssuerKeyHash []byte
+	SerialNumber  *big.Int
+}
+
+type ocspRequest struct {
+	TBSRequest tbsRequest
+}
+
+type tbsRequest struct {
+	Version       int           `asn1:"explicit,tag:0,default:0,optional"`
+	RequestorName asn1.RawValue `asn1:"explicit,tag:1,optional"`
+	RequestList   []singleRequest
+	Extensions    []pkix.Extension `asn1:"explicit,tag:2,optional"`
+}
+
+type singleRequest struct {
+	Cert certID
+}
+
+type ocspResponse struct {
+	Status   asn1.Enumerated
+	Response responseBytes `asn1:"explicit,tag:0,optional"`
+}
+
+type responseBytes struct {
+	ResponseType asn1.ObjectIdentifier
+	Response     []byte
+}
+
+type basicResponse struct {
+	TBSResponseData    asn1.RawValue
+	SignatureAlgorithm pkix.AlgorithmIdentifier
+	Signature          asn1.BitString
+	Certificates       []asn1.RawValue `asn1:"explicit,tag:0,optional"`
+}
+
+type responseData struct {
+	Version     int `asn1:"explicit,tag:0,default:0,optional"`
+	ResponderID asn1.RawValue
+	ProducedAt  time.Time `asn1:"generalized"`
+	Responses   []singleResponse
+	Extensions  []pkix.Extension `asn1:"explicit,tag:1,optional"`
+}
+
+type singleResponse struct {
+	CertID     certID
+	Good       asn1.Flag   `asn1:"tag:0,optional"`
+	Revoked    revokedInfo `asn1:"tag:1,optional"`
+	Unknown    asn1.Flag   `asn1:"tag:2,optional"`
+	ThisUpdate time.Time   `asn1:"generalized"`
+	NextUpdate time.Time   `asn1:"generalized,explicit,tag:0,optional"`
+}
+
+type revokedInfo struct {
+	RevocationTime time.Time       `asn1:"generalized"`
+	Reason         asn1.Enumerated `asn1:"explicit,tag:0,optional"`
+}
+
+// OCSPResponse.responseStatus
+const (
+	ocspSuccessful       = 0
+	ocspMalformedRequest = 1
+	ocspInternalError    = 2
+	ocspUnauthorized     = 6
+)
+
+// 证书状态
+type OCSPStatus int
+
+const (
+	Good OCSPStatus = iota
+	Revoked
+	Unknown
+)
+
+func (s OCSPStatus) String() string {
+	switch s {
+	case Good:
+		return "good"
+	case Revoked:
+		return "revoked"
+	}
+	return "unknown"
+}
+
+// 解析后的OCSP响应
+type OCSPResponse struct {
+	Status       OCSPStatus
+	SerialNumber *big.Int
+	ProducedAt   time.Time
+	ThisUpdate   time.Time
+	NextUpdate   time.Time
+	RevokedAt    time.Time
+	Reason       Reason
+	// 委托签名时为响应者证书，CA直接签名时为nil
+	Responder *x509.Certificate
+	Nonce     []byte
+}
+
+var (
+	ErrOCSPSignature = errors.New("ocsp: response signature is invalid")
+	ErrOCSPMismatch  = errors.New("ocsp: response does not match the requested certificate")
+)
+
+// OCSP服务返回的错误状态
+type OCSPError int
+
+func (e OCSPError) Error() string {
+	names := map[OCSPError]string{1: "malformedRequest", 2: "internalError", 3: "tryLater", 5: "sigRequired", 6: "unauthorized"}
+	if name, ok := names[e]; ok {
+		return "ocsp: responder returned " + name
+	}
+	return fmt.Sprintf("ocsp: responder returned status %d", int(e))
+}
+
+// 使用SHA-1计算CertID，与openssl ocsp默认一致
+func newCertID(cert, issuer *x509.Certificate, hashOID asn1.ObjectIdentifier) (certID, error) {
+
+	var info struct {
+		Algorithm pkix.AlgorithmIdentifier
+		PublicKey asn1.BitString
+	}
+	if _, err := asn1.Unmarshal(issuer.RawSubjectPublicKeyInfo, &info); err != nil {
+		return certID{}, err
+	}
+	h := sha1.New
+	if hashOID.Equal(oidSHA256) {
+		h = sha256.New
+	}
+	nameHash, keyHash := h(), h()
+	nameHash.Write(issuer.RawSubject)
+	keyHash.Write(info.PublicKey.Bytes)
+	return certID{
+		HashAlgorithm: pkix.AlgorithmIdentifier{Algorithm: hashOID, Parameters: asn1.NullRawValue},
+		NameHash:      nameHash.Sum(nil),
+		IssuerKeyHash: keyHash.Sum(nil),
+		SerialNumber:  cert.SerialNumber,
+	}, nil
+}
+
+// 生成查询cert状态的DER编码请求，nonce为nil时不包含nonce扩展
+func CreateOCSPRequest(cert, issuer *x509.Certificate, nonce []byte) ([]byte, error) {
+
+	id, err := newCertID(cert, issuer, oidSHA1)
+	if err != nil {
+		return nil, err
+	}
+	req := ocspRequest{TBSRequest: tbsRequest{RequestList: []singleRequest{{Cert: id}}}}
+	if nonce != nil {
+		value, err := asn1.Marshal(nonce)
+		if err != nil {
+			return nil, err
+		}
+		req.TBSRequest.Extensions = []pkix.Extension{{Id: oidOCSPNonce, Value: value}}
+	}
+	return asn1.Marshal(req)
+}
+
+// OCSP响应服务，同时支持GET(RFC 6960 A.1)与POST请求
+type OCSPResponder struct {
+	CA *CA
+	// 委托的响应者证书及私钥，需由CA签发且包含OCSPSigning用途；为nil时使用CA私钥签名
+	Cert *x509.Certificate
+	Key  crypto.Signer
+	// 响应的有效期，即NextUpdate-ThisUpdate，为0时使用1小时
+	Validity time.Duration
+}
+
+func NewOCSPResponder(ca *CA) *OCSPResponder {
+	return &OCSPResponder{CA: ca, Validity: time.Hour}
+}
+
+func (o *OCSPResponder) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+
+	var req []byte
+	var err error
+	switch r.Method {
+	case http.MethodPost:
+		if ct := r.Header.Get("Content-Type"); ct != "" && ct != "application/ocsp-request" {
+			http.Error(w, "unsupported content type", http.StatusUnsupportedMediaType)
+			return
+		}
+		req, err = ioutil.ReadAll(http.MaxBytesReader(w, r.Body, 10<<10))
+	case http.MethodGet:
+		// 路径的最后一段为URL编码的base64请求
+		var raw string
+		raw, err = url.PathUnescape(r.URL.EscapedPath()[strings.LastIndex(r.URL.EscapedPath(), "/")+1:])
+		if err == nil {
+			req, err = base64.StdEncoding.DecodeString(raw)
+		}
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	if err != nil {
+		req = nil
+	}
+
+	resp := o.Respond(req)
+	w.Header().Set("Content-Type", "application/ocsp-response")
+	w.Write(resp)
+}
+
+// 处理DER编码的请求，返回DER编码的响应；请求无效时返回对应的错误状态
+func (o *OCSPResponder) Respond(reqDER []byte) []byte {
+
+	var req ocspRequest
+	if rest, err := asn1.Unmarshal(reqDER, &req); err != nil || len(rest) > 0 || len(req.TBSRequest.RequestList) == 0 {
+		return errorResponse(ocspMalformedRequest)
+	}
+	// 没有签发数据库时无法判断证书状态
+	if o.CA.DB == nil {
+		return errorResponse(ocspInternalError)
+	}
+
+	now := time.Now().UTC().Truncate(time.Second)
+	validity := o.Validity
+	if validity == 0 {
+		validity = time.Hour
+	}
+	data := responseData{ProducedAt: now}
+	for _, single := range req.TBSRequest.RequestList {
+		id := single.Cert
+		expected, err := newCertID(&x509.Certificate{SerialNumber: id.SerialNumber}, o.CA.Cert, id.HashAlgorithm.Algorithm)
+		if err != nil {
+			return errorResponse(ocspInternalError)
+		}
+		// 只回答本CA签发的证书
+		if !bytes.Equal(id.NameHash, expected.NameHash) || !bytes.Equal(id.IssuerKeyHash, expected.IssuerKeyHash) {
+			return errorResponse(ocspUnauthorized)
+		}
+		resp := singleResponse{CertID: id, ThisUpdate: now, NextUpdate: now.Add(validity)}
+		record, ok := o.CA.DB.Lookup(id.SerialNumber)
+		switch {
+		case !ok:
+			resp.Unknown = true
+		case record.Revoked():
+			resp.Revoked = revokedInfo{RevocationTime: *record.RevokedAt, Reason: asn1.Enumerated(record.Reason)}
+		default:
+			resp.Good = true
+		}
+		data.Responses = append(data.Responses, resp)
+	}
+	// 回显请求中的nonce(RFC 8954)
+	for _, ext := range req.TBSRequest.Extensions {
+		if ext.Id.Equal(oidOCSPNonce) {
+			data.Extensions = append(data.Extensions, ext)
+		}
+	}
+
+	signerCert, key := o.CA.Cert, o.CA.Key
+	if o.Cert != nil {
+		signerCert, key = o.Cert, o.Key
+	}
+	der, err := o.sign(data, signerCert, key)
+	if err != nil {
+		return errorResponse(ocspInternalError)
+	}
+	return der
+}
+
+func (o *OCSPResponder) sign(data responseData, signerCert *x509.Certificate, key crypto.Signer) ([]byte, error) {
+
+	// ResponderID byKey: [2] EXPLICIT KeyHash
+	keyHash, err := subjectKeyID(signerCert.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	octets, err := asn1.Marshal(keyHash)
+	if err != nil {
+		return nil, err
+	}
+	data.ResponderID = asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 2, IsCompound: true, Bytes: octets}
+	tbs, err := asn1.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+
+	algorithm, hash, err := signingAlgorithm(key.Public())
+	if err != nil {
+		return nil, err
+	}
+	h := hash.New()
+	h.Write(tbs)
+	signature, err := key.Sign(rand.Reader, h.Sum(nil), hash)
+	if err != nil {
+		return nil, err
+	}
+	basic := basicResponse{
+		TBSResponseData:    asn1.RawValue{FullBytes: tbs},
+		SignatureAlgorithm: algorithm,
+		Signature:          asn1.BitString{Bytes: signature, BitLength: 8 * len(signature)},
+	}
+	if o.Cert != nil {
+		basic.Certificates = []asn1.RawValue{{FullBytes: o.Cert.Raw}}
+	}
+	basicDER, err := asn1.Marshal(basic)
+	if err != nil {
+		return nil, err
+	}
+	return asn1.Marshal(ocspResponse{
+		Status:   ocspSuccessful,
+		Response: responseBytes{ResponseType: oidOCSPBasic, Response: basicDER},
+	})
+}
+
+func signingAlgorithm(pub crypto.PublicKey) (pkix.AlgorithmIdentifier, crypto.Hash, error) {
+	switch key := pub.(type) {
+	case *rsa.PublicKey:
+		return pkix.AlgorithmIdentifier{Algorithm: oidSHA256WithRSA, Parameters: asn1.NullRawValue}, crypto.SHA256, nil
+	case *ecdsa.PublicKey:
+		switch key.Curve {
+		case elliptic.P384():
+			return pkix.AlgorithmIdentifier{Algorithm: oidECDSAWithSHA384}, crypto.SHA384, nil
+		case elliptic.P521():
+			return pkix.AlgorithmIdentifier{Algorithm: oidECDSAWithSHA512}, crypto.SHA512, nil
+		}
+		return pkix.AlgorithmIdentifier{Algorithm: oidECDSAWithSHA256}, crypto.SHA256, nil
+	}
+	return pkix.AlgorithmIdentifier{}, 0, fmt.Errorf("ocsp: unsupported key type %T", pub)
+}
+
+func errorResponse(status int) []byte {
+	der, _ := asn1.Marshal(ocspResponse{Status: asn1.Enumerated(status)})
+	return der
+}
+
+// 解析响应并校验签名，签名者必须是issuer或由issuer签发的OCSPSigning证书
+// 响应须包含cert的状态
+func ParseOCSPResponse(der []byte, cert, issuer *x509.Certificate) (*OCSPResponse, error) {
+
+	var resp ocspResponse
+	if rest, err := asn1.Unmarshal(der, &resp); err != nil || len(rest) > 0 {
+		return nil, errors.New("ocsp: malformed response")
+	}
+	if resp.Status != ocspSuccessful {
+		return nil, OCSPError(resp.Status)
+	}
+	if !resp.Response.ResponseType.Equal(oidOCSPBasic) {
+		return nil, errors.New("ocsp: unsupported response type")
+	}
+	var basic basicResponse
+	if _, err := asn1.Unmarshal(resp.Response.Response, &basic); err != nil {
+		return nil, err
+	}
+	var data responseData
+	if _, err := asn1.Unmarshal(basic.TBSResponseData.FullBytes, &data); err != nil {
+		return nil, err
+	}
+
+	result := &OCSPResponse{ProducedAt: data.ProducedAt}
+	signer := issuer
+	if len(basic.Certificates) > 0 {
+		responder, err := x509.ParseCertificate(basic.Certificates[0].FullBytes)
+		if err != nil {
+			return nil, err
+		}
+		// 响应者证书与issuer相同时即CA直接签名
+		if !bytes.Equal(responder.Raw, issuer.Raw) {
+			if err := responder.CheckSignatureFrom(issuer); err != nil {
+				return nil, ErrOCSPSignature
+			}
+			if !containsEKU(responder.ExtKeyUsage, x509.ExtKeyUsageOCSPSigning) {
+				return nil, errors.New("ocsp: responder certificate is not authorized for OCSP signing")
+			}
+			if now := time.Now(); now.Before(responder.NotBefore) || now.After(responder.NotAfter) {
+				return nil, errors.New("ocsp: responder certificate is expired or not yet valid")
+			}
+			signer, result.Responder = responder, responder
+		}
+	}
+	alg := x509.UnknownSignatureAlgorithm
+	for _, a := range signatureAlgorithms {
+		if a.oid.Equal(basic.SignatureAlgorithm.Algorithm) {
+			alg = a.alg
+		}
+	}
+	if err := signer.CheckSignature(alg, basic.TBSResponseData.FullBytes, basic.Signature.RightAlign()); err != nil {
+		return nil, ErrOCSPSignature
+	}
+
+	for _, single := range data.Responses {
+		expected, err := newCertID(cert, issuer, single.CertID.HashAlgorithm.Algorithm)
+		if err != nil {
+			return nil, err
+		}
+		if single.CertID.SerialNumber.Cmp(cert.SerialNumber) != 0 ||
+			!bytes.Equal(single.CertID.NameHash, expected.NameHash) ||
+			!bytes.Equal(single.CertID.IssuerKeyHash, expected.IssuerKeyHash) {
+			continue
+		}
+		result.SerialNumber = single.CertID.SerialNumber
+		result.ThisUpdate, result.NextUpdate = single.ThisUpdate, single.NextUpdate
+		switch {
+		case bool(single.Good):
+			result.Status = Good
+		case bool(single.Unknown):
+			result.Status = Unknown
+		default:
+			result.Status = Revoked
+			result.RevokedAt, result.Reason = single.Revoked.RevocationTime, Reason(single.Revoked.Reason)
+		}
+		for _, ext := range data.Extensions {
+			if ext.Id.Equal(oidOCSPNonce) {
+				asn1.Unmarshal(ext.Value, &result.Nonce)
+			}
+		}
+		return result, nil
+	}
+	return nil, ErrOCSPMismatch
+}
@@ -0,0 +1,194 @@
+package extra
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+)
+
+// 签发终端证书时的策略
+type Policy struct {
+	// 允许的域名，"example.com"允许example.com及其子域名；为空时不限制
+	AllowedDomains []string `json:"allowed_domains,omitempty"`
+	// 允许的IP网段，为空时不允许IP地址
+	AllowedIPNets []string `json:"allowed_ip_nets,omitempty"`
+	AllowWildcard bool     `json:"allow_wildcard,omitempty"`
+	AllowEmails   bool     `json:"allow_emails,omitempty"`
+	AllowURIs     bool     `json:"allow_uris,omitempty"`
+	// 最长有效天数，为0时不限制
+	MaxValidityDays int `json:"max_validity_days"`
+	// 允许的KeyUsage位
+	AllowedKeyUsage x509.KeyUsage `json:"allowed_key_usage"`
+	// 允许的ExtKeyUsage
+	AllowedExtKeyUsage []x509.ExtKeyUsage `json:"allowed_ext_key_usage"`
+	MinRSABits         int                `json:"min_rsa_bits"`
+}
+
+// 默认策略: 服务端及客户端身份证书，有效期不超过397天
+// 不允许IP地址，需要签发IP SAN时显式设置AllowedIPNets
+func DefaultPolicy() Policy {
+	return Policy{
+		MaxValidityDays:    397,
+		AllowedKeyUsage:    x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		AllowedExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		MinRSABits:         2048,
+	}
+}
+
+// 证书请求违反策略
+type PolicyError struct {
+	Reason string
+}
+
+func (e *PolicyError) Error() string {
+	return "x509: policy violation: " + e.Reason
+}
+
+func violation(format string, args ...interface{}) error {
+	return &PolicyError{Reason: fmt.Sprintf(format, args...)}
+}
+
+// 检查待签发的模板
+func (p *Policy) Check(template *x509.Certificate, pub interface{}) error {
+
+	if len(template.DNSNames)+len(template.IPAddresses)+len(template.EmailAddresses)+len(template.URIs) == 0 {
+		return violation("certificate has no subject alternative names")
+	}
+	for _, name := range template.DNSNames {
+		if err := p.checkDNSName(name); err != nil {
+			return err
+		}
+	}
+	for _, ip := range template.IPAddresses {
+		if !p.ipAllowed(ip) {
+			return violation("IP address %s is not allowed", ip)
+		}
+	}
+	if len(template.EmailAddresses) > 0 && !p.AllowEmails {
+		return violation("email addresses are not allowed")
+	}
+	if len(template.URIs) > 0 && !p.AllowURIs {
+		return violation("URIs are not allowed")
+	}
+	// CommonName不单独校验，只能为空或与某个已校验的SAN相同
+	if cn := template.Subject.CommonName; cn != "" && !hasSAN(template, cn) {
+		return violation("common name %q does not match any subject alternative name", cn)
+	}
+
+	max := time.Duration(p.MaxValidityDays) * 24 * time.Hour
+	if validity := template.NotAfter.Sub(template.NotBefore); max > 0 && validity > max {
+		return violation("validity of %d days exceeds maximum of %d days", int(validity.Hours()/24), p.MaxValidityDays)
+	}
+	if template.KeyUsage&^p.AllowedKeyUsage != 0 {
+		return violation("key usage %#x is not allowed", int(template.KeyUsage&^p.AllowedKeyUsage))
+	}
+	for _, eku := range template.ExtKeyUsage {
+		if !containsEKU(p.AllowedExtKeyUsage, eku) {
+			return violation("extended key usage %d is not allowed", eku)
+		}
+	}
+
+	switch key := pub.(type) {
+	case *rsa.PublicKey:
+		if key.N.BitLen() < p.MinRSABits {
+			return violation("RSA key size %d is below %d", key.N.BitLen(), p.MinRSABits)
+		}
+	case *ecdsa.PublicKey:
+		if key.Curve.Params().BitSize < 256 {
+			return violation("ECDSA curve %s is too weak", key.Curve.Params().Name)
+		}
+	case ed25519.PublicKey:
+		// Ed25519密钥长度固定，无需检查
+	default:
+		return violation("unsupported public key type %T", pub)
+	}
+	return nil
+}
+
+func (p *Policy) checkDNSName(name string) error {
+
+	if !validDNSName(name, p.AllowWildcard) {
+		return violation("invalid DNS name %q", name)
+	}
+	if len(p.AllowedDomains) == 0 {
+		return nil
+	}
+	host := strings.TrimPrefix(name, "*.")
+	for _, domain := range p.AllowedDomains {
+		domain = strings.ToLower(strings.TrimPrefix(domain, "."))
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return nil
+		}
+	}
+	return violation("DNS name %q is outside the allowed domains", name)
+}
+
+func (p *Policy) ipAllowed(ip net.IP) bool {
+	for _, cidr := range p.AllowedIPNets {
+		if _, n, err := net.ParseCIDR(cidr); err == nil && n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// 小写的LDH标签，通配符只能出现在最左侧
+func validDNSName(name string, wildcard bool) bool {
+
+	if wildcard && strings.HasPrefix(name, "*.") {
+		name = name[2:]
+	}
+	if name == "" || len(name) > 253 {
+		return false
+	}
+	for _, label := range strings.Split(name, ".") {
+		if label == "" || len(label) > 63 || label[0] == '-' || label[len(label)-1] == '-' {
+			return false
+		}
+		for _, c := range label {
+			if !(c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '-') {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func hasSAN(template *x509.Certificate, name string) bool {
+
+	for _, dns := range template.DNSNames {
+		if strings.EqualFold(dns, name) {
+			return true
+		}
+	}
+	for _, ip := range template.IPAddresses {
+		if ip.String() == name {
+			return true
+		}
+	}
+	for _, email := range template.EmailAddresses {
+		if email == name {
+			return true
+		}
+	}
+	for _, uri := range template.URIs {
+		if uri.String() == name {
+			return true
+		}
+	}
+	return false
+}
+
+func containsEKU(list []x509.ExtKeyUsage, eku x509.ExtKeyUsage) bool {
+	for _, v := range list {
+		if v == eku {
+			return true
+		}
+	}
+	return false
+}
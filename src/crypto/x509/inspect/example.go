@@ -0,0 +1,373 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"flag"
+	"fmt"
+	x509Extra "github.com/zc2638/go-standard/src/crypto/x509/extra"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// 查看证书、证书请求、CRL与密钥，相当于openssl x509/req/crl/pkey -text
+//
+//	go run ./src/crypto/x509/inspect testdata/x509_cert.pem
+//	go run ./src/crypto/x509/inspect -json chain.pem
+//	go run ./src/crypto/x509/inspect -verify -roots root.pem -dns api.internal chain.pem
+//	go run ./src/crypto/x509/inspect -key testdata/x509_key.pem testdata/x509_cert.pem
+//
+// 支持PEM(可包含多个块)与DER编码；不带参数运行时演示
+func main() {
+
+	asJSON := flag.Bool("json", false, "以JSON格式输出")
+	verify := flag.Bool("verify", false, "以第一张证书为终端证书、其余为中间证书校验证书链")
+	rootsFile := flag.String("roots", "", "校验使用的根证书文件，为空时使用系统根证书")
+	dnsName := flag.String("dns", "", "校验时同时检查的主机名")
+	keyFile := flag.String("key", "", "检查私钥或公钥是否与第一张证书匹配")
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		Demo()
+		return
+	}
+	objects, err := load(flag.Args()...)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := Print(os.Stdout, objects, *asJSON); err != nil {
+		log.Fatal(err)
+	}
+
+	ok := true
+	if *verify {
+		ok = Verify(os.Stdout, objects, *rootsFile, *dnsName) && ok
+	}
+	if *keyFile != "" {
+		ok = MatchKey(os.Stdout, objects, *keyFile) && ok
+	}
+	if !ok {
+		os.Exit(1)
+	}
+}
+
+func load(files ...string) ([]x509Extra.Object, error) {
+	var objects []x509Extra.Object
+	for _, file := range files {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		objs, err := x509Extra.Inspect(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", file, err)
+		}
+		objects = append(objects, objs...)
+	}
+	return objects, nil
+}
+
+func certificates(objects []x509Extra.Object) []*x509.Certificate {
+	var certs []*x509.Certificate
+	for _, obj := range objects {
+		if obj.Cert != nil {
+			certs = append(certs, obj.Cert)
+		}
+	}
+	return certs
+}
+
+// 校验证书链并输出校验通过的链
+func Verify(w io.Writer, objects []x509Extra.Object, rootsFile, dnsName string) bool {
+
+	var roots []*x509.Certificate
+	if rootsFile != "" {
+		objs, err := load(rootsFile)
+		if err != nil {
+			fmt.Fprintln(w, "verify:", err)
+			return false
+		}
+		roots = certificates(objs)
+	}
+	chains, err := x509Extra.VerifyChain(certificates(objects), roots, dnsName, time.Time{})
+	if err != nil {
+		fmt.Fprintln(w, "verify: FAILED:", err)
+		return false
+	}
+	fmt.Fprintln(w, "verify: OK")
+	for i, chain := range chains {
+		fmt.Fprintf(w, "  chain %d:\n", i)
+		for depth, cert := range chain {
+			fmt.Fprintf(w, "    %d %s\n", depth, cert.Subject)
+		}
+	}
+	return true
+}
+
+// 检查密钥文件中的公钥是否与第一张证书匹配
+func MatchKey(w io.Writer, objects []x509Extra.Object, keyFile string) bool {
+
+	certs := certificates(objects)
+	if len(certs) == 0 {
+		fmt.Fprintln(w, "key: no certificate to compare with")
+		return false
+	}
+	keys, err := load(keyFile)
+	if err != nil {
+		fmt.Fprintln(w, "key:", err)
+		return false
+	}
+	for _, k := range keys {
+		if k.Key == nil {
+			continue
+		}
+		if x509Extra.KeyMatches(k.PublicKey, certs[0]) {
+			fmt.Fprintln(w, "key: matches certificate")
+			return true
+		}
+		fmt.Fprintln(w, "key: does NOT match certificate")
+		return false
+	}
+	fmt.Fprintln(w, "key: no key found in", keyFile)
+	return false
+}
+
+func Print(w io.Writer, objects []x509Extra.Object, asJSON bool) error {
+
+	if asJSON {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(objects)
+	}
+	for i, obj := range objects {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		switch {
+		case obj.Certificate != nil:
+			printCertificate(w, obj.Certificate)
+		case obj.CSR != nil:
+			printCSR(w, obj.CSR)
+		case obj.CRL != nil:
+			printCRL(w, obj.CRL)
+		case obj.Key != nil:
+			fmt.Fprintf(w, "Key (%s):\n", obj.Type)
+			printKey(w, "    ", obj.Key)
+		}
+	}
+	return nil
+}
+
+func printCertificate(w io.Writer, c *x509Extra.CertificateInfo) {
+
+	fmt.Fprintln(w, "Certificate:")
+	field(w, "Subject", c.Subject)
+	field(w, "Issuer", c.Issuer)
+	field(w, "Serial Number", c.Serial)
+	field(w, "Not Before", c.NotBefore.UTC().Format(time.RFC3339))
+	field(w, "Not After", c.NotAfter.UTC().Format(time.RFC3339)+" ("+remaining(c.NotAfter)+")")
+	field(w, "Signature Algorithm", c.SignatureAlgorithm)
+	fmt.Fprintln(w, "    Public Key:")
+	printKey(w, "        ", &c.PublicKey)
+	printSANs(w, c.DNSNames, c.IPAddresses, c.EmailAddresses, c.URIs)
+	basic := "CA:FALSE"
+	if c.IsCA {
+		basic = "CA:TRUE"
+		if c.MaxPathLen >= 0 {
+			basic += fmt.Sprintf(", pathlen:%d", c.MaxPathLen)
+		}
+	}
+	field(w, "Basic Constraints", basic)
+	field(w, "Key Usage", strings.Join(c.KeyUsage, ", "))
+	field(w, "Extended Key Usage", strings.Join(c.ExtKeyUsage, ", "))
+	field(w, "Subject Key ID", c.SubjectKeyID)
+	field(w, "Authority Key ID", c.AuthorityKeyID)
+	field(w, "OCSP", strings.Join(c.OCSPServer, ", "))
+	field(w, "CA Issuers", strings.Join(c.IssuingURL, ", "))
+	field(w, "CRL Distribution", strings.Join(c.CRLPoints, ", "))
+	if c.SelfSigned {
+		field(w, "Self-signed", "yes")
+	}
+	printExtensions(w, c.Extensions)
+	fmt.Fprintln(w, "    Fingerprints:")
+	fmt.Fprintf(w, "        SHA-1:   %s\n", c.SHA1)
+	fmt.Fprintf(w, "        SHA-256: %s\n", c.SHA256)
+}
+
+func printCSR(w io.Writer, c *x509Extra.CSRInfo) {
+	fmt.Fprintln(w, "Certificate Request:")
+	field(w, "Subject", c.Subject)
+	field(w, "Signature Algorithm", c.SignatureAlgorithm)
+	valid := "valid"
+	if !c.SignatureValid {
+		valid = "INVALID"
+	}
+	field(w, "Signature", valid)
+	fmt.Fprintln(w, "    Public Key:")
+	printKey(w, "        ", &c.PublicKey)
+	printSANs(w, c.DNSNames, c.IPAddresses, c.EmailAddresses, c.URIs)
+	printExtensions(w, c.Extensions)
+}
+
+func printCRL(w io.Writer, c *x509Extra.CRLInfo) {
+	fmt.Fprintln(w, "Certificate Revocation List:")
+	field(w, "Issuer", c.Issuer)
+	field(w, "CRL Number", c.Number)
+	field(w, "This Update", c.ThisUpdate.UTC().Format(time.RFC3339))
+	field(w, "Next Update", c.NextUpdate.UTC().Format(time.RFC3339)+" ("+remaining(c.NextUpdate)+")")
+	field(w, "Signature Algorithm", c.SignatureAlgorithm)
+	field(w, "Authority Key ID", c.AuthorityKeyID)
+	fmt.Fprintf(w, "    Revoked Certificates: %d\n", len(c.Revoked))
+	for _, r := range c.Revoked {
+		fmt.Fprintf(w, "        %s  %s  %s\n", r.Serial, r.RevokedAt.UTC().Format(time.RFC3339), r.Reason)
+	}
+}
+
+func printKey(w io.Writer, indent string, k *x509Extra.KeyInfo) {
+	desc := k.Algorithm
+	if k.Bits > 0 {
+		desc += fmt.Sprintf(" %d bit", k.Bits)
+	}
+	if k.Curve != "" {
+		desc += " (" + k.Curve + ")"
+	}
+	fmt.Fprintf(w, "%sType: %s\n", indent, desc)
+	fmt.Fprintf(w, "%sSPKI SHA-256: %s\n", indent, k.SPKISHA256)
+}
+
+func printSANs(w io.Writer, dns, ips, emails, uris []string) {
+	var sans []string
+	for _, group := range []struct {
+		prefix string
+		values []string
+	}{{"DNS", dns}, {"IP", ips}, {"email", emails}, {"URI", uris}} {
+		for _, v := range group.values {
+			sans = append(sans, group.prefix+":"+v)
+		}
+	}
+	field(w, "Subject Alt Names", strings.Join(sans, ", "))
+}
+
+func printExtensions(w io.Writer, exts []x509Extra.ExtensionInfo) {
+	if len(exts) == 0 {
+		return
+	}
+	fmt.Fprintln(w, "    Extensions:")
+	for _, ext := range exts {
+		name := ext.Name
+		if name == "" {
+			name = "unknown"
+		}
+		critical := ""
+		if ext.Critical {
+			critical = " critical"
+		}
+		fmt.Fprintf(w, "        %-24s %s%s\n", ext.OID, name, critical)
+	}
+}
+
+// 空值不输出
+func field(w io.Writer, name, value string) {
+	if value != "" {
+		fmt.Fprintf(w, "    %-20s %s\n", name+":", value)
+	}
+}
+
+func remaining(t time.Time) string {
+	d := time.Until(t)
+	if d < 0 {
+		return fmt.Sprintf("expired %d days ago", int(-d.Hours()/24))
+	}
+	return fmt.Sprintf("expires in %d days", int(d.Hours()/24))
+}
+
+func Demo() {
+
+	// 仓库中的示例证书与私钥
+	objects, err := load("testdata/x509_cert.pem")
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := Print(os.Stdout, objects, false); err != nil {
+		log.Fatal(err)
+	}
+	MatchKey(os.Stdout, objects, "testdata/x509_key.pem")
+	MatchKey(os.Stdout, objects, "testdata/rsa_private_pkcs1.pem")
+	fmt.Println()
+
+	// 使用CA签发一条证书链，并查看证书请求、证书链与CRL
+	dir, err := ioutil.TempDir("", "inspect-example")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	db, err := x509Extra.OpenDB(filepath.Join(dir, "db.json"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	rootKey, _ := x509Extra.GenerateKey("p384")
+	root, err := x509Extra.NewRootCA(pkix.Name{CommonName: "Inspect Root CA"}, rootKey, 24*time.Hour, 0, db)
+	if err != nil {
+		log.Fatal(err)
+	}
+	key, _ := x509Extra.GenerateKey("rsa2048")
+	der, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+		Subject:  pkix.Name{CommonName: "api.internal"},
+		DNSNames: []string{"api.internal"},
+	}, key)
+	if err != nil {
+		log.Fatal(err)
+	}
+	csr, err := x509.ParseCertificateRequest(der)
+	if err != nil {
+		log.Fatal(err)
+	}
+	leaf, err := root.SignCSR(csr, x509Extra.LeafOptions{Validity: time.Hour})
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := root.Revoke(leaf.SerialNumber, x509Extra.Superseded); err != nil {
+		log.Fatal(err)
+	}
+	crl, err := root.CreateCRL(time.Hour)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	files := map[string][]byte{
+		"request.csr": x509Extra.EncodeCSR(csr),
+		"chain.pem":   root.Bundle(leaf),
+		"root.pem":    x509Extra.EncodeCertificates(root.Cert),
+		"ca.crl":      crl,
+	}
+	for name, data := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), data, 0644); err != nil {
+			log.Fatal(err)
+		}
+	}
+	objects, err = load(filepath.Join(dir, "request.csr"), filepath.Join(dir, "chain.pem"), filepath.Join(dir, "ca.crl"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := Print(os.Stdout, objects, false); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println()
+	Verify(os.Stdout, objects, filepath.Join(dir, "root.pem"), "api.internal")
+	Verify(os.Stdout, objects, filepath.Join(dir, "root.pem"), "www.example.com")
+	// 不指定根证书时使用系统根证书
+	Verify(os.Stdout, objects, "", "")
+
+	// JSON输出
+	objects, _ = load(filepath.Join(dir, "root.pem"))
+	if err := Print(os.Stdout, objects, true); err != nil {
+		log.Fatal(err)
+	}
+}
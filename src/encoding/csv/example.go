@@ -3,9 +3,14 @@ package main
 import (
 	"bytes"
 	"encoding/csv"
+	"errors"
 	"fmt"
+	"github.com/zc2638/go-standard/src/encoding/csv/extra"
 	"io"
 	"log"
+	"os"
+	"strings"
+	"time"
 )
 
 // csv读写逗号分隔值（csv）的文件
@@ -52,4 +57,139 @@ func main() {
 		log.Fatal(err)
 	}
 	fmt.Println(records)
+
+	// 按结构体标签解码与编码
+	StructDecode()
+	StructEncode()
+	// 逐行流式处理
+	Stream()
+}
+
+// 自定义类型通过TextUnmarshaler/TextMarshaler转换
+type Level int
+
+func (l *Level) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "low":
+		*l = 1
+	case "high":
+		*l = 2
+	default:
+		return fmt.Errorf("unknown level %q", text)
+	}
+	return nil
+}
+
+func (l Level) MarshalText() ([]byte, error) {
+	return []byte(map[Level]string{1: "low", 2: "high"}[l]), nil
+}
+
+type Product struct {
+	ID       int       `csv:"id,required"`
+	Name     string    `csv:"name"`
+	Price    *float64  `csv:"price"`
+	InStock  bool      `csv:"in_stock"`
+	Released time.Time `csv:"released" layout:"2006-01-02"`
+	Level    Level     `csv:"level"`
+	// 只在程序内部使用的字段
+	Internal string `csv:"-"`
+}
+
+const products = `id,name,price,in_stock,released,level
+1,Keyboard,49.9,true,2019-03-01,low
+2,"Monitor, 27""",,false,2020-11-20,high
+`
+
+func StructDecode() {
+
+	// 按列名映射，列的顺序无关
+	var list []Product
+	if err := extra.Unmarshal([]byte(products), &list); err != nil {
+		log.Fatal(err)
+	}
+	for _, p := range list {
+		fmt.Printf("%d %s price=%v in_stock=%t released=%s level=%d\n",
+			p.ID, p.Name, p.Price != nil, p.InStock, p.Released.Format("2006-01-02"), p.Level)
+	}
+
+	// 错误中包含行号与列号
+	bad := products + "3,Mouse,abc,true,2021-01-01,low\n"
+	var errList []Product
+	err := extra.Unmarshal([]byte(bad), &errList)
+	var decodeErr *extra.DecodeError
+	if errors.As(err, &decodeErr) {
+		fmt.Println(err, "| line", decodeErr.Line, "column", decodeErr.Column)
+	}
+
+	// 没有表头时按列序号映射，分隔符在csv.Reader上设置
+	type Point struct {
+		X     float64 `csv:",index=0"`
+		Y     float64 `csv:",index=1"`
+		Label string  `csv:",index=3"`
+	}
+	r := csv.NewReader(strings.NewReader("1.5;2;ignored;a\n3;4.25;ignored;b\n"))
+	r.Comma = ';'
+	dec := extra.NewDecoder(r)
+	dec.NoHeader = true
+	var points []Point
+	var pt Point
+	if err := dec.Each(&pt, func() error {
+		points = append(points, pt)
+		return nil
+	}); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(points)
+}
+
+func StructEncode() {
+
+	price := 12.5
+	list := []Product{
+		{ID: 1, Name: "Cable", Price: &price, InStock: true, Released: time.Date(2021, 5, 1, 0, 0, 0, 0, time.UTC), Level: 1},
+		{ID: 2, Name: "Dock, USB-C", Level: 2},
+	}
+	data, err := extra.Marshal(list)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Print(string(data))
+
+	// 直接写入io.Writer
+	enc := extra.NewEncoder(csv.NewWriter(os.Stdout))
+	for _, p := range list {
+		if err := enc.Encode(p); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if err := enc.Flush(); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func Stream() {
+
+	// 大文件逐行解码后通过channel交给处理方，不需要全部读入内存
+	var buf bytes.Buffer
+	buf.WriteString("id,name,price\n")
+	for i := 1; i <= 1000; i++ {
+		fmt.Fprintf(&buf, "%d,item-%d,%d.5\n", i, i, i)
+	}
+	dec := extra.NewDecoder(csv.NewReader(&buf))
+	ch := make(chan *Product, 16)
+	errc := make(chan error, 1)
+	go func() {
+		errc <- dec.Stream(ch)
+	}()
+
+	var count int
+	var total float64
+	for p := range ch {
+		count++
+		total += *p.Price
+	}
+	if err := <-errc; err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println("rows:", count, "total:", total)
 }
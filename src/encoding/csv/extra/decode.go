@@ -0,0 +1,344 @@
+package extra
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 单元格转换失败，Line与Column均从1开始，Column为列序号
+type DecodeError struct {
+	Line   int
+	Column int
+	// 列名，没有表头时为结构体字段名
+	Field string
+	Value string
+	Err   error
+}
+
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("csv: line %d, column %d (%s): cannot decode %q: %v", e.Line, e.Column, e.Field, e.Value, e.Err)
+}
+
+var ErrMissingColumn = errors.New("csv: required column is missing")
+
+// 逐行把CSV记录解码为结构体
+// 默认第一行为表头，按列名映射字段，表头中没有该列名时使用index标签
+// NoHeader为true时按与Encoder相同的列布局映射
+//
+//	dec := extra.NewDecoder(csv.NewReader(file))
+//	for {
+//		var row Row
+//		if err := dec.Decode(&row); err == io.EOF {
+//			break
+//		} else if err != nil {
+//			return err
+//		}
+//	}
+type Decoder struct {
+	NoHeader bool
+
+	r      *csv.Reader
+	header []string
+	// 每种结构体类型对应的列序号，列不存在时为-1
+	columns map[reflect.Type][]int
+}
+
+// 分隔符等读取选项在r上设置
+func NewDecoder(r *csv.Reader) *Decoder {
+	return &Decoder{r: r, columns: make(map[reflect.Type][]int)}
+}
+
+// 表头，首次调用Decode之后可用
+func (d *Decoder) Header() []string {
+	return d.header
+}
+
+// 把下一行解码到v，v必须是指向结构体的指针，没有更多记录时返回io.EOF
+func (d *Decoder) Decode(v interface{}) error {
+
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return errors.New("csv: Decode requires a non-nil pointer to a struct")
+	}
+	if err := d.readHeader(); err != nil {
+		return err
+	}
+	record, err := d.r.Read()
+	if err != nil {
+		return err
+	}
+	return d.decodeRecord(record, rv.Elem())
+}
+
+// 逐行解码到v并调用fn，v在每行解码前被重置为零值
+// fn返回错误时停止并返回该错误，读取结束时返回nil
+func (d *Decoder) Each(v interface{}, fn func() error) error {
+
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return errors.New("csv: Each requires a non-nil pointer to a struct")
+	}
+	zero := reflect.Zero(rv.Elem().Type())
+	for {
+		rv.Elem().Set(zero)
+		err := d.Decode(v)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := fn(); err != nil {
+			return err
+		}
+	}
+}
+
+// 逐行解码并发送到ch，ch的类型为chan T或chan *T，T为结构体
+// 结束后关闭ch，发生错误时停止并返回错误
+//
+//	ch := make(chan Row)
+//	go func() { errc <- dec.Stream(ch) }()
+//	for row := range ch {}
+func (d *Decoder) Stream(ch interface{}) error {
+
+	cv := reflect.ValueOf(ch)
+	if cv.Kind() != reflect.Chan || cv.Type().ChanDir()&reflect.SendDir == 0 {
+		return errors.New("csv: Stream requires a channel")
+	}
+	defer cv.Close()
+	elem := cv.Type().Elem()
+	isPtr := elem.Kind() == reflect.Ptr
+	st, err := structType(elem)
+	if err != nil {
+		return err
+	}
+	for {
+		row := reflect.New(st)
+		err := d.Decode(row.Interface())
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if isPtr {
+			cv.Send(row)
+		} else {
+			cv.Send(row.Elem())
+		}
+	}
+}
+
+func (d *Decoder) readHeader() error {
+
+	if d.NoHeader || d.header != nil {
+		return nil
+	}
+	header, err := d.r.Read()
+	if err != nil {
+		return err
+	}
+	// Excel导出的UTF-8文件以BOM开头
+	if len(header) > 0 {
+		header[0] = strings.TrimPrefix(header[0], "\ufeff")
+	}
+	d.header = make([]string, len(header))
+	for i, h := range header {
+		d.header[i] = strings.TrimSpace(h)
+	}
+	return nil
+}
+
+// 计算结构体字段对应的列序号
+func (d *Decoder) columnsFor(t reflect.Type, fields []field) ([]int, error) {
+
+	if cols, ok := d.columns[t]; ok {
+		return cols, nil
+	}
+	if d.NoHeader {
+		cols, err := columnLayout(fields)
+		if err != nil {
+			return nil, err
+		}
+		d.columns[t] = cols
+		return cols, nil
+	}
+	cols := make([]int, len(fields))
+	for i, f := range fields {
+		cols[i] = -1
+		// 先精确匹配列名，再忽略大小写匹配
+		for j, h := range d.header {
+			if h == f.name {
+				cols[i] = j
+				break
+			}
+		}
+		for j, h := range d.header {
+			if cols[i] < 0 && strings.EqualFold(h, f.name) {
+				cols[i] = j
+			}
+		}
+		// 表头中没有该列名时使用index标签
+		if cols[i] < 0 && f.index >= 0 {
+			cols[i] = f.index
+		}
+		if cols[i] < 0 && f.required {
+			return nil, fmt.Errorf("%v: %s", ErrMissingColumn, f.name)
+		}
+	}
+	d.columns[t] = cols
+	return cols, nil
+}
+
+func (d *Decoder) decodeRecord(record []string, rv reflect.Value) error {
+
+	t, err := structType(rv.Type())
+	if err != nil {
+		return err
+	}
+	fields, err := cachedFields(t)
+	if err != nil {
+		return err
+	}
+	cols, err := d.columnsFor(t, fields)
+	if err != nil {
+		return err
+	}
+	for i, f := range fields {
+		col := cols[i]
+		value := ""
+		if col >= 0 && col < len(record) {
+			value = record[col]
+		}
+		var err error
+		if value == "" && f.required {
+			err = errors.New("value is required")
+		} else if fv, ok := fieldByPath(rv, f.path, value != ""); ok {
+			// 值不为空时为途经的nil匿名结构体指针分配内存
+			err = setValue(fv, value, f.layout)
+		}
+		if err != nil {
+			line, _ := d.r.FieldPos(0)
+			if col >= 0 && col < len(record) {
+				line, _ = d.r.FieldPos(col)
+			}
+			return &DecodeError{Line: line, Column: col + 1, Field: f.name, Value: value, Err: err}
+		}
+	}
+	return nil
+}
+
+// 空值对应零值，指针字段为nil
+func setValue(v reflect.Value, s, layout string) error {
+
+	if v.Kind() == reflect.Ptr {
+		if s == "" {
+			v.Set(reflect.Zero(v.Type()))
+			return nil
+		}
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return setValue(v.Elem(), s, layout)
+	}
+	if v.CanAddr() && v.Addr().Type().Implements(textUnmarshalerType) && v.Type() != timeType {
+		if s == "" {
+			v.Set(reflect.Zero(v.Type()))
+			return nil
+		}
+		return v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s))
+	}
+	if s == "" {
+		v.Set(reflect.Zero(v.Type()))
+		return nil
+	}
+
+	switch v.Type() {
+	case timeType:
+		t, err := time.Parse(layout, s)
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(t))
+		return nil
+	case reflect.TypeOf(time.Duration(0)):
+		dur, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(dur))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(strings.TrimSpace(s), 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(strings.TrimSpace(s), 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(strings.TrimSpace(s), v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(n)
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
+
+// 解码全部记录，v必须是指向结构体切片(或结构体指针切片)的指针
+func Unmarshal(data []byte, v interface{}) error {
+
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Slice {
+		return errors.New("csv: Unmarshal requires a pointer to a slice")
+	}
+	slice := rv.Elem()
+	elem := slice.Type().Elem()
+	st, err := structType(elem)
+	if err != nil {
+		return err
+	}
+	dec := NewDecoder(csv.NewReader(bytes.NewReader(data)))
+	for {
+		row := reflect.New(st)
+		err := dec.Decode(row.Interface())
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if elem.Kind() == reflect.Ptr {
+			slice.Set(reflect.Append(slice, row))
+		} else {
+			slice.Set(reflect.Append(slice, row.Elem()))
+		}
+	}
+}
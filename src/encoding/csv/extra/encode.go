@@ -0,0 +1,158 @@
+package extra
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+// 把结构体逐行编码为CSV记录，首次编码时写入表头
+// 设置了index标签的字段写在对应的列，其余字段按声明顺序填入空余的列，没有字段的列为空
+type Encoder struct {
+	NoHeader bool
+
+	w           *csv.Writer
+	wroteHeader bool
+}
+
+// 分隔符等写入选项在w上设置
+func NewEncoder(w *csv.Writer) *Encoder {
+	return &Encoder{w: w}
+}
+
+// 编码一行，v为结构体或指向结构体的指针
+func (e *Encoder) Encode(v interface{}) error {
+
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return errors.New("csv: Encode of nil pointer")
+		}
+		rv = rv.Elem()
+	}
+	t, err := structType(rv.Type())
+	if err != nil {
+		return err
+	}
+	fields, err := cachedFields(t)
+	if err != nil {
+		return err
+	}
+	cols, err := columnLayout(fields)
+	if err != nil {
+		return err
+	}
+	width := 0
+	for _, col := range cols {
+		if col >= width {
+			width = col + 1
+		}
+	}
+	if !e.NoHeader && !e.wroteHeader {
+		header := make([]string, width)
+		for i, f := range fields {
+			header[cols[i]] = f.name
+		}
+		if err := e.w.Write(header); err != nil {
+			return err
+		}
+		e.wroteHeader = true
+	}
+
+	record := make([]string, width)
+	for i, f := range fields {
+		// 途经nil的匿名结构体指针时输出为空
+		fv, ok := fieldByPath(rv, f.path, false)
+		if !ok || f.omitEmpty && isZero(fv) {
+			continue
+		}
+		if record[cols[i]], err = formatValue(fv, f.layout); err != nil {
+			return fmt.Errorf("csv: field %s: %v", f.name, err)
+		}
+	}
+	return e.w.Write(record)
+}
+
+// 编码切片中的全部元素
+func (e *Encoder) EncodeAll(slice interface{}) error {
+	rv := reflect.ValueOf(slice)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return errors.New("csv: EncodeAll requires a slice")
+	}
+	for i := 0; i < rv.Len(); i++ {
+		if err := e.Encode(rv.Index(i).Interface()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// 将缓存写入底层的io.Writer并返回写入错误
+func (e *Encoder) Flush() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+// 编码全部记录，v为结构体切片或结构体指针切片
+func Marshal(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := NewEncoder(csv.NewWriter(&buf))
+	if err := enc.EncodeAll(v); err != nil {
+		return nil, err
+	}
+	if err := enc.Flush(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func formatValue(v reflect.Value, layout string) (string, error) {
+
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return "", nil
+		}
+		v = v.Elem()
+	}
+	switch v.Type() {
+	case timeType:
+		t := v.Interface().(time.Time)
+		if t.IsZero() {
+			return "", nil
+		}
+		return t.Format(layout), nil
+	case reflect.TypeOf(time.Duration(0)):
+		return time.Duration(v.Int()).String(), nil
+	}
+	if v.Type().Implements(textMarshalerType) {
+		text, err := v.Interface().(encoding.TextMarshaler).MarshalText()
+		return string(text), err
+	}
+	if v.CanAddr() && v.Addr().Type().Implements(textMarshalerType) {
+		text, err := v.Addr().Interface().(encoding.TextMarshaler).MarshalText()
+		return string(text), err
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, v.Type().Bits()), nil
+	}
+	return "", fmt.Errorf("unsupported type %s", v.Type())
+}
+
+func isZero(v reflect.Value) bool {
+	return reflect.DeepEqual(v.Interface(), reflect.Zero(v.Type()).Interface())
+}
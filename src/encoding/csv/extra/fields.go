@@ -0,0 +1,182 @@
+package extra
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 结构体字段与CSV列的对应关系，由标签决定
+//
+//	type Row struct {
+//		ID      int        `csv:"id,required"`
+//		Name    string     `csv:"name"`
+//		Price   *float64   `csv:"price"`                         // 空值对应nil
+//		Created time.Time  `csv:"created" layout:"2006-01-02"`   // 默认布局为RFC3339
+//		Note    string     `csv:",index=5"`                      // 按列序号(从0开始)
+//		Secret  string     `csv:"-"`                             // 忽略
+//	}
+//
+// 标签选项: required表示列必须存在且不为空，omitempty表示编码时零值输出为空
+// 匿名的结构体及结构体指针字段会展开，编码时nil指针中的字段输出为空，解码时按需分配
+type field struct {
+	name      string
+	index     int
+	path      []int
+	typ       reflect.Type
+	layout    string
+	required  bool
+	omitEmpty bool
+}
+
+var (
+	fieldCache sync.Map
+	timeType   = reflect.TypeOf(time.Time{})
+	// 实现了TextUnmarshaler/TextMarshaler的类型使用文本编码
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	textMarshalerType   = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// 解析结构体的字段，匿名结构体字段会展开
+func cachedFields(t reflect.Type) ([]field, error) {
+
+	if f, ok := fieldCache.Load(t); ok {
+		return f.([]field), nil
+	}
+	fields, err := typeFields(t, nil, map[reflect.Type]bool{t: true})
+	if err != nil {
+		return nil, err
+	}
+	fieldCache.Store(t, fields)
+	return fields, nil
+}
+
+// seen为正在展开的结构体类型，避免递归嵌入时无限展开
+func typeFields(t reflect.Type, parent []int, seen map[reflect.Type]bool) ([]field, error) {
+
+	var fields []field
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag, hasTag := sf.Tag.Lookup("csv")
+		if tag == "-" {
+			continue
+		}
+		path := append(append([]int(nil), parent...), i)
+		if et := sf.Type; sf.Anonymous && !hasTag {
+			isPtr := et.Kind() == reflect.Ptr
+			if isPtr {
+				et = et.Elem()
+			}
+			if et.Kind() == reflect.Struct && et != timeType {
+				// 未导出类型的指针无法在解码时分配
+				if isPtr && sf.PkgPath != "" || seen[et] {
+					continue
+				}
+				seen[et] = true
+				embedded, err := typeFields(et, path, seen)
+				delete(seen, et)
+				if err != nil {
+					return nil, err
+				}
+				fields = append(fields, embedded...)
+				continue
+			}
+		}
+		// 未导出字段无法赋值
+		if sf.PkgPath != "" {
+			continue
+		}
+
+		f := field{name: sf.Name, index: -1, path: path, typ: sf.Type, layout: sf.Tag.Get("layout")}
+		parts := strings.Split(tag, ",")
+		if parts[0] != "" {
+			f.name = parts[0]
+		}
+		for _, opt := range parts[1:] {
+			switch {
+			case opt == "required":
+				f.required = true
+			case opt == "omitempty":
+				f.omitEmpty = true
+			case strings.HasPrefix(opt, "index="):
+				n, err := strconv.Atoi(strings.TrimPrefix(opt, "index="))
+				if err != nil || n < 0 {
+					return nil, fmt.Errorf("csv: invalid index in tag of field %s.%s", t.Name(), sf.Name)
+				}
+				f.index = n
+			default:
+				return nil, fmt.Errorf("csv: unknown tag option %q on field %s.%s", opt, t.Name(), sf.Name)
+			}
+		}
+		if f.layout == "" {
+			f.layout = time.RFC3339
+		}
+		fields = append(fields, f)
+	}
+	return fields, nil
+}
+
+// 字段对应的列序号: 设置了index的字段使用该序号，其余字段按声明顺序填入未被占用的列
+// 编码与无表头的解码使用同一布局，index重复时报错
+func columnLayout(fields []field) ([]int, error) {
+
+	cols := make([]int, len(fields))
+	used := make(map[int]string)
+	for i, f := range fields {
+		if f.index < 0 {
+			continue
+		}
+		if name, ok := used[f.index]; ok {
+			return nil, fmt.Errorf("csv: fields %s and %s have the same index", name, f.name)
+		}
+		used[f.index] = f.name
+		cols[i] = f.index
+	}
+	next := 0
+	for i, f := range fields {
+		if f.index >= 0 {
+			continue
+		}
+		for ; ; next++ {
+			if _, ok := used[next]; !ok {
+				break
+			}
+		}
+		used[next] = f.name
+		cols[i] = next
+	}
+	return cols, nil
+}
+
+// 按路径取字段，途经的nil指针在alloc为true时分配，否则返回false
+func fieldByPath(v reflect.Value, path []int, alloc bool) (reflect.Value, bool) {
+
+	for i, x := range path {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				if !alloc {
+					return reflect.Value{}, false
+				}
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, true
+}
+
+// v必须是结构体或指向结构体的指针
+func structType(t reflect.Type) (reflect.Type, error) {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("csv: %s is not a struct", t)
+	}
+	return t, nil
+}
@@ -1,10 +1,15 @@
 package main
 
 import (
+	"bytes"
 	"encoding/hex"
 	"fmt"
+	"github.com/zc2638/go-standard/src/hash/adler32/extra"
 	"hash/adler32"
+	"io/ioutil"
 	"log"
+	"math/rand"
+	"os"
 )
 
 // adler32包实现了Adler-32校验和算法
@@ -27,4 +32,96 @@ func main() {
 	}
 	hb := h.Sum(nil)
 	fmt.Println(hex.EncodeToString(hb))
-}
\ No newline at end of file
+
+	// 滚动Adler-32
+	Rolling()
+	// 基于内容的分块与去重存储
+	Dedup()
+}
+
+func Rolling() {
+
+	data := make([]byte, 4096)
+	rand.New(rand.NewSource(1)).Read(data)
+
+	// 窗口每滑动一个字节，滚动结果都与对窗口重新计算的校验和一致
+	const window = 64
+	r := extra.NewRollingAdler32(window)
+	match := true
+	for i, b := range data {
+		sum := r.Roll(b)
+		if i >= window-1 && sum != adler32.Checksum(data[i-window+1:i+1]) {
+			match = false
+		}
+	}
+	fmt.Println("滚动Adler-32与Checksum一致: ", match)
+}
+
+func Dedup() {
+
+	dir, err := ioutil.TempDir("", "chunk-store")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	store, err := extra.NewStore(dir)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// 示例使用较小的块，实际备份可以使用extra.DefaultOptions
+	opts := extra.Options{MinSize: 16 << 10, AvgSize: 64 << 10, MaxSize: 256 << 10, Window: 64, Hash: extra.Buzhash32}
+
+	// 模拟一个8MB的磁盘镜像
+	v1 := make([]byte, 8<<20)
+	rand.New(rand.NewSource(2)).Read(v1)
+	_, stats, err := store.Backup("disk-v1.img", bytes.NewReader(v1), opts)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("v1: %d块, 新增%d块, 写入%d/%d字节\n", stats.Chunks, stats.NewChunks, stats.NewBytes, stats.Bytes)
+
+	// 修改中间100字节，并在开头附近插入10字节，后续数据整体偏移
+	v2 := append([]byte{}, v1[:1000]...)
+	v2 = append(v2, []byte("0123456789")...)
+	v2 = append(v2, v1[1000:]...)
+	copy(v2[4<<20:], bytes.Repeat([]byte{0xff}, 100))
+	_, stats, err = store.Backup("disk-v2.img", bytes.NewReader(v2), opts)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("v2: %d块, 新增%d块, 写入%d/%d字节\n", stats.Chunks, stats.NewChunks, stats.NewBytes, stats.Bytes)
+
+	// 恢复并校验
+	var buf bytes.Buffer
+	if _, err := store.Restore("disk-v2.img", &buf); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println("v2恢复一致: ", bytes.Equal(buf.Bytes(), v2))
+
+	// 对比: 固定大小分块时，插入数据会使之后所有的块都发生变化
+	fixed := extra.Options{MinSize: 64 << 10, AvgSize: 64 << 10, MaxSize: 64 << 10, Window: 64}
+	fixedStore, err := extra.NewStore(dir + "/fixed")
+	if err != nil {
+		log.Fatal(err)
+	}
+	fixedStore.Backup("disk-v1.img", bytes.NewReader(v1), fixed)
+	_, stats, err = fixedStore.Backup("disk-v2.img", bytes.NewReader(v2), fixed)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("固定分块v2: %d块, 新增%d块\n", stats.Chunks, stats.NewChunks)
+
+	// 使用滚动Adler-32判断边界
+	opts.Hash = extra.RollingAdler
+	adlerStore, err := extra.NewStore(dir + "/adler32")
+	if err != nil {
+		log.Fatal(err)
+	}
+	adlerStore.Backup("disk-v1.img", bytes.NewReader(v1), opts)
+	_, stats, err = adlerStore.Backup("disk-v2.img", bytes.NewReader(v2), opts)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("Adler-32 v2: %d块, 新增%d块\n", stats.Chunks, stats.NewChunks)
+}
@@ -0,0 +1,165 @@
+package extra
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+)
+
+// 滚动哈希算法
+const (
+	Buzhash32    = "buzhash"
+	RollingAdler = "adler32"
+)
+
+// 分块参数，切分概率为 1/2^k，2^k为不小于AvgSize-MinSize的最小2的幂
+type Options struct {
+	MinSize int
+	AvgSize int
+	MaxSize int
+	// 滚动窗口大小，为0时使用64字节
+	Window int
+	// 滚动哈希算法，为空时使用Buzhash32
+	Hash string
+}
+
+// 适合备份虚拟机镜像等大文件的默认参数: 最小256KB，平均1MB，最大4MB
+var DefaultOptions = Options{
+	MinSize: 256 << 10,
+	AvgSize: 1 << 20,
+	MaxSize: 4 << 20,
+	Window:  64,
+	Hash:    Buzhash32,
+}
+
+// 块ID为内容的SHA-256
+type ID [sha256.Size]byte
+
+func (id ID) String() string {
+	return hex.EncodeToString(id[:])
+}
+
+func ParseID(s string) (ID, error) {
+	var id ID
+	b, err := hex.DecodeString(s)
+	if err != nil || len(b) != len(id) {
+		return id, errors.New("chunk: invalid id")
+	}
+	copy(id[:], b)
+	return id, nil
+}
+
+func (id ID) MarshalText() ([]byte, error) {
+	return []byte(id.String()), nil
+}
+
+func (id *ID) UnmarshalText(text []byte) error {
+	v, err := ParseID(string(text))
+	if err != nil {
+		return err
+	}
+	*id = v
+	return nil
+}
+
+type Chunk struct {
+	ID     ID
+	Offset int64
+	Data   []byte
+}
+
+// 基于内容的分块(CDC): 当窗口哈希混合后的低k位全为1时切分，k = log2(AvgSize - MinSize)
+// 边界只取决于窗口内的内容，文件中间插入或删除数据只会影响附近的块，其余块仍可去重
+type Chunker struct {
+	r      *bufio.Reader
+	opts   Options
+	roller Roller
+	mask   uint32
+	offset int64
+	buf    []byte
+}
+
+func NewChunker(r io.Reader, opts Options) (*Chunker, error) {
+
+	if opts.Window == 0 {
+		opts.Window = 64
+	}
+	if opts.Window < 0 {
+		return nil, errors.New("chunk: window must be positive")
+	}
+	if opts.MinSize <= 0 || opts.AvgSize < opts.MinSize || opts.MaxSize < opts.AvgSize {
+		return nil, errors.New("chunk: sizes must satisfy 0 < min <= avg <= max")
+	}
+	if opts.Window > opts.MinSize {
+		return nil, errors.New("chunk: window must not be larger than min size")
+	}
+
+	var roller Roller
+	switch opts.Hash {
+	case "", Buzhash32:
+		roller = NewBuzhash(opts.Window)
+	case RollingAdler:
+		roller = NewRollingAdler32(opts.Window)
+	default:
+		return nil, errors.New("chunk: unknown rolling hash " + opts.Hash)
+	}
+
+	// 最小块之后才开始判断边界，期望块大小约为 MinSize + AvgSize，这里从掩码中扣除MinSize的贡献
+	bits := uint(0)
+	for 1<<bits < opts.AvgSize-opts.MinSize {
+		bits++
+	}
+	return &Chunker{
+		r:      bufio.NewReaderSize(r, 64<<10),
+		opts:   opts,
+		roller: roller,
+		mask:   1<<bits - 1,
+		buf:    make([]byte, 0, opts.MaxSize),
+	}, nil
+}
+
+// 返回下一个块，数据读完时返回io.EOF。返回的Data在下次调用前有效，需要保留时请复制
+func (c *Chunker) Next() (*Chunk, error) {
+
+	c.buf = c.buf[:0]
+	c.roller.Reset()
+	for len(c.buf) < c.opts.MaxSize {
+		b, err := c.r.ReadByte()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		c.buf = append(c.buf, b)
+
+		// 最小块之前的最后一个窗口也要计算，保证判断边界时窗口已填满
+		if len(c.buf) < c.opts.MinSize-c.opts.Window {
+			continue
+		}
+		h := mix(c.roller.Roll(b))
+		if len(c.buf) >= c.opts.MinSize && h&c.mask == c.mask {
+			break
+		}
+	}
+	if len(c.buf) == 0 {
+		return nil, io.EOF
+	}
+
+	chunk := &Chunk{ID: sha256.Sum256(c.buf), Offset: c.offset, Data: c.buf}
+	c.offset += int64(len(c.buf))
+	return chunk, nil
+}
+
+// 窗口较小时Adler-32的s1最多只有十几位有效值，直接取低位会导致永远遇不到边界
+// 判断前使用MurmurHash3的fmix32打散各位
+func mix(h uint32) uint32 {
+	h ^= h >> 16
+	h *= 0x85ebca6b
+	h ^= h >> 13
+	h *= 0xc2b2ae35
+	h ^= h >> 16
+	return h
+}
@@ -0,0 +1,111 @@
+package extra
+
+// 滚动哈希: 在固定大小的窗口上滑动，每移入一个字节以O(1)代价得到新窗口的哈希值
+type Roller interface {
+	// 清空窗口，等同于窗口内全部为0
+	Reset()
+	// 移入一个字节，移出窗口最早的字节，返回当前窗口的哈希值
+	Roll(b byte) uint32
+}
+
+const mod = 65521
+
+// 滚动Adler-32，Sum32始终等于adler32.Checksum(当前窗口)
+type RollingAdler32 struct {
+	window []byte
+	pos    int
+	s1, s2 uint32
+}
+
+func NewRollingAdler32(window int) *RollingAdler32 {
+	r := &RollingAdler32{window: make([]byte, window)}
+	r.Reset()
+	return r
+}
+
+func (r *RollingAdler32) Reset() {
+	for i := range r.window {
+		r.window[i] = 0
+	}
+	r.pos = 0
+	// n个0字节的Adler-32: s1 = 1, s2 = n
+	r.s1 = 1
+	r.s2 = uint32(len(r.window)) % mod
+}
+
+func (r *RollingAdler32) Roll(b byte) uint32 {
+
+	out := uint32(r.window[r.pos])
+	r.window[r.pos] = b
+	r.pos = (r.pos + 1) % len(r.window)
+
+	// s1' = s1 - out + in
+	// s2' = s2 - n*out + s1' - 1
+	// 为避免无符号数下溢，减法前先加上mod的倍数
+	n := uint32(len(r.window)) % mod
+	r.s1 = (r.s1 + mod - out + uint32(b)) % mod
+	r.s2 = (r.s2 + mod - n*out%mod + r.s1 + mod - 1) % mod
+	return r.Sum32()
+}
+
+func (r *RollingAdler32) Sum32() uint32 {
+	return r.s2<<16 | r.s1
+}
+
+// Buzhash(循环多项式): h = rotl(T[b0], n-1) ^ rotl(T[b1], n-2) ^ ... ^ T[bn-1]
+// 各位分布比Adler-32均匀，更适合作为分块边界的判断依据
+type Buzhash struct {
+	window []byte
+	pos    int
+	h      uint32
+}
+
+// 固定种子生成的字节映射表，保证不同进程、不同机器的分块边界一致
+var buzTable = func() [256]uint32 {
+	var t [256]uint32
+	// splitmix64
+	x := uint64(0x9e3779b97f4a7c15)
+	for i := range t {
+		x += 0x9e3779b97f4a7c15
+		z := x
+		z = (z ^ z>>30) * 0xbf58476d1ce4e5b9
+		z = (z ^ z>>27) * 0x94d049bb133111eb
+		t[i] = uint32(z ^ z>>31)
+	}
+	return t
+}()
+
+func NewBuzhash(window int) *Buzhash {
+	r := &Buzhash{window: make([]byte, window)}
+	r.Reset()
+	return r
+}
+
+func (r *Buzhash) Reset() {
+	for i := range r.window {
+		r.window[i] = 0
+	}
+	r.pos = 0
+	r.h = 0
+	n := len(r.window)
+	for i := 0; i < n; i++ {
+		r.h ^= rotl(buzTable[0], uint(n-1-i))
+	}
+}
+
+func (r *Buzhash) Roll(b byte) uint32 {
+	out := r.window[r.pos]
+	r.window[r.pos] = b
+	r.pos = (r.pos + 1) % len(r.window)
+	r.h = rotl(r.h, 1) ^ rotl(buzTable[out], uint(len(r.window))) ^ buzTable[b]
+	return r.h
+}
+
+func (r *Buzhash) Sum32() uint32 {
+	return r.h
+}
+
+func rotl(x uint32, n uint) uint32 {
+	n &= 31
+	return x<<n | x>>(32-n)
+}
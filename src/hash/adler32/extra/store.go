@@ -0,0 +1,196 @@
+package extra
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+var ErrChunkNotFound = errors.New("chunk: not found")
+var ErrChunkCorrupted = errors.New("chunk: content does not match id")
+
+// 文件由有序的块ID组成，恢复时按顺序拼接
+type Recipe struct {
+	Name   string `json:"name"`
+	Size   int64  `json:"size"`
+	Chunks []ID   `json:"chunks"`
+}
+
+// 一次备份的统计信息
+type Stats struct {
+	Chunks    int
+	NewChunks int
+	Bytes     int64
+	// 实际写入存储的字节数
+	NewBytes int64
+}
+
+// 按内容寻址的块存储，相同内容的块只保存一份，可以跨文件去重
+// 目录结构:
+//
+//	chunks/ab/abcdef...  以SHA-256命名的块文件，使用前两位作为子目录
+//	recipes/name.json    文件的块列表
+type Store struct {
+	dir string
+	mu  sync.Mutex
+}
+
+func NewStore(dir string) (*Store, error) {
+	for _, sub := range []string{"chunks", "recipes"} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0755); err != nil {
+			return nil, err
+		}
+	}
+	return &Store{dir: dir}, nil
+}
+
+func (s *Store) chunkPath(id ID) string {
+	h := id.String()
+	return filepath.Join(s.dir, "chunks", h[:2], h)
+}
+
+func (s *Store) Has(id ID) bool {
+	_, err := os.Stat(s.chunkPath(id))
+	return err == nil
+}
+
+// 保存块，已存在时不重复写入，返回是否为新块
+func (s *Store) Put(id ID, data []byte) (bool, error) {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.Has(id) {
+		return false, nil
+	}
+	p := s.chunkPath(id)
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		return false, err
+	}
+
+	// 先写临时文件再重命名，避免中途失败留下不完整的块
+	tmp, err := ioutil.TempFile(filepath.Dir(p), ".tmp-")
+	if err != nil {
+		return false, err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return false, err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return false, err
+	}
+	if err := os.Rename(tmp.Name(), p); err != nil {
+		os.Remove(tmp.Name())
+		return false, err
+	}
+	return true, nil
+}
+
+// 读取块并校验内容
+func (s *Store) Get(id ID) ([]byte, error) {
+
+	data, err := ioutil.ReadFile(s.chunkPath(id))
+	if os.IsNotExist(err) {
+		return nil, ErrChunkNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if sha256.Sum256(data) != id {
+		return nil, ErrChunkCorrupted
+	}
+	return data, nil
+}
+
+// 将r分块保存，只写入存储中不存在的块，并记录为名为name的文件
+func (s *Store) Backup(name string, r io.Reader, opts Options) (*Recipe, *Stats, error) {
+
+	chunker, err := NewChunker(r, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	recipe := &Recipe{Name: name}
+	stats := &Stats{}
+	for {
+		chunk, err := chunker.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		isNew, err := s.Put(chunk.ID, chunk.Data)
+		if err != nil {
+			return nil, nil, err
+		}
+		recipe.Chunks = append(recipe.Chunks, chunk.ID)
+		recipe.Size += int64(len(chunk.Data))
+		stats.Chunks++
+		stats.Bytes += int64(len(chunk.Data))
+		if isNew {
+			stats.NewChunks++
+			stats.NewBytes += int64(len(chunk.Data))
+		}
+	}
+
+	data, err := json.Marshal(recipe)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := ioutil.WriteFile(s.recipePath(name), data, 0644); err != nil {
+		return nil, nil, err
+	}
+	return recipe, stats, nil
+}
+
+func (s *Store) Recipe(name string) (*Recipe, error) {
+	data, err := ioutil.ReadFile(s.recipePath(name))
+	if err != nil {
+		return nil, err
+	}
+	var recipe Recipe
+	if err := json.Unmarshal(data, &recipe); err != nil {
+		return nil, err
+	}
+	return &recipe, nil
+}
+
+// 按块列表恢复文件，写入w
+func (s *Store) Restore(name string, w io.Writer) (int64, error) {
+
+	recipe, err := s.Recipe(name)
+	if err != nil {
+		return 0, err
+	}
+	var n int64
+	for _, id := range recipe.Chunks {
+		data, err := s.Get(id)
+		if err != nil {
+			return n, err
+		}
+		written, err := io.Copy(w, bytes.NewReader(data))
+		n += written
+		if err != nil {
+			return n, err
+		}
+	}
+	if n != recipe.Size {
+		return n, errors.New("chunk: restored size does not match recipe")
+	}
+	return n, nil
+}
+
+func (s *Store) recipePath(name string) string {
+	// 转义文件名中的路径分隔符，避免写到recipes目录之外
+	return filepath.Join(s.dir, "recipes", url.PathEscape(name)+".json")
+}
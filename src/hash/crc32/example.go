@@ -2,8 +2,11 @@ package main
 
 import (
 	"fmt"
+	"github.com/zc2638/go-standard/src/hash/crc32/extra"
 	"hash/crc32"
 	"log"
+	"math"
+	"strconv"
 )
 
 // crc32包实现了32位循环冗余校验（CRC-32）的校验和算法
@@ -41,4 +44,144 @@ func main() {
 
 	// 创建一个使用tab代表的多项式计算CRC-32校验和的hash.Hash32接口
 	crc32.New(t)
-}
\ No newline at end of file
+
+	// 一致性哈希
+	Sharding()
+	// 有界负载
+	BoundedLoad()
+}
+
+const keyCount = 100000
+
+func newNodes(n int) []string {
+	nodes := make([]string, n)
+	for i := range nodes {
+		nodes[i] = "cache-" + strconv.Itoa(i) + ":6379"
+	}
+	return nodes
+}
+
+func assign(s extra.Sharder) []string {
+	owners := make([]string, keyCount)
+	for i := range owners {
+		owners[i] = s.Locate("user:" + strconv.Itoa(i))
+	}
+	return owners
+}
+
+// 两次分配结果中归属发生变化的key所占比例
+func moved(before, after []string) float64 {
+	n := 0
+	for i := range before {
+		if before[i] != after[i] {
+			n++
+		}
+	}
+	return float64(n) / float64(len(before)) * 100
+}
+
+// 各节点key数量的变异系数(标准差/平均值)，越小越均匀
+func spread(owners []string) float64 {
+	counts := map[string]float64{}
+	for _, o := range owners {
+		counts[o]++
+	}
+	mean := float64(len(owners)) / float64(len(counts))
+	var v float64
+	for _, c := range counts {
+		v += (c - mean) * (c - mean)
+	}
+	return math.Sqrt(v/float64(len(counts))) / mean * 100
+}
+
+func Sharding() {
+
+	strategies := []struct {
+		name string
+		new  func() extra.Sharder
+	}{
+		{"Ring(CRC32)", func() extra.Sharder { return extra.NewRing(160, extra.CRC32) }},
+		{"Ring(FNV32a)", func() extra.Sharder { return extra.NewRing(160, extra.FNV32a) }},
+		{"Jump", func() extra.Sharder { return extra.NewJump() }},
+		{"Rendezvous", func() extra.Sharder { return extra.NewRendezvous() }},
+	}
+
+	// 10个节点增加到11个，理想的移动比例为 1/11 = 9.09%
+	// 从10个节点中移除一个，理想的移动比例为 1/10 = 10%
+	for _, st := range strategies {
+		s := st.new()
+		nodes := newNodes(11)
+		for _, node := range nodes[:10] {
+			s.Add(node, 1)
+		}
+		before := assign(s)
+
+		s.Add(nodes[10], 1)
+		added := assign(s)
+
+		s.Remove(nodes[10])
+		restored := assign(s)
+
+		// Jump Hash移除末尾的节点代价最小，这里移除中间的节点以体现差异
+		s.Remove(nodes[3])
+		removed := assign(s)
+
+		fmt.Printf("%-13s 不均匀度 %5.2f%%  加入节点移动 %5.2f%%  移除后恢复一致 %v  移除节点移动 %5.2f%%\n",
+			st.name, spread(before), moved(before, added), moved(before, restored) == 0, moved(before, removed))
+	}
+
+	// 权重: cache-0权重为2，应承担约2/11的key
+	ring := extra.NewRing(160, nil)
+	for i, node := range newNodes(10) {
+		weight := 1
+		if i == 0 {
+			weight = 2
+		}
+		ring.Add(node, weight)
+	}
+	count := 0
+	for _, o := range assign(ring) {
+		if o == "cache-0:6379" {
+			count++
+		}
+	}
+	fmt.Printf("权重为2的节点承担: %.2f%% (期望 %.2f%%)\n", float64(count)/keyCount*100, 2.0/11*100)
+
+	// 副本放置: 顺时针的3个不同节点
+	fmt.Println("user:1 的副本节点: ", ring.LocateN("user:1", 3))
+}
+
+func BoundedLoad() {
+
+	// 少数热点key占据大部分请求时，普通一致性哈希会让单个节点过载
+	ring := extra.NewRing(160, nil)
+	for _, node := range newNodes(5) {
+		ring.Add(node, 1)
+	}
+	requests := make([]string, 0, 10000)
+	for i := 0; i < 10000; i++ {
+		key := "user:" + strconv.Itoa(i)
+		if i%2 == 0 {
+			key = "hot-key"
+		}
+		requests = append(requests, key)
+	}
+
+	for _, c := range []float64{0, 1.25} {
+		ring.SetLoadFactor(c)
+		var acquired []string
+		for _, key := range requests {
+			acquired = append(acquired, ring.Acquire(key))
+		}
+		max := 0
+		for _, load := range ring.Loads() {
+			if load > max {
+				max = load
+			}
+		}
+		fmt.Printf("负载因子 %.2f: 最大负载 %d, 平均负载 %d\n", c, max, len(requests)/5)
+		for _, node := range acquired {
+			ring.Release(node)
+		}
+	}
+}
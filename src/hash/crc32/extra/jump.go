@@ -0,0 +1,159 @@
+package extra
+
+import (
+	"hash/crc64"
+	"math"
+	"sync"
+)
+
+var crc64Table = crc64.MakeTable(crc64.ECMA)
+
+// 64位哈希，Jump Hash与Rendezvous Hash需要更大的取值空间
+// CRC-64的低位相关性较强，经过fmix64打散
+func hash64(data []byte) uint64 {
+	h := crc64.Checksum(data, crc64Table)
+	h ^= h >> 33
+	h *= 0xff51afd7ed558ccd
+	h ^= h >> 33
+	h *= 0xc4ceb9fe1a85ec53
+	h ^= h >> 33
+	return h
+}
+
+// Jump一致性哈希(Lamping, Veach: A Fast, Minimal Memory, Consistent Hash Algorithm)
+// 不需要保存环，分布非常均匀，但桶只能在末尾增减:
+// 移除中间的节点时会将最后一个桶移到其位置，此时移动的key多于理想值
+//
+// 桶的顺序由Add/Remove的调用历史决定，也是映射的一部分: 成员相同但调用顺序不同的两个Jump会把key分到不同节点
+// 多个客户端共享同一映射时，必须以相同的顺序执行相同的Add/Remove，或者通过Buckets同步桶顺序
+type Jump struct {
+	mu      sync.RWMutex
+	buckets []string
+	weights map[string]int
+}
+
+func NewJump() *Jump {
+	return &Jump{weights: make(map[string]int)}
+}
+
+// 权重为w的节点占用w个桶
+func (j *Jump) Add(node string, weight int) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if weight < 1 {
+		weight = 1
+	}
+	if _, ok := j.weights[node]; ok {
+		j.remove(node)
+	}
+	j.weights[node] = weight
+	for i := 0; i < weight; i++ {
+		j.buckets = append(j.buckets, node)
+	}
+}
+
+func (j *Jump) Remove(node string) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.remove(node)
+}
+
+func (j *Jump) remove(node string) {
+	if _, ok := j.weights[node]; !ok {
+		return
+	}
+	delete(j.weights, node)
+	// 用末尾的桶填补被移除的桶，只有这些桶上的key会移动
+	for i := 0; i < len(j.buckets); {
+		if j.buckets[i] != node {
+			i++
+			continue
+		}
+		last := len(j.buckets) - 1
+		j.buckets[i] = j.buckets[last]
+		j.buckets = j.buckets[:last]
+	}
+}
+
+func (j *Jump) Locate(key string) string {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+	if len(j.buckets) == 0 {
+		return ""
+	}
+	return j.buckets[JumpHash(hash64([]byte(key)), len(j.buckets))]
+}
+
+// 按桶号排列的节点，权重为w的节点出现w次
+func (j *Jump) Buckets() []string {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+	return append([]string(nil), j.buckets...)
+}
+
+func (j *Jump) Members() []string {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+	return members(j.weights)
+}
+
+// 返回key在[0, buckets)中的桶号，桶数由n增加到n+1时只有1/(n+1)的key移动到新桶
+func JumpHash(key uint64, buckets int) int {
+	var b, j int64 = -1, 0
+	for j < int64(buckets) {
+		b = j
+		key = key*2862933555777941757 + 1
+		j = int64(float64(b+1) * (float64(int64(1)<<31) / float64((key>>33)+1)))
+	}
+	return int(b)
+}
+
+// Rendezvous哈希(最高随机权重，HRW): 对每个节点计算hash(node, key)，取得分最高的节点
+// 节点离开时只有属于它的key移动；查找为O(n)，适合节点数较少的场景
+type Rendezvous struct {
+	mu      sync.RWMutex
+	weights map[string]int
+}
+
+func NewRendezvous() *Rendezvous {
+	return &Rendezvous{weights: make(map[string]int)}
+}
+
+func (r *Rendezvous) Add(node string, weight int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if weight < 1 {
+		weight = 1
+	}
+	r.weights[node] = weight
+}
+
+func (r *Rendezvous) Remove(node string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.weights, node)
+}
+
+func (r *Rendezvous) Locate(key string) string {
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var best string
+	bestScore := math.Inf(-1)
+	for node, weight := range r.weights {
+		// 加权HRW: score = -w / ln(h)，h为(0,1)间均匀分布的值
+		h := (float64(hash64([]byte(node+"\x00"+key))>>11) + 0.5) / (1 << 53)
+		score := -float64(weight) / math.Log(h)
+		if score > bestScore || (score == bestScore && node < best) {
+			best, bestScore = node, score
+		}
+	}
+	return best
+}
+
+func (r *Rendezvous) Members() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return members(r.weights)
+}
@@ -0,0 +1,243 @@
+package extra
+
+import (
+	"hash/crc32"
+	"hash/fnv"
+	"math"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+// 将key分配到节点的策略，节点加入或离开时尽量少地移动key
+type Sharder interface {
+	// 添加节点，weight为相对权重，小于1时按1处理
+	Add(node string, weight int)
+	Remove(node string)
+	// 返回key所属的节点，没有节点时返回空字符串
+	Locate(key string) string
+	Members() []string
+}
+
+var (
+	_ Sharder = (*Ring)(nil)
+	_ Sharder = (*Jump)(nil)
+	_ Sharder = (*Rendezvous)(nil)
+)
+
+type HashFunc func(data []byte) uint32
+
+// 默认使用IEEE多项式的CRC-32
+var CRC32 HashFunc = crc32.ChecksumIEEE
+
+// 可选的32位FNV-1a
+func FNV32a(data []byte) uint32 {
+	h := fnv.New32a()
+	h.Write(data)
+	return h.Sum32()
+}
+
+// 一致性哈希环: 每个节点按权重在环上放置多个虚拟节点，key顺时针找到的第一个虚拟节点即所属节点
+type Ring struct {
+	mu       sync.RWMutex
+	hash     HashFunc
+	replicas int
+	points   []uint32
+	owners   map[uint32]string
+	weights  map[string]int
+
+	// 有界负载，参见SetLoadFactor
+	loadFactor float64
+	loads      map[string]int
+	totalLoad  int
+}
+
+// replicas为权重为1的节点的虚拟节点数，hash为nil时使用CRC32
+func NewRing(replicas int, hash HashFunc) *Ring {
+	if replicas <= 0 {
+		replicas = 160
+	}
+	if hash == nil {
+		hash = CRC32
+	}
+	return &Ring{
+		hash:     hash,
+		replicas: replicas,
+		owners:   make(map[uint32]string),
+		weights:  make(map[string]int),
+		loads:    make(map[string]int),
+	}
+}
+
+func (r *Ring) Add(node string, weight int) {
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if weight < 1 {
+		weight = 1
+	}
+	if _, ok := r.weights[node]; ok {
+		r.remove(node)
+	}
+	r.weights[node] = weight
+	for i := 0; i < r.replicas*weight; i++ {
+		h := mix32(r.hash([]byte(node + "#" + strconv.Itoa(i))))
+		// 极少数情况下虚拟节点哈希冲突，保留先加入的
+		if _, ok := r.owners[h]; ok {
+			continue
+		}
+		r.owners[h] = node
+		r.points = append(r.points, h)
+	}
+	sort.Slice(r.points, func(i, j int) bool { return r.points[i] < r.points[j] })
+}
+
+func (r *Ring) Remove(node string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.remove(node)
+}
+
+func (r *Ring) remove(node string) {
+	if _, ok := r.weights[node]; !ok {
+		return
+	}
+	delete(r.weights, node)
+	points := r.points[:0]
+	for _, p := range r.points {
+		if r.owners[p] == node {
+			delete(r.owners, p)
+			continue
+		}
+		points = append(points, p)
+	}
+	r.points = points
+	r.totalLoad -= r.loads[node]
+	delete(r.loads, node)
+}
+
+func (r *Ring) Locate(key string) string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if len(r.points) == 0 {
+		return ""
+	}
+	return r.owners[r.points[r.search(key)]]
+}
+
+// 返回key顺时针方向的n个不同节点，可用于副本放置
+func (r *Ring) LocateN(key string, n int) []string {
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if n > len(r.weights) {
+		n = len(r.weights)
+	}
+	nodes := make([]string, 0, n)
+	seen := make(map[string]bool, n)
+	for i, start := 0, r.search(key); len(nodes) < n && i < len(r.points); i++ {
+		node := r.owners[r.points[(start+i)%len(r.points)]]
+		if !seen[node] {
+			seen[node] = true
+			nodes = append(nodes, node)
+		}
+	}
+	return nodes
+}
+
+// 第一个不小于key哈希值的虚拟节点，超过最大值时回到环的起点
+func (r *Ring) search(key string) int {
+	h := mix32(r.hash([]byte(key)))
+	i := sort.Search(len(r.points), func(i int) bool { return r.points[i] >= h })
+	if i == len(r.points) {
+		i = 0
+	}
+	return i
+}
+
+func (r *Ring) Members() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return members(r.weights)
+}
+
+// 有界负载的一致性哈希(Mirrokni et al. Consistent Hashing with Bounded Loads)
+// 每个节点的负载上限为 ceil(c * (总负载+1) * 权重/总权重)，c > 1
+// 顺时针找到的节点已满时继续向后查找，热点key不会压垮单个节点
+func (r *Ring) SetLoadFactor(c float64) {
+	r.mu.Lock()
+	r.loadFactor = c
+	r.mu.Unlock()
+}
+
+// 为key分配节点并增加其负载，处理完成后需要调用Release
+// 未设置负载因子时与Locate相同
+func (r *Ring) Acquire(key string) string {
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(r.points) == 0 {
+		return ""
+	}
+	start := r.search(key)
+	node := r.owners[r.points[start]]
+	if r.loadFactor > 1 {
+		totalWeight := 0
+		for _, w := range r.weights {
+			totalWeight += w
+		}
+		for i := 0; i < len(r.points); i++ {
+			candidate := r.owners[r.points[(start+i)%len(r.points)]]
+			limit := math.Ceil(r.loadFactor * float64(r.totalLoad+1) * float64(r.weights[candidate]) / float64(totalWeight))
+			if float64(r.loads[candidate]+1) <= limit {
+				node = candidate
+				break
+			}
+		}
+	}
+	r.loads[node]++
+	r.totalLoad++
+	return node
+}
+
+func (r *Ring) Release(node string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.loads[node] > 0 {
+		r.loads[node]--
+		r.totalLoad--
+	}
+}
+
+// 当前各节点的负载
+func (r *Ring) Loads() map[string]int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	loads := make(map[string]int, len(r.weights))
+	for node := range r.weights {
+		loads[node] = r.loads[node]
+	}
+	return loads
+}
+
+// CRC-32是线性的，相似的输入(如node#1、node#2)得到的值在环上分布不均，使用MurmurHash3的fmix32打散
+func mix32(h uint32) uint32 {
+	h ^= h >> 16
+	h *= 0x85ebca6b
+	h ^= h >> 13
+	h *= 0xc2b2ae35
+	h ^= h >> 16
+	return h
+}
+
+func members(weights map[string]int) []string {
+	nodes := make([]string, 0, len(weights))
+	for node := range weights {
+		nodes = append(nodes, node)
+	}
+	sort.Strings(nodes)
+	return nodes
+}
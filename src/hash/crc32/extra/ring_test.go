@@ -0,0 +1,115 @@
+package extra
+
+import (
+	"strconv"
+	"testing"
+)
+
+const testKeys = 100000
+
+func testNodes(n int) []string {
+	nodes := make([]string, n)
+	for i := range nodes {
+		nodes[i] = "cache-" + strconv.Itoa(i) + ":6379"
+	}
+	return nodes
+}
+
+func testAssign(s Sharder) []string {
+	owners := make([]string, testKeys)
+	for i := range owners {
+		owners[i] = s.Locate("user:" + strconv.Itoa(i))
+	}
+	return owners
+}
+
+// 归属发生变化的key所占比例，以及变化后不属于target的key数量
+func testMoved(before, after []string, target string) (float64, int) {
+	moved, stray := 0, 0
+	for i := range before {
+		if before[i] == after[i] {
+			continue
+		}
+		moved++
+		if after[i] != target && before[i] != target {
+			stray++
+		}
+	}
+	return float64(moved) / float64(len(before)), stray
+}
+
+// 10个节点增加到11个时理想的移动比例为1/11，移除其中一个时为1/10
+func TestKeyMovement(t *testing.T) {
+
+	strategies := []struct {
+		name string
+		new  func() Sharder
+		// 移除中间节点时的移动上限，Jump会额外移动最后一个桶上的key
+		removeMax float64
+	}{
+		{"Ring(CRC32)", func() Sharder { return NewRing(160, CRC32) }, 0.13},
+		{"Ring(FNV32a)", func() Sharder { return NewRing(160, FNV32a) }, 0.13},
+		{"Jump", func() Sharder { return NewJump() }, 0.22},
+		{"Rendezvous", func() Sharder { return NewRendezvous() }, 0.13},
+	}
+
+	for _, st := range strategies {
+		t.Run(st.name, func(t *testing.T) {
+			s := st.new()
+			nodes := testNodes(11)
+			for _, node := range nodes[:10] {
+				s.Add(node, 1)
+			}
+			before := testAssign(s)
+
+			// 加入节点: 只有移动到新节点的key发生变化
+			s.Add(nodes[10], 1)
+			added := testAssign(s)
+			moved, stray := testMoved(before, added, nodes[10])
+			if moved < 0.06 || moved > 0.12 {
+				t.Errorf("add moved %.2f%% of keys, want 6%%~12%%", moved*100)
+			}
+			if stray != 0 {
+				t.Errorf("add moved %d keys between existing nodes", stray)
+			}
+
+			// 移除刚加入的节点后恢复原来的分配
+			s.Remove(nodes[10])
+			if moved, _ := testMoved(before, testAssign(s), ""); moved != 0 {
+				t.Errorf("remove after add moved %.2f%% of keys, want 0", moved*100)
+			}
+
+			// 移除中间的节点: Ring与Rendezvous只移动属于该节点的key
+			s.Remove(nodes[3])
+			moved, stray = testMoved(before, testAssign(s), nodes[3])
+			if moved < 0.07 || moved > st.removeMax {
+				t.Errorf("remove moved %.2f%% of keys, want 7%%~%.0f%%", moved*100, st.removeMax*100)
+			}
+			if _, ok := s.(*Jump); !ok && stray != 0 {
+				t.Errorf("remove moved %d keys between remaining nodes", stray)
+			}
+		})
+	}
+}
+
+// Jump的映射由桶顺序决定，调用历史相同的两个实例映射一致
+func TestJumpBucketOrder(t *testing.T) {
+
+	nodes := testNodes(5)
+	a, b := NewJump(), NewJump()
+	for _, j := range []*Jump{a, b} {
+		for _, node := range nodes {
+			j.Add(node, 2)
+		}
+		j.Remove(nodes[1])
+	}
+	for i := 0; i < 1000; i++ {
+		key := "user:" + strconv.Itoa(i)
+		if a.Locate(key) != b.Locate(key) {
+			t.Fatalf("same history located %s differently", key)
+		}
+	}
+	if len(a.Buckets()) != 8 {
+		t.Errorf("got %d buckets, want 8", len(a.Buckets()))
+	}
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"github.com/zc2638/go-standard/src/hash/extra"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// 多算法校验和工具，兼容sha256sum/md5sum的清单格式
+//
+//	go run ./src/hash -a sha256 src/hash > SHA256SUMS
+//	go run ./src/hash -a sha256,md5,crc32 -tag src/hash
+//	go run ./src/hash -c SHA256SUMS
+//
+// 不带参数运行时在临时目录中演示生成及校验清单
+func main() {
+
+	algs := flag.String("a", "sha256", "逗号分隔的算法列表: "+strings.Join(extra.Algorithms(), ","))
+	tag := flag.Bool("tag", false, "输出BSD格式，多个算法时自动启用")
+	check := flag.String("c", "", "读取清单文件并校验")
+	workers := flag.Int("j", runtime.NumCPU(), "并行计算的文件数")
+	quiet := flag.Bool("quiet", false, "校验时不输出OK的文件")
+	flag.Parse()
+
+	if *check != "" {
+		alg := ""
+		// 指定了单个算法时，GNU格式的清单使用该算法，否则根据摘要长度推断
+		if isFlagSet("a") {
+			names, err := extra.ParseAlgorithms(*algs)
+			if err != nil {
+				log.Fatal(err)
+			}
+			if len(names) > 1 {
+				log.Fatal("hash: -c accepts only one algorithm")
+			}
+			alg = names[0]
+		}
+		if failed := Check(*check, alg, *workers, *quiet); failed {
+			os.Exit(1)
+		}
+		return
+	}
+	if flag.NArg() == 0 {
+		Demo()
+		return
+	}
+
+	names, err := extra.ParseAlgorithms(*algs)
+	if err != nil {
+		log.Fatal(err)
+	}
+	format := extra.GNU
+	if *tag || len(names) > 1 {
+		format = extra.BSD
+	}
+	if err := Sum(os.Stdout, flag.Args(), names, format, *workers); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func isFlagSet(name string) bool {
+	set := false
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
+
+// 计算文件及目录树的校验和并写出清单
+func Sum(w io.Writer, paths, algs []string, format extra.Format, workers int) error {
+
+	files, err := extra.Expand(paths)
+	if err != nil {
+		return err
+	}
+	results := extra.SumFiles(files, algs, workers)
+	for _, r := range results {
+		if r.Err != nil {
+			fmt.Fprintln(os.Stderr, r.Err)
+		}
+	}
+	return extra.WriteManifest(w, results, algs, format)
+}
+
+// 校验清单，返回是否存在失败的文件
+func Check(manifest, alg string, workers int, quiet bool) bool {
+
+	f, err := os.Open(manifest)
+	if err != nil {
+		log.Fatal(err)
+	}
+	entries, err := extra.ParseManifest(f, alg)
+	f.Close()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// 与sha256sum -c一致，清单中的相对路径基于当前目录
+	var changed, missing, unreadable int
+	for _, c := range extra.Verify(entries, "", workers) {
+		switch c.Status {
+		case extra.OK:
+			if quiet {
+				continue
+			}
+		case extra.Changed:
+			changed++
+		case extra.Missing:
+			missing++
+		default:
+			unreadable++
+		}
+		fmt.Printf("%s: %s\n", c.Entry.Path, c.Status)
+	}
+
+	// 与sha256sum -c的汇总信息一致
+	if changed > 0 {
+		fmt.Fprintf(os.Stderr, "WARNING: %d computed checksum(s) did NOT match\n", changed)
+	}
+	if missing > 0 {
+		fmt.Fprintf(os.Stderr, "WARNING: %d listed file(s) could not be found\n", missing)
+	}
+	if unreadable > 0 {
+		fmt.Fprintf(os.Stderr, "WARNING: %d listed file(s) could not be read\n", unreadable)
+	}
+	return changed+missing+unreadable > 0
+}
+
+func Demo() {
+
+	dir, err := ioutil.TempDir("", "hash-example")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// 准备目录树
+	files := map[string]string{
+		"a.txt":           "Hello World!",
+		"docs/readme.md":  "# readme",
+		"docs/notes.txt":  "notes",
+		"bin/data.bin":    strings.Repeat("\x00\x01", 1024),
+		"with space.conf": "key=value",
+	}
+	for name, content := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			log.Fatal(err)
+		}
+		if err := ioutil.WriteFile(p, []byte(content), 0644); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	// 一次读取同时计算多个摘要
+	digests, n, err := extra.SumReader(strings.NewReader("Hello World!"), "md5", "sha1", "sha256", "crc32", "adler32", "fnv64a")
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, alg := range []string{"md5", "sha1", "sha256", "crc32", "adler32", "fnv64a"} {
+		fmt.Printf("%-8s %s\n", alg, hex.EncodeToString(digests[alg]))
+	}
+	fmt.Println("读取字节数: ", n)
+
+	// 在目录中生成GNU格式清单，可直接使用sha256sum -c校验
+	if err := os.Chdir(dir); err != nil {
+		log.Fatal(err)
+	}
+	manifest, err := os.Create("SHA256SUMS")
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := Sum(manifest, []string{"a.txt", "bin", "docs", "with space.conf"}, []string{"sha256"}, extra.GNU, 4); err != nil {
+		log.Fatal(err)
+	}
+	manifest.Close()
+	b, _ := ioutil.ReadFile("SHA256SUMS")
+	fmt.Print("SHA256SUMS:\n", string(b))
+
+	// BSD格式，包含多个算法
+	fmt.Println("BSD格式:")
+	if err := Sum(os.Stdout, []string{"docs"}, []string{"sha256", "md5"}, extra.BSD, 4); err != nil {
+		log.Fatal(err)
+	}
+
+	// 修改一个文件并删除一个文件后校验
+	if err := ioutil.WriteFile("a.txt", []byte("Hello World?"), 0644); err != nil {
+		log.Fatal(err)
+	}
+	if err := os.Remove(filepath.Join("docs", "notes.txt")); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println("校验清单:")
+	failed := Check("SHA256SUMS", "", 4, false)
+	fmt.Println("存在失败: ", failed)
+}
@@ -0,0 +1,88 @@
+package extra
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"fmt"
+	"hash"
+	"hash/adler32"
+	"hash/crc32"
+	"hash/crc64"
+	"hash/fnv"
+	"sort"
+	"strings"
+)
+
+var crc64ISO = crc64.MakeTable(crc64.ISO)
+var crc64ECMA = crc64.MakeTable(crc64.ECMA)
+var crc32C = crc32.MakeTable(crc32.Castagnoli)
+
+// 算法名称与构造方法，名称均为小写
+var algorithms = map[string]func() hash.Hash{
+	"md5":      md5.New,
+	"sha1":     sha1.New,
+	"sha224":   sha256.New224,
+	"sha256":   sha256.New,
+	"sha384":   sha512.New384,
+	"sha512":   sha512.New,
+	"crc32":    func() hash.Hash { return crc32.NewIEEE() },
+	"crc32c":   func() hash.Hash { return crc32.New(crc32C) },
+	"crc64":    func() hash.Hash { return crc64.New(crc64ECMA) },
+	"crc64iso": func() hash.Hash { return crc64.New(crc64ISO) },
+	"adler32":  func() hash.Hash { return adler32.New() },
+	"fnv32":    func() hash.Hash { return fnv.New32() },
+	"fnv32a":   func() hash.Hash { return fnv.New32a() },
+	"fnv64":    func() hash.Hash { return fnv.New64() },
+	"fnv64a":   func() hash.Hash { return fnv.New64a() },
+	"fnv128":   fnv.New128,
+	"fnv128a":  fnv.New128a,
+}
+
+// 根据名称创建hash.Hash，名称不区分大小写，可以带'-'，如SHA-256
+func New(name string) (hash.Hash, error) {
+	f, ok := algorithms[normalize(name)]
+	if !ok {
+		return nil, fmt.Errorf("hash: unknown algorithm %q", name)
+	}
+	return f(), nil
+}
+
+// 返回支持的算法名称，按字母排序
+func Algorithms() []string {
+	names := make([]string, 0, len(algorithms))
+	for name := range algorithms {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// 解析逗号分隔的算法列表，如"sha256,md5"
+func ParseAlgorithms(list string) ([]string, error) {
+	var names []string
+	for _, name := range strings.Split(list, ",") {
+		name = normalize(name)
+		if name == "" {
+			continue
+		}
+		if _, ok := algorithms[name]; !ok {
+			return nil, fmt.Errorf("hash: unknown algorithm %q", name)
+		}
+		names = append(names, name)
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("hash: no algorithm specified")
+	}
+	return names, nil
+}
+
+// BSD格式中的算法标签，与coreutils的--tag输出一致，如SHA256、MD5
+func Tag(name string) string {
+	return strings.ToUpper(normalize(name))
+}
+
+func normalize(name string) string {
+	return strings.ToLower(strings.Replace(strings.TrimSpace(name), "-", "", -1))
+}
@@ -0,0 +1,283 @@
+package extra
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// 清单中的一行
+type Entry struct {
+	Algorithm string
+	Digest    []byte
+	Path      string
+	// GNU格式中以'*'标记的二进制模式
+	Binary bool
+}
+
+// 输出格式
+type Format int
+
+const (
+	// sha256sum/md5sum默认格式: <hex>  <path>
+	GNU Format = iota
+	// BSD格式，即sha256sum --tag: SHA256 (<path>) = <hex>
+	BSD
+)
+
+// 写出一行，文件名包含'\'或换行时按coreutils的规则转义，并在行首加'\'
+func (e *Entry) Format(format Format) string {
+
+	path, escaped := escapePath(e.Path)
+	prefix := ""
+	if escaped {
+		prefix = "\\"
+	}
+	digest := hex.EncodeToString(e.Digest)
+	if format == BSD {
+		return fmt.Sprintf("%s%s (%s) = %s", prefix, Tag(e.Algorithm), path, digest)
+	}
+	mode := " "
+	if e.Binary {
+		mode = "*"
+	}
+	return fmt.Sprintf("%s%s %s%s", prefix, digest, mode, path)
+}
+
+// 将计算结果按algs中的算法顺序写出，计算失败的文件会被跳过
+// GNU格式只能包含一种算法，因此多个算法时只能使用BSD格式
+func WriteManifest(w io.Writer, results []Result, algs []string, format Format) error {
+
+	if format == GNU && len(algs) != 1 {
+		return fmt.Errorf("hash: GNU format supports exactly one algorithm, use BSD format instead")
+	}
+	bw := bufio.NewWriter(w)
+	for _, r := range results {
+		if r.Err != nil {
+			continue
+		}
+		for _, alg := range algs {
+			e := Entry{Algorithm: normalize(alg), Digest: r.Digests[normalize(alg)], Path: filepath.ToSlash(r.Path)}
+			if _, err := bw.WriteString(e.Format(format) + "\n"); err != nil {
+				return err
+			}
+		}
+	}
+	return bw.Flush()
+}
+
+var (
+	bsdLine = regexp.MustCompile(`^([A-Za-z0-9-]+) \((.*)\) = ([0-9a-fA-F]+)$`)
+	gnuLine = regexp.MustCompile(`^([0-9a-fA-F]+) ([ *])(.*)$`)
+)
+
+// 解析清单，自动识别GNU及BSD格式，两种格式可以混合出现
+// GNU格式不包含算法名称，使用alg；alg为空时根据摘要长度推断
+func ParseManifest(r io.Reader, alg string) ([]Entry, error) {
+
+	var entries []Entry
+	scanner := bufio.NewScanner(r)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		escaped := strings.HasPrefix(line, "\\")
+		if escaped {
+			line = line[1:]
+		}
+
+		var e Entry
+		var digest string
+		if m := bsdLine.FindStringSubmatch(line); m != nil {
+			e.Algorithm, e.Path, digest = normalize(m[1]), m[2], m[3]
+		} else if m := gnuLine.FindStringSubmatch(line); m != nil {
+			digest, e.Binary, e.Path = m[1], m[2] == "*", m[3]
+			e.Algorithm = normalize(alg)
+			if e.Algorithm == "" {
+				e.Algorithm = guessAlgorithm(len(digest) / 2)
+			}
+		} else {
+			return nil, fmt.Errorf("hash: line %d: improperly formatted checksum line", lineNo)
+		}
+
+		if _, ok := algorithms[e.Algorithm]; !ok {
+			return nil, fmt.Errorf("hash: line %d: unknown algorithm %q", lineNo, e.Algorithm)
+		}
+		d, err := hex.DecodeString(digest)
+		if err != nil {
+			return nil, fmt.Errorf("hash: line %d: %v", lineNo, err)
+		}
+		e.Digest = d
+		if escaped {
+			e.Path = unescapePath(e.Path)
+		}
+		entries = append(entries, e)
+	}
+	return entries, scanner.Err()
+}
+
+// 校验状态
+type Status int
+
+const (
+	OK Status = iota
+	// 摘要不一致，文件已被修改
+	Changed
+	// 文件不存在
+	Missing
+	// 读取失败
+	ReadError
+)
+
+func (s Status) String() string {
+	switch s {
+	case OK:
+		return "OK"
+	case Changed:
+		return "FAILED"
+	case Missing:
+		return "MISSING"
+	}
+	return "READ ERROR"
+}
+
+type Check struct {
+	Entry  Entry
+	Status Status
+	Err    error
+}
+
+// 并行校验清单中的文件，相对路径基于dir，dir为空时基于当前目录
+// 同一文件的多个算法只读取一次
+func Verify(entries []Entry, dir string, workers int) []Check {
+
+	// 按文件分组，收集每个文件需要的算法
+	var paths []string
+	algs := make(map[string][]string)
+	for _, e := range entries {
+		p := e.Path
+		if !filepath.IsAbs(p) {
+			p = filepath.Join(dir, filepath.FromSlash(p))
+		}
+		if _, ok := algs[p]; !ok {
+			paths = append(paths, p)
+		}
+		algs[p] = appendUnique(algs[p], e.Algorithm)
+	}
+
+	if workers <= 0 {
+		workers = 1
+	}
+	results := make(map[string]Result, len(paths))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	jobs := make(chan string)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for p := range jobs {
+				r := SumFile(p, algs[p]...)
+				mu.Lock()
+				results[p] = r
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, p := range paths {
+		jobs <- p
+	}
+	close(jobs)
+	wg.Wait()
+
+	checks := make([]Check, len(entries))
+	for i, e := range entries {
+		p := e.Path
+		if !filepath.IsAbs(p) {
+			p = filepath.Join(dir, filepath.FromSlash(p))
+		}
+		r := results[p]
+		checks[i].Entry = e
+		switch {
+		case os.IsNotExist(r.Err):
+			checks[i].Status, checks[i].Err = Missing, r.Err
+		case r.Err != nil:
+			checks[i].Status, checks[i].Err = ReadError, r.Err
+		case !bytes.Equal(r.Digests[e.Algorithm], e.Digest):
+			checks[i].Status = Changed
+		default:
+			checks[i].Status = OK
+		}
+	}
+	return checks
+}
+
+// 根据摘要字节数推断GNU格式清单的算法
+func guessAlgorithm(size int) string {
+	switch size {
+	case md5.Size:
+		return "md5"
+	case sha1.Size:
+		return "sha1"
+	case sha256.Size224:
+		return "sha224"
+	case sha256.Size:
+		return "sha256"
+	case sha512.Size384:
+		return "sha384"
+	case sha512.Size:
+		return "sha512"
+	}
+	return ""
+}
+
+func escapePath(p string) (string, bool) {
+	if !strings.ContainsAny(p, "\\\n\r") {
+		return p, false
+	}
+	r := strings.NewReplacer("\\", "\\\\", "\n", "\\n", "\r", "\\r")
+	return r.Replace(p), true
+}
+
+func unescapePath(p string) string {
+	var b strings.Builder
+	for i := 0; i < len(p); i++ {
+		if p[i] != '\\' || i+1 == len(p) {
+			b.WriteByte(p[i])
+			continue
+		}
+		i++
+		switch p[i] {
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		default:
+			b.WriteByte(p[i])
+		}
+	}
+	return b.String()
+}
+
+func appendUnique(list []string, s string) []string {
+	for _, v := range list {
+		if v == s {
+			return list
+		}
+	}
+	return append(list, s)
+}
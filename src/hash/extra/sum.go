@@ -0,0 +1,116 @@
+package extra
+
+import (
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// 一个文件的计算结果
+type Result struct {
+	Path string
+	Size int64
+	// 算法名称对应的摘要
+	Digests map[string][]byte
+	Err     error
+}
+
+// 一次读取r，同时计算多个算法的摘要
+func SumReader(r io.Reader, algs ...string) (map[string][]byte, int64, error) {
+
+	hashes := make([]hash.Hash, len(algs))
+	writers := make([]io.Writer, len(algs))
+	for i, alg := range algs {
+		h, err := New(alg)
+		if err != nil {
+			return nil, 0, err
+		}
+		hashes[i] = h
+		writers[i] = h
+	}
+
+	// 每次读取的数据同时写入所有hash，只需要读一遍文件
+	n, err := io.Copy(io.MultiWriter(writers...), r)
+	if err != nil {
+		return nil, n, err
+	}
+	digests := make(map[string][]byte, len(algs))
+	for i, alg := range algs {
+		digests[normalize(alg)] = hashes[i].Sum(nil)
+	}
+	return digests, n, nil
+}
+
+func SumFile(path string, algs ...string) Result {
+
+	result := Result{Path: path}
+	f, err := os.Open(path)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	defer f.Close()
+
+	result.Digests, result.Size, result.Err = SumReader(f, algs...)
+	return result
+}
+
+// 使用workers个goroutine并行计算多个文件，结果顺序与paths一致
+func SumFiles(paths []string, algs []string, workers int) []Result {
+
+	if workers <= 0 {
+		workers = 1
+	}
+	results := make([]Result, len(paths))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for idx := range jobs {
+				results[idx] = SumFile(paths[idx], algs...)
+			}
+		}()
+	}
+	for i := range paths {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	return results
+}
+
+// 展开路径列表，目录会递归列出其中的普通文件，结果按路径排序
+func Expand(paths []string) ([]string, error) {
+
+	var files []string
+	for _, p := range paths {
+		info, err := os.Stat(p)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			files = append(files, p)
+			continue
+		}
+		err = filepath.Walk(p, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			// 跳过目录、符号链接及设备文件等
+			if info.Mode().IsRegular() {
+				files = append(files, path)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
@@ -2,8 +2,11 @@ package main
 
 import (
 	"fmt"
+	"github.com/zc2638/go-standard/src/hash/fnv/extra"
 	"hash/fnv"
 	"log"
+	"math/rand"
+	"strconv"
 )
 
 // fnv包实现了FNV-1和FNV-1a（非加密hash函数）
@@ -33,4 +36,153 @@ func main() {
 
 	// 返回一个新的128位FNV-1a的hash.Hash64接口
 	fnv.New128a()
-}
\ No newline at end of file
+
+	// 基于FNV的概率数据结构
+	BloomFilter()
+	ScalableBloomFilter()
+	CountMinSketch()
+	HyperLogLog()
+}
+
+func BloomFilter() {
+
+	// 预计10万个元素，目标误判率1%
+	b, err := extra.NewBloom(100000, 0.01)
+	if err != nil {
+		log.Fatal(err)
+	}
+	for i := 0; i < 100000; i++ {
+		b.AddString("user-" + strconv.Itoa(i))
+	}
+	fmt.Println("Bloom m/k: ", b.Cap(), b.K(), "包含user-42: ", b.TestString("user-42"))
+
+	// 统计实际误判率
+	fp := 0
+	for i := 0; i < 100000; i++ {
+		if b.TestString("other-" + strconv.Itoa(i)) {
+			fp++
+		}
+	}
+	fmt.Printf("Bloom实际误判率: %.4f 估算误判率: %.4f\n", float64(fp)/100000, b.FalsePositiveRate())
+
+	// 各节点分别构建，序列化后在中心节点合并
+	node, _ := extra.NewBloom(100000, 0.01)
+	node.AddString("from-node")
+	data, err := node.MarshalBinary()
+	if err != nil {
+		log.Fatal(err)
+	}
+	var received extra.Bloom
+	if err := received.UnmarshalBinary(data); err != nil {
+		log.Fatal(err)
+	}
+	if err := b.Merge(&received); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println("Bloom合并后包含from-node: ", b.TestString("from-node"), "序列化字节数: ", len(data))
+}
+
+func ScalableBloomFilter() {
+
+	// 初始容量1000，总误判率1%，元素数量远超初始容量时自动扩展
+	sb, err := extra.NewScalableBloom(1000, 0.01)
+	if err != nil {
+		log.Fatal(err)
+	}
+	for i := 0; i < 100000; i++ {
+		sb.AddString("item-" + strconv.Itoa(i))
+	}
+	fp := 0
+	for i := 0; i < 100000; i++ {
+		if sb.TestString("other-" + strconv.Itoa(i)) {
+			fp++
+		}
+	}
+	fmt.Printf("可扩展Bloom: %d个过滤器, 元素%d, 实际误判率: %.4f\n", sb.Filters(), sb.Count(), float64(fp)/100000)
+
+	data, err := sb.MarshalBinary()
+	if err != nil {
+		log.Fatal(err)
+	}
+	var received extra.ScalableBloom
+	if err := received.UnmarshalBinary(data); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println("可扩展Bloom反序列化后包含item-99999: ", received.TestString("item-99999"))
+}
+
+func CountMinSketch() {
+
+	// 两个节点分别统计访问日志，误差不超过总数的0.1%的概率为99%
+	// Zipf分布: 少数路径访问量很大
+	zipf := rand.NewZipf(rand.New(rand.NewSource(1)), 1.2, 1, 10000)
+	var nodes []*extra.TopK
+	for i := 0; i < 2; i++ {
+		node, err := extra.NewTopK(5, 0.001, 0.01)
+		if err != nil {
+			log.Fatal(err)
+		}
+		nodes = append(nodes, node)
+	}
+	exact := map[string]uint64{}
+	for i := 0; i < 200000; i++ {
+		path := "/page/" + strconv.FormatUint(zipf.Uint64(), 10)
+		nodes[i%2].Add(path, 1)
+		exact[path]++
+	}
+
+	// 序列化sketch后合并
+	data, err := nodes[1].Sketch().MarshalBinary()
+	if err != nil {
+		log.Fatal(err)
+	}
+	var cms extra.CountMin
+	if err := cms.UnmarshalBinary(data); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println("Count-Min序列化字节数: ", len(data), "节点2的/page/0: ", cms.EstimateString("/page/0"))
+
+	if err := nodes[0].Merge(nodes[1]); err != nil {
+		log.Fatal(err)
+	}
+	for _, item := range nodes[0].Items() {
+		fmt.Println("热点: ", item.Item, "估计", item.Count, "实际", exact[item.Item])
+	}
+}
+
+func HyperLogLog() {
+
+	// 精度14，标准误差约0.8%，稠密表示占用16KB
+	a, err := extra.NewHyperLogLog(14)
+	if err != nil {
+		log.Fatal(err)
+	}
+	b, _ := extra.NewHyperLogLog(14)
+	for i := 0; i < 1000; i++ {
+		a.AddString("user-" + strconv.Itoa(i))
+	}
+	// 基数较小时使用稀疏表示，估计接近精确
+	fmt.Println("HLL 1000个元素: ", a.Count())
+
+	for i := 0; i < 600000; i++ {
+		a.AddString("user-" + strconv.Itoa(i))
+	}
+	for i := 400000; i < 1000000; i++ {
+		b.AddString("user-" + strconv.Itoa(i))
+	}
+	fmt.Println("HLL a(60万): ", a.Count(), "b(60万): ", b.Count())
+
+	// 序列化后合并，得到并集的基数
+	data, err := b.MarshalBinary()
+	if err != nil {
+		log.Fatal(err)
+	}
+	var received extra.HyperLogLog
+	if err := received.UnmarshalBinary(data); err != nil {
+		log.Fatal(err)
+	}
+	if err := a.Merge(&received); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println("HLL 并集(100万): ", a.Count(), "序列化字节数: ", len(data))
+}
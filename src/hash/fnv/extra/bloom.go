@@ -0,0 +1,168 @@
+package extra
+
+import (
+	"encoding/binary"
+	"math"
+	"math/bits"
+)
+
+// 布隆过滤器: 判断元素一定不存在或可能存在
+type Bloom struct {
+	m     uint64
+	k     uint32
+	count uint64
+	bits  []uint64
+}
+
+// 根据预计元素数量n与目标误判率p计算参数，p必须在(0, 1)内
+// m = -n*ln(p) / (ln2)^2, k = m/n * ln2
+func NewBloom(n uint64, p float64) (*Bloom, error) {
+	if !(p > 0 && p < 1) {
+		return nil, ErrInvalidRate
+	}
+	return newBloom(n, p), nil
+}
+
+func newBloom(n uint64, p float64) *Bloom {
+	if n == 0 {
+		n = 1
+	}
+	m := uint64(math.Ceil(-float64(n) * math.Log(p) / (math.Ln2 * math.Ln2)))
+	k := uint32(math.Round(float64(m) / float64(n) * math.Ln2))
+	return NewBloomWithSize(m, k)
+}
+
+// 指定位数m与哈希函数数量k
+func NewBloomWithSize(m uint64, k uint32) *Bloom {
+	if m < 64 {
+		m = 64
+	}
+	if k < 1 {
+		k = 1
+	}
+	return &Bloom{m: m, k: k, bits: make([]uint64, (m+63)/64)}
+}
+
+func (b *Bloom) Add(data []byte) {
+	h1, h2 := hash128(data)
+	for i := uint64(0); i < uint64(b.k); i++ {
+		pos := (h1 + i*h2) % b.m
+		b.bits[pos/64] |= 1 << (pos % 64)
+	}
+	b.count++
+}
+
+func (b *Bloom) AddString(s string) {
+	b.Add([]byte(s))
+}
+
+// 返回false时元素一定不存在，返回true时元素可能存在
+func (b *Bloom) Test(data []byte) bool {
+	h1, h2 := hash128(data)
+	for i := uint64(0); i < uint64(b.k); i++ {
+		pos := (h1 + i*h2) % b.m
+		if b.bits[pos/64]&(1<<(pos%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func (b *Bloom) TestString(s string) bool {
+	return b.Test([]byte(s))
+}
+
+// 添加并返回添加前是否可能存在
+func (b *Bloom) TestAndAdd(data []byte) bool {
+	exists := b.Test(data)
+	b.Add(data)
+	return exists
+}
+
+// 已添加的次数(包含重复元素)
+func (b *Bloom) Count() uint64 {
+	return b.count
+}
+
+func (b *Bloom) Cap() uint64 {
+	return b.m
+}
+
+func (b *Bloom) K() uint32 {
+	return b.k
+}
+
+// 根据已置位的比例估算当前误判率: (置位比例)^k
+func (b *Bloom) FalsePositiveRate() float64 {
+	var set int
+	for _, w := range b.bits {
+		set += bits.OnesCount64(w)
+	}
+	return math.Pow(float64(set)/float64(b.m), float64(b.k))
+}
+
+// 合并参数相同的过滤器，结果等同于向一个过滤器添加两者的全部元素
+func (b *Bloom) Merge(other *Bloom) error {
+	if b.m != other.m || b.k != other.k {
+		return ErrIncompatible
+	}
+	for i := range b.bits {
+		b.bits[i] |= other.bits[i]
+	}
+	b.count += other.count
+	return nil
+}
+
+// 格式(大端): 类型 | 版本 | m(8) | k(4) | count(8) | bits
+func (b *Bloom) MarshalBinary() ([]byte, error) {
+	buf := make([]byte, 2+20+8*len(b.bits))
+	buf[0], buf[1] = typeBloom, version
+	b.encode(buf[2:])
+	return buf, nil
+}
+
+func (b *Bloom) UnmarshalBinary(data []byte) error {
+	data, err := checkHeader(data, typeBloom)
+	if err != nil {
+		return err
+	}
+	_, err = b.decode(data)
+	return err
+}
+
+func (b *Bloom) encodedSize() int {
+	return 20 + 8*len(b.bits)
+}
+
+func (b *Bloom) encode(buf []byte) {
+	binary.BigEndian.PutUint64(buf[0:], b.m)
+	binary.BigEndian.PutUint32(buf[8:], b.k)
+	binary.BigEndian.PutUint64(buf[12:], b.count)
+	for i, w := range b.bits {
+		binary.BigEndian.PutUint64(buf[20+8*i:], w)
+	}
+}
+
+// 返回读取的字节数
+func (b *Bloom) decode(data []byte) (int, error) {
+	if len(data) < 20 {
+		return 0, ErrInvalidData
+	}
+	m := binary.BigEndian.Uint64(data[0:])
+	k := binary.BigEndian.Uint32(data[8:])
+	// 先用数据长度限制m，避免(m+63)/64溢出
+	if m == 0 || k == 0 || m > uint64(len(data)-20)*8 {
+		return 0, ErrInvalidData
+	}
+	words := (m + 63) / 64
+	if uint64(len(data)-20)/8 < words {
+		return 0, ErrInvalidData
+	}
+	b.m, b.k = m, k
+	b.count = binary.BigEndian.Uint64(data[12:])
+	b.bits = make([]uint64, words)
+	for i := range b.bits {
+		b.bits[i] = binary.BigEndian.Uint64(data[20+8*i:])
+	}
+	return b.encodedSize(), nil
+}
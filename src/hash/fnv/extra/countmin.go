@@ -0,0 +1,251 @@
+package extra
+
+import (
+	"container/heap"
+	"encoding/binary"
+	"math"
+	"sort"
+)
+
+// Count-Min Sketch: 估算元素出现次数，只会高估不会低估
+// 以概率 1-delta 保证误差不超过 epsilon * 总数
+type CountMin struct {
+	width  uint32
+	depth  uint32
+	total  uint64
+	counts []uint64
+}
+
+// 计数器总数上限，即最多占用512MiB内存
+const maxCountMinCells = 1 << 26
+
+// width = ceil(e/epsilon), depth = ceil(ln(1/delta))，epsilon与delta必须在(0,1)之间
+func NewCountMin(epsilon, delta float64) (*CountMin, error) {
+	if !(epsilon > 0 && epsilon < 1 && delta > 0 && delta < 1) {
+		return nil, ErrInvalidError
+	}
+	width := math.Ceil(math.E / epsilon)
+	depth := math.Ceil(math.Log(1 / delta))
+	if width*depth > maxCountMinCells {
+		return nil, ErrTooLarge
+	}
+	return NewCountMinWithSize(uint32(width), uint32(depth))
+}
+
+// width*depth超过上限时返回ErrTooLarge
+func NewCountMinWithSize(width, depth uint32) (*CountMin, error) {
+	if width < 1 {
+		width = 1
+	}
+	if depth < 1 {
+		depth = 1
+	}
+	n := uint64(width) * uint64(depth)
+	if n > maxCountMinCells {
+		return nil, ErrTooLarge
+	}
+	return &CountMin{width: width, depth: depth, counts: make([]uint64, n)}, nil
+}
+
+// 每一行使用双重哈希得到的一个位置
+func (c *CountMin) Add(data []byte, count uint64) {
+	h1, h2 := hash128(data)
+	for i := uint64(0); i < uint64(c.depth); i++ {
+		pos := (h1 + i*h2) % uint64(c.width)
+		c.counts[i*uint64(c.width)+pos] += count
+	}
+	c.total += count
+}
+
+func (c *CountMin) AddString(s string, count uint64) {
+	c.Add([]byte(s), count)
+}
+
+// 取各行计数的最小值
+func (c *CountMin) Estimate(data []byte) uint64 {
+	h1, h2 := hash128(data)
+	min := uint64(math.MaxUint64)
+	for i := uint64(0); i < uint64(c.depth); i++ {
+		pos := (h1 + i*h2) % uint64(c.width)
+		if v := c.counts[i*uint64(c.width)+pos]; v < min {
+			min = v
+		}
+	}
+	return min
+}
+
+func (c *CountMin) EstimateString(s string) uint64 {
+	return c.Estimate([]byte(s))
+}
+
+func (c *CountMin) Total() uint64 {
+	return c.total
+}
+
+// 合并同尺寸的sketch，对应计数相加
+func (c *CountMin) Merge(other *CountMin) error {
+	if c.width != other.width || c.depth != other.depth {
+		return ErrIncompatible
+	}
+	for i := range c.counts {
+		c.counts[i] += other.counts[i]
+	}
+	c.total += other.total
+	return nil
+}
+
+// 格式(大端): 类型 | 版本 | width(4) | depth(4) | total(8) | counts
+func (c *CountMin) MarshalBinary() ([]byte, error) {
+	buf := make([]byte, 2+16+8*len(c.counts))
+	buf[0], buf[1] = typeCountMin, version
+	binary.BigEndian.PutUint32(buf[2:], c.width)
+	binary.BigEndian.PutUint32(buf[6:], c.depth)
+	binary.BigEndian.PutUint64(buf[10:], c.total)
+	for i, v := range c.counts {
+		binary.BigEndian.PutUint64(buf[18+8*i:], v)
+	}
+	return buf, nil
+}
+
+func (c *CountMin) UnmarshalBinary(data []byte) error {
+	data, err := checkHeader(data, typeCountMin)
+	if err != nil {
+		return err
+	}
+	if len(data) < 16 {
+		return ErrInvalidData
+	}
+	width := binary.BigEndian.Uint32(data[0:])
+	depth := binary.BigEndian.Uint32(data[4:])
+	// 先与数据长度比较再相乘，避免8*n溢出
+	n := uint64(width) * uint64(depth)
+	if n == 0 || n > maxCountMinCells || n > uint64(len(data)-16)/8 || uint64(len(data)-16) != 8*n {
+		return ErrInvalidData
+	}
+	c.width, c.depth = width, depth
+	c.total = binary.BigEndian.Uint64(data[8:])
+	c.counts = make([]uint64, n)
+	for i := range c.counts {
+		c.counts[i] = binary.BigEndian.Uint64(data[16+8*i:])
+	}
+	return nil
+}
+
+type ItemCount struct {
+	Item  string
+	Count uint64
+}
+
+// 基于Count-Min Sketch的Top-K热点统计，只保存k个候选元素
+type TopK struct {
+	k      int
+	sketch *CountMin
+	heap   itemHeap
+	index  map[string]*heapItem
+}
+
+func NewTopK(k int, epsilon, delta float64) (*TopK, error) {
+	if k < 1 {
+		return nil, ErrInvalidK
+	}
+	sketch, err := NewCountMin(epsilon, delta)
+	if err != nil {
+		return nil, err
+	}
+	return &TopK{k: k, sketch: sketch, index: make(map[string]*heapItem)}, nil
+}
+
+func (t *TopK) Add(item string, count uint64) {
+	t.sketch.AddString(item, count)
+	t.offer(item, t.sketch.EstimateString(item))
+}
+
+// 更新候选: 已在堆中则更新计数，否则在堆未满或超过堆中最小值时替换
+func (t *TopK) offer(item string, est uint64) {
+	if e, ok := t.index[item]; ok {
+		e.count = est
+		heap.Fix(&t.heap, e.index)
+		return
+	}
+	if len(t.heap) < t.k {
+		e := &heapItem{item: item, count: est}
+		heap.Push(&t.heap, e)
+		t.index[item] = e
+		return
+	}
+	if min := t.heap[0]; est > min.count {
+		delete(t.index, min.item)
+		min.item, min.count = item, est
+		t.index[item] = min
+		heap.Fix(&t.heap, 0)
+	}
+}
+
+// 按计数降序返回
+func (t *TopK) Items() []ItemCount {
+	items := make([]ItemCount, len(t.heap))
+	for i, e := range t.heap {
+		items[i] = ItemCount{Item: e.item, Count: e.count}
+	}
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].Count != items[j].Count {
+			return items[i].Count > items[j].Count
+		}
+		return items[i].Item < items[j].Item
+	})
+	return items
+}
+
+func (t *TopK) Sketch() *CountMin {
+	return t.sketch
+}
+
+// 合并sketch后用合并后的计数重新评估双方的候选元素
+func (t *TopK) Merge(other *TopK) error {
+	if err := t.sketch.Merge(other.sketch); err != nil {
+		return err
+	}
+	candidates := make([]string, 0, len(t.heap)+len(other.heap))
+	for _, e := range t.heap {
+		candidates = append(candidates, e.item)
+	}
+	for _, e := range other.heap {
+		candidates = append(candidates, e.item)
+	}
+	t.heap = t.heap[:0]
+	t.index = make(map[string]*heapItem)
+	for _, item := range candidates {
+		t.offer(item, t.sketch.EstimateString(item))
+	}
+	return nil
+}
+
+type heapItem struct {
+	item  string
+	count uint64
+	index int
+}
+
+// 以计数为键的小顶堆
+type itemHeap []*heapItem
+
+func (h itemHeap) Len() int           { return len(h) }
+func (h itemHeap) Less(i, j int) bool { return h[i].count < h[j].count }
+func (h itemHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *itemHeap) Push(x interface{}) {
+	e := x.(*heapItem)
+	e.index = len(*h)
+	*h = append(*h, e)
+}
+
+func (h *itemHeap) Pop() interface{} {
+	old := *h
+	e := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return e
+}
@@ -0,0 +1,64 @@
+package extra
+
+import (
+	"encoding/binary"
+	"errors"
+	"hash/fnv"
+)
+
+var (
+	ErrIncompatible = errors.New("sketch: incompatible parameters")
+	ErrInvalidData  = errors.New("sketch: invalid binary data")
+	ErrInvalidRate  = errors.New("sketch: false positive rate must be between 0 and 1")
+	ErrInvalidError = errors.New("sketch: epsilon and delta must be between 0 and 1")
+	ErrTooLarge     = errors.New("sketch: size is too large")
+	ErrInvalidK     = errors.New("sketch: k must be positive")
+)
+
+// 序列化格式的类型标识
+const (
+	typeBloom byte = iota + 1
+	typeScalableBloom
+	typeCountMin
+	typeHyperLogLog
+)
+
+const version = 1
+
+// 使用128位FNV-1a得到两个64位哈希值，再用双重哈希 g_i = h1 + i*h2 模拟k个独立哈希函数
+// (Kirsch, Mitzenmacher: Less Hashing, Same Performance)
+// FNV对相近的输入雪崩效果较差，两半分别经过fmix64打散
+func hash128(data []byte) (uint64, uint64) {
+	h := fnv.New128a()
+	h.Write(data)
+	var sum [16]byte
+	h.Sum(sum[:0])
+	h1 := mix64(binary.BigEndian.Uint64(sum[:8]))
+	h2 := mix64(binary.BigEndian.Uint64(sum[8:]))
+	// h2为偶数且m为2的幂时会减少可选位置，保证其为奇数
+	return h1, h2 | 1
+}
+
+func hash64(data []byte) uint64 {
+	h := fnv.New64a()
+	h.Write(data)
+	return mix64(h.Sum64())
+}
+
+// MurmurHash3 fmix64
+func mix64(h uint64) uint64 {
+	h ^= h >> 33
+	h *= 0xff51afd7ed558ccd
+	h ^= h >> 33
+	h *= 0xc4ceb9fe1a85ec53
+	h ^= h >> 33
+	return h
+}
+
+// 检查序列化数据的头部: 类型(1字节) | 版本(1字节)
+func checkHeader(data []byte, typ byte) ([]byte, error) {
+	if len(data) < 2 || data[0] != typ || data[1] != version {
+		return nil, ErrInvalidData
+	}
+	return data[2:], nil
+}
@@ -0,0 +1,263 @@
+package extra
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+	"math/bits"
+	"sort"
+)
+
+// 稀疏表示使用的精度
+const sparsePrecision = 25
+
+// HyperLogLog++ 基数估计(Heule et al. 2013)
+//   - 使用64位哈希，不需要对大基数做修正
+//   - 基数较小时使用精度为25的稀疏表示，估计值接近精确
+//   - 稀疏表示占用超过稠密表示时转换为2^p个寄存器
+//
+// 原论文的偏差修正依赖大量经验数据表，这里改用Ertl提出的改进估计方法
+// (New cardinality estimation algorithms for HyperLogLog sketches, 2017)，在全部基数范围内无需偏差修正
+type HyperLogLog struct {
+	p uint8
+	// 稀疏表示: 精度25的寄存器索引 -> rho
+	sparse map[uint32]uint8
+	// 稠密表示
+	registers []uint8
+}
+
+// 精度p取值4~18，标准误差约为 1.04/sqrt(2^p)
+func NewHyperLogLog(p uint8) (*HyperLogLog, error) {
+	if p < 4 || p > 18 {
+		return nil, errors.New("hyperloglog: precision must be between 4 and 18")
+	}
+	return &HyperLogLog{p: p, sparse: make(map[uint32]uint8)}, nil
+}
+
+func (h *HyperLogLog) Add(data []byte) {
+	h.addHash(hash64(data))
+}
+
+func (h *HyperLogLog) AddString(s string) {
+	h.Add([]byte(s))
+}
+
+func (h *HyperLogLog) addHash(x uint64) {
+
+	if h.registers != nil {
+		idx, rho := split64(x, h.p)
+		if rho > h.registers[idx] {
+			h.registers[idx] = rho
+		}
+		return
+	}
+
+	idx, rho := split64(x, sparsePrecision)
+	if rho > h.sparse[uint32(idx)] {
+		h.sparse[uint32(idx)] = rho
+	}
+	// 每个稀疏项按5字节计算，超过稠密表示的大小时转换
+	if len(h.sparse)*5 > 1<<h.p {
+		h.toDense()
+	}
+}
+
+// 高p位为寄存器索引，剩余位中前导0的数量+1为rho
+func split64(x uint64, p uint8) (uint64, uint8) {
+	idx := x >> (64 - p)
+	w := x<<p | 1<<(p-1)
+	return idx, uint8(bits.LeadingZeros64(w)) + 1
+}
+
+// 稀疏项(精度25)转换为精度p的寄存器
+// 精度25索引的低(25-p)位是精度p下剩余位的开头部分，若其中有1则rho由这部分决定
+func (h *HyperLogLog) toDense() {
+	h.registers = make([]uint8, 1<<h.p)
+	shift := sparsePrecision - h.p
+	for idx, rho := range h.sparse {
+		dense := idx >> shift
+		low := idx & (1<<shift - 1)
+		var r uint8
+		if low != 0 {
+			r = uint8(bits.LeadingZeros32(low<<(32-shift))) + 1
+		} else {
+			r = rho + shift
+		}
+		if r > h.registers[dense] {
+			h.registers[dense] = r
+		}
+	}
+	h.sparse = nil
+}
+
+// 估计不同元素的数量
+func (h *HyperLogLog) Count() uint64 {
+	if h.registers == nil {
+		// 稀疏表示时在2^25个寄存器上使用线性计数
+		m := float64(uint64(1) << sparsePrecision)
+		return uint64(math.Round(m * math.Log(m/(m-float64(len(h.sparse))))))
+	}
+	return uint64(math.Round(ertlEstimate(h.registers, 64-int(h.p))))
+}
+
+// Ertl改进估计: 统计取值为k的寄存器个数C[k]
+// z = m*tau(1-C[q+1]/m); 对k = q..1: z = (z + C[k])/2; z += m*sigma(C[0]/m)
+// 估计值 = alpha_inf * m^2 / z，alpha_inf = 1/(2ln2)
+func ertlEstimate(registers []uint8, q int) float64 {
+	m := float64(len(registers))
+	c := make([]float64, q+2)
+	for _, r := range registers {
+		c[r]++
+	}
+	z := m * tau(1-c[q+1]/m)
+	for k := q; k >= 1; k-- {
+		z = 0.5 * (z + c[k])
+	}
+	z += m * sigma(c[0]/m)
+	return m * m / (2 * math.Ln2 * z)
+}
+
+func sigma(x float64) float64 {
+	if x == 1 {
+		return math.Inf(1)
+	}
+	y, z := 1.0, x
+	for {
+		x *= x
+		prev := z
+		z += x * y
+		y += y
+		if z == prev {
+			return z
+		}
+	}
+}
+
+func tau(x float64) float64 {
+	if x == 0 || x == 1 {
+		return 0
+	}
+	y, z := 1.0, 1-x
+	for {
+		x = math.Sqrt(x)
+		prev := z
+		y *= 0.5
+		z -= (1 - x) * (1 - x) * y
+		if z == prev {
+			return z / 3
+		}
+	}
+}
+
+// 合并精度相同的sketch，等同于对两者元素的并集计数
+func (h *HyperLogLog) Merge(other *HyperLogLog) error {
+
+	if h.p != other.p {
+		return ErrIncompatible
+	}
+	if h.registers == nil && other.registers == nil {
+		for idx, rho := range other.sparse {
+			if rho > h.sparse[idx] {
+				h.sparse[idx] = rho
+			}
+		}
+		if len(h.sparse)*5 > 1<<h.p {
+			h.toDense()
+		}
+		return nil
+	}
+
+	if h.registers == nil {
+		h.toDense()
+	}
+	src := other
+	if other.registers == nil {
+		// 不修改other，转换其副本
+		src = &HyperLogLog{p: other.p, sparse: other.sparse}
+		src.toDense()
+	}
+	for i, r := range src.registers {
+		if r > h.registers[i] {
+			h.registers[i] = r
+		}
+	}
+	return nil
+}
+
+// 格式(大端): 类型 | 版本 | p(1) | 表示(1，0稀疏 1稠密) | 数据
+// 稀疏: 数量(4) | [索引(4) | rho(1)]...，按索引排序
+// 稠密: 2^p个寄存器，每个1字节
+func (h *HyperLogLog) MarshalBinary() ([]byte, error) {
+
+	buf := []byte{typeHyperLogLog, version, h.p}
+	if h.registers != nil {
+		buf = append(buf, 1)
+		return append(buf, h.registers...), nil
+	}
+
+	buf = append(buf, 0)
+	keys := make([]uint32, 0, len(h.sparse))
+	for idx := range h.sparse {
+		keys = append(keys, idx)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+
+	var tmp [4]byte
+	binary.BigEndian.PutUint32(tmp[:], uint32(len(keys)))
+	buf = append(buf, tmp[:]...)
+	for _, idx := range keys {
+		binary.BigEndian.PutUint32(tmp[:], idx)
+		buf = append(buf, tmp[:]...)
+		buf = append(buf, h.sparse[idx])
+	}
+	return buf, nil
+}
+
+func (h *HyperLogLog) UnmarshalBinary(data []byte) error {
+
+	data, err := checkHeader(data, typeHyperLogLog)
+	if err != nil {
+		return err
+	}
+	if len(data) < 2 || data[0] < 4 || data[0] > 18 {
+		return ErrInvalidData
+	}
+	p, dense := data[0], data[1]
+	data = data[2:]
+
+	switch dense {
+	case 1:
+		if len(data) != 1<<p {
+			return ErrInvalidData
+		}
+		// 寄存器取值不超过 q+1 = 65-p，否则估计时会越界
+		for _, r := range data {
+			if int(r) > 65-int(p) {
+				return ErrInvalidData
+			}
+		}
+		*h = HyperLogLog{p: p, registers: append([]uint8(nil), data...)}
+	case 0:
+		if len(data) < 4 {
+			return ErrInvalidData
+		}
+		n := binary.BigEndian.Uint32(data)
+		data = data[4:]
+		if uint64(len(data)) != uint64(n)*5 {
+			return ErrInvalidData
+		}
+		sparse := make(map[uint32]uint8, n)
+		for i := uint32(0); i < n; i++ {
+			idx := binary.BigEndian.Uint32(data[5*i:])
+			rho := data[5*i+4]
+			if idx >= 1<<sparsePrecision || rho == 0 || int(rho) > 65-sparsePrecision {
+				return ErrInvalidData
+			}
+			sparse[idx] = rho
+		}
+		*h = HyperLogLog{p: p, sparse: sparse}
+	default:
+		return ErrInvalidData
+	}
+	return nil
+}
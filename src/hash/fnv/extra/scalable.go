@@ -0,0 +1,158 @@
+package extra
+
+import (
+	"encoding/binary"
+	"math"
+)
+
+// 可扩展布隆过滤器(Almeida et al. Scalable Bloom Filters)
+// 元素数量未知时使用: 当前过滤器写满后追加一个容量更大、误判率更低的过滤器，总误判率收敛于 p/(1-r)
+type ScalableBloom struct {
+	// 每个过滤器的容量
+	capacity []uint64
+	filters  []*Bloom
+	p        float64
+	// 误判率收紧比例
+	r float64
+	// 容量增长倍数
+	s uint64
+}
+
+// n为初始容量，p为目标总误判率，必须在(0, 1)内
+func NewScalableBloom(n uint64, p float64) (*ScalableBloom, error) {
+	if !(p > 0 && p < 1) {
+		return nil, ErrInvalidRate
+	}
+	sb := &ScalableBloom{p: p, r: 0.9, s: 2}
+	sb.grow(n, p*(1-sb.r))
+	return sb, nil
+}
+
+func (sb *ScalableBloom) grow(n uint64, p float64) {
+	sb.capacity = append(sb.capacity, n)
+	sb.filters = append(sb.filters, newBloom(n, p))
+}
+
+func (sb *ScalableBloom) Add(data []byte) {
+
+	// 已存在时不重复添加，避免无谓地消耗容量
+	if sb.Test(data) {
+		return
+	}
+	last := len(sb.filters) - 1
+	if sb.filters[last].Count() >= sb.capacity[last] {
+		// 第i个过滤器的误判率为 p0 * r^i
+		p := sb.p * (1 - sb.r) * math.Pow(sb.r, float64(len(sb.filters)))
+		sb.grow(sb.capacity[last]*sb.s, p)
+		last++
+	}
+	sb.filters[last].Add(data)
+}
+
+func (sb *ScalableBloom) AddString(s string) {
+	sb.Add([]byte(s))
+}
+
+func (sb *ScalableBloom) Test(data []byte) bool {
+	for _, f := range sb.filters {
+		if f.Test(data) {
+			return true
+		}
+	}
+	return false
+}
+
+func (sb *ScalableBloom) TestString(s string) bool {
+	return sb.Test([]byte(s))
+}
+
+// 已添加的不同元素数量(近似)
+func (sb *ScalableBloom) Count() uint64 {
+	var n uint64
+	for _, f := range sb.filters {
+		n += f.Count()
+	}
+	return n
+}
+
+func (sb *ScalableBloom) Filters() int {
+	return len(sb.filters)
+}
+
+// 合并: 对应位置参数相同的过滤器按位或，多出的过滤器直接追加
+func (sb *ScalableBloom) Merge(other *ScalableBloom) error {
+	if sb.p != other.p || sb.r != other.r || sb.s != other.s || sb.capacity[0] != other.capacity[0] {
+		return ErrIncompatible
+	}
+	for i, f := range other.filters {
+		if i < len(sb.filters) {
+			if err := sb.filters[i].Merge(f); err != nil {
+				return err
+			}
+			continue
+		}
+		clone := *f
+		clone.bits = append([]uint64(nil), f.bits...)
+		sb.capacity = append(sb.capacity, other.capacity[i])
+		sb.filters = append(sb.filters, &clone)
+	}
+	return nil
+}
+
+// 格式(大端): 类型 | 版本 | p(8) | r(8) | s(8) | 过滤器数量(4) | [容量(8) | 过滤器]...
+func (sb *ScalableBloom) MarshalBinary() ([]byte, error) {
+	size := 2 + 28
+	for _, f := range sb.filters {
+		size += 8 + f.encodedSize()
+	}
+	buf := make([]byte, size)
+	buf[0], buf[1] = typeScalableBloom, version
+	binary.BigEndian.PutUint64(buf[2:], math.Float64bits(sb.p))
+	binary.BigEndian.PutUint64(buf[10:], math.Float64bits(sb.r))
+	binary.BigEndian.PutUint64(buf[18:], sb.s)
+	binary.BigEndian.PutUint32(buf[26:], uint32(len(sb.filters)))
+	off := 30
+	for i, f := range sb.filters {
+		binary.BigEndian.PutUint64(buf[off:], sb.capacity[i])
+		f.encode(buf[off+8:])
+		off += 8 + f.encodedSize()
+	}
+	return buf, nil
+}
+
+func (sb *ScalableBloom) UnmarshalBinary(data []byte) error {
+	data, err := checkHeader(data, typeScalableBloom)
+	if err != nil {
+		return err
+	}
+	if len(data) < 28 {
+		return ErrInvalidData
+	}
+	p := math.Float64frombits(binary.BigEndian.Uint64(data[0:]))
+	r := math.Float64frombits(binary.BigEndian.Uint64(data[8:]))
+	s := binary.BigEndian.Uint64(data[16:])
+	count := binary.BigEndian.Uint32(data[24:])
+	data = data[28:]
+	// 参数用于之后扩容时创建新的过滤器
+	if count == 0 || !(p > 0 && p < 1) || !(r > 0 && r < 1) || s < 1 {
+		return ErrInvalidData
+	}
+
+	var capacity []uint64
+	var filters []*Bloom
+	for i := uint32(0); i < count; i++ {
+		if len(data) < 8 {
+			return ErrInvalidData
+		}
+		capacity = append(capacity, binary.BigEndian.Uint64(data))
+		f := &Bloom{}
+		n, err := f.decode(data[8:])
+		if err != nil {
+			return err
+		}
+		filters = append(filters, f)
+		data = data[8+n:]
+	}
+	*sb = ScalableBloom{capacity: capacity, filters: filters, p: p, r: r, s: s}
+	return nil
+}
@@ -5,12 +5,17 @@ import (
 	"context"
 	"crypto/tls"
 	"fmt"
+	tlsExtra "github.com/zc2638/go-standard/src/crypto/tls/extra"
+	httpExtra "github.com/zc2638/go-standard/src/net/http/extra"
 	"io"
 	"io/ioutil"
 	"log"
 	"net"
 	"net/http"
+	"net/http/httptest"
 	"net/url"
+	"os"
+	"path/filepath"
 	"strings"
 	"time"
 )
@@ -36,6 +41,12 @@ func main() {
 	// 客户端请求
 	exampleClient()
 
+	// 服务间的HMAC请求签名
+	exampleSignature()
+
+	// 预签名的限时下载链接
+	exampleSignedURL()
+
 	// 静态文件服务监听
 	exampleFileServer()
 
@@ -457,9 +468,18 @@ func exampleFileServer() {
 	// StripPrefix会向URL.Path字段中没有给定前缀的请求回复404 page not found
 	fileHandler := http.StripPrefix("/tmpfiles/", fileServer)
 
+	// 只有持有预签名链接的请求才能访问，链接1小时后过期
+	// 签名覆盖完整路径，目录的链接只能查看目录列表，每个文件需要单独签名
+	signer := httpExtra.NewURLSigner([]byte("file-server-secret"))
+	link, err := signer.SignURL("http://localhost:8080/tmpfiles/", time.Hour, 0)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println("share link:", link)
+
 	// 注册HTTP处理器handler和对应的模式pattern（注册到DefaultServeMux）
 	// 如果该模式已经注册有一个处理器，Handle会panic
-	http.Handle("/tmpfiles/", fileHandler)
+	http.Handle("/tmpfiles/", signer.Handler(fileHandler))
 
 	// 监听TCP地址addr，并且会使用handler参数调用Serve函数处理接收到的连接
 	// handler参数一般会设为nil，此时会使用DefaultServeMux
@@ -491,7 +511,12 @@ func exampleServeMux() {
 	// 必须提供证书文件和对应的私钥文件
 	// 如果证书是由权威机构签发的，certFile参数必须是顺序串联的服务端证书和CA证书
 	// 如果srv.Addr为空字符串，会使用":https"
-	if err := http.ListenAndServeTLS(":8443", "cert.pem", "key.pem", mux); err != nil {
+	// 开发环境使用自动生成并缓存的本地证书，首次运行时创建，之后复用直到临近过期
+	certFile, keyFile, err := (&tlsExtra.DevCert{}).Files()
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := http.ListenAndServeTLS(":8443", certFile, keyFile, mux); err != nil {
 		log.Fatal(err)
 	}
 }
@@ -553,12 +578,18 @@ func exampleServer() {
 		log.Fatal(err)
 	}
 
+	// 开发环境使用自动生成并缓存的本地证书
+	certFile, keyFile, err := (&tlsExtra.DevCert{}).Files()
+	if err != nil {
+		log.Fatal(err)
+	}
+
 	// 接手l上的传入连接，为每个连接创建一个新的服务例程。服务程序读取请求，然后调用srv.Handler来回复它们
 	// 此外，如果服务器的TLSConfig.Certificates和TLSConfig.GetCertificate都未被填充，则必须提供包含服务器的证书和匹配私钥的文件
 	// 如果证书由证书颁发机构签署，则certFile应该是服务器的串联证书，任何中间件和CA的证书
 	// 对于HTTP/2支持，在调用Serve之前，应将srv.TLSConfig初始化为提供的侦听器的TLS配置
 	// 如果srv.TLSConfig非零，并且在Config.NextProtos中不包含字符串“h2”，则不启用HTTP/2支持
-	if err := srv.ServeTLS(l, "cert.pem", "key.pem"); err != nil {
+	if err := srv.ServeTLS(l, certFile, keyFile); err != nil {
 		log.Fatal(err)
 	}
 
@@ -574,7 +605,7 @@ func exampleServer() {
 	// 如果证书是由权威机构签发的，certFile参数必须是顺序串联的服务端证书和CA证书
 	// 如果srv.Addr为空字符串，会使用":https"
 	// 效果等同于http.ListenAndServeTLS
-	if err := srv.ListenAndServeTLS("cert.pem", "key.pem"); err != nil {
+	if err := srv.ListenAndServeTLS(certFile, keyFile); err != nil {
 		log.Fatal(err)
 	}
 
@@ -692,4 +723,140 @@ func exampleTransport() {
 
 	// 高层次的HTTP客户端支持（如管理cookie和重定向）请参见Get、Post等函数和Client类型
 	transport.RoundTrip(req)
-}
\ No newline at end of file
+}
+
+func exampleSignature() {
+
+	secrets := map[string][]byte{"order-service": []byte("s3cr3t")}
+	verifier := &httpExtra.Verifier{
+		Keys: func(keyID string) ([]byte, error) {
+			secret, ok := secrets[keyID]
+			if !ok {
+				return nil, httpExtra.ErrUnknownKey
+			}
+			return secret, nil
+		},
+		MaxSkew: time.Minute,
+		Nonces:  httpExtra.NewMemoryNonceCache(),
+	}
+	handler := verifier.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		keyID, _ := httpExtra.KeyIDFromContext(r.Context())
+		body, _ := ioutil.ReadAll(r.Body)
+		fmt.Fprintf(w, "hello %s, got %q", keyID, body)
+	}))
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	signer := &httpExtra.Signer{
+		KeyID:   "order-service",
+		Secret:  secrets["order-service"],
+		Headers: []string{"Content-Type"},
+	}
+	client := &http.Client{Transport: &httpExtra.Transport{Signer: signer}}
+
+	do := func(name string, req *http.Request, c *http.Client) {
+		res, err := c.Do(req)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer res.Body.Close()
+		body, _ := ioutil.ReadAll(res.Body)
+		fmt.Printf("%s: %d %s\n", name, res.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	// 签名的请求
+	req, _ := http.NewRequest("POST", server.URL+"/orders?b=2&a=1", strings.NewReader(`{"id":1}`))
+	req.Header.Set("Content-Type", "application/json")
+	do("signed", req, client)
+
+	// 未签名的请求
+	req, _ = http.NewRequest("GET", server.URL+"/orders", nil)
+	do("unsigned", req, http.DefaultClient)
+
+	// 手动签名后篡改查询参数
+	req, _ = http.NewRequest("GET", server.URL+"/orders?a=1", nil)
+	if err := signer.Sign(req); err != nil {
+		log.Fatal(err)
+	}
+	req.URL.RawQuery = "a=2"
+	do("tampered", req, http.DefaultClient)
+
+	// 重放同一个请求
+	req, _ = http.NewRequest("GET", server.URL+"/orders", nil)
+	if err := signer.Sign(req); err != nil {
+		log.Fatal(err)
+	}
+	do("first", req, http.DefaultClient)
+	do("replay", req, http.DefaultClient)
+
+	// 客户端时钟偏差过大
+	skewed := &httpExtra.Signer{
+		KeyID:  signer.KeyID,
+		Secret: signer.Secret,
+		Now:    func() time.Time { return time.Now().Add(-10 * time.Minute) },
+	}
+	req, _ = http.NewRequest("GET", server.URL+"/orders", nil)
+	do("skewed", req, &http.Client{Transport: &httpExtra.Transport{Signer: skewed}})
+}
+
+func exampleSignedURL() {
+
+	dir, err := ioutil.TempDir("", "signed-url")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err := ioutil.WriteFile(filepath.Join(dir, "report.txt"), []byte("quarterly report"), 0644); err != nil {
+		log.Fatal(err)
+	}
+
+	signer := httpExtra.NewURLSigner([]byte("file-server-secret"))
+	mux := http.NewServeMux()
+	mux.Handle("/files/", signer.Handler(http.StripPrefix("/files/", http.FileServer(http.Dir(dir)))))
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	get := func(name, link string, header ...string) {
+		req, err := http.NewRequest(http.MethodGet, link, nil)
+		if err != nil {
+			log.Fatal(err)
+		}
+		for i := 0; i+1 < len(header); i += 2 {
+			req.Header.Set(header[i], header[i+1])
+		}
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer res.Body.Close()
+		body, _ := ioutil.ReadAll(res.Body)
+		fmt.Printf("%s: %d %s\n", name, res.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	// 最多下载2次的链接
+	link, err := signer.SignURL(server.URL+"/files/report.txt", 10*time.Minute, 2)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(link)
+	// 分段请求返回206，不计入下载次数
+	get("range", link, "Range", "bytes=0-8")
+	get("download 1", link)
+	get("download 2", link)
+	get("download 3", link)
+	fmt.Println("downloads:", signer.Downloads(link))
+
+	// 文件不存在时返回404，同样不计数
+	missing, _ := signer.SignURL(server.URL+"/files/missing.txt", 10*time.Minute, 1)
+	get("missing", missing)
+	fmt.Println("downloads:", signer.Downloads(missing))
+
+	// 篡改路径或下载次数
+	get("tampered path", strings.Replace(link, "report.txt", "secret.txt", 1))
+	get("tampered limit", strings.Replace(link, "downloads=2", "downloads=9", 1))
+	get("unsigned", server.URL+"/files/report.txt")
+
+	// 已过期的链接
+	expired, _ := signer.SignURL(server.URL+"/files/report.txt", -time.Second, 0)
+	get("expired", expired)
+}
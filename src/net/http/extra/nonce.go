@@ -0,0 +1,63 @@
+package extra
+
+import (
+	"sync"
+	"time"
+)
+
+// 记录已使用的nonce，用于拒绝重放请求
+type NonceCache interface {
+	// nonce已存在时返回true，否则记录并保存到expiry
+	Seen(nonce string, expiry time.Time) bool
+}
+
+// 内存中的nonce缓存，零值可直接使用，多实例部署时需要替换为Redis等共享存储
+type MemoryNonceCache struct {
+	mu      sync.Mutex
+	entries map[string]time.Time
+	// 上次清理过期项的时间
+	lastSweep time.Time
+	// 返回当前时间，为nil时使用time.Now
+	Now func() time.Time
+}
+
+func NewMemoryNonceCache() *MemoryNonceCache {
+	return &MemoryNonceCache{entries: make(map[string]time.Time)}
+}
+
+func (c *MemoryNonceCache) Seen(nonce string, expiry time.Time) bool {
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// 零值的MemoryNonceCache在首次使用时创建map
+	if c.entries == nil {
+		c.entries = make(map[string]time.Time)
+	}
+
+	now := time.Now()
+	if c.Now != nil {
+		now = c.Now()
+	}
+	// 每分钟最多清理一次过期项
+	if now.Sub(c.lastSweep) > time.Minute {
+		for k, exp := range c.entries {
+			if now.After(exp) {
+				delete(c.entries, k)
+			}
+		}
+		c.lastSweep = now
+	}
+
+	if exp, ok := c.entries[nonce]; ok && !now.After(exp) {
+		return true
+	}
+	c.entries[nonce] = expiry
+	return false
+}
+
+func (c *MemoryNonceCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.entries)
+}
@@ -0,0 +1,249 @@
+package extra
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// 预签名链接使用的查询参数
+const (
+	ParamExpires   = "expires"
+	ParamDownloads = "downloads"
+	ParamSignature = "signature"
+)
+
+var (
+	ErrURLSignature    = errors.New("presign: invalid signature")
+	ErrURLExpired      = errors.New("presign: link has expired")
+	ErrDownloadLimited = errors.New("presign: download limit reached")
+)
+
+// 生成和校验有效期受限的下载链接，签名覆盖路径、过期时间和下载次数上限
+//
+//	/files/report.pdf?expires=1700000000&downloads=3&signature=base64url(HMAC-SHA256(secret, path \n expires \n downloads))
+type URLSigner struct {
+	Secret []byte
+	// 返回当前时间，为nil时使用time.Now
+	Now func() time.Time
+
+	mu sync.Mutex
+	// 签名 -> 已下载次数，只保存设置了次数上限的链接
+	downloads map[string]*downloadCount
+	// 上次清理过期计数的时间
+	lastSweep time.Time
+}
+
+type downloadCount struct {
+	count   int
+	expires time.Time
+}
+
+func NewURLSigner(secret []byte) *URLSigner {
+	return &URLSigner{Secret: secret, downloads: make(map[string]*downloadCount)}
+}
+
+// 为rawurl生成在ttl后过期的链接，maxDownloads大于0时限制下载次数
+func (s *URLSigner) SignURL(rawurl string, ttl time.Duration, maxDownloads int) (string, error) {
+
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return "", err
+	}
+	expires := strconv.FormatInt(s.now().Add(ttl).Unix(), 10)
+	downloads := ""
+	if maxDownloads > 0 {
+		downloads = strconv.Itoa(maxDownloads)
+	}
+
+	query := u.Query()
+	query.Set(ParamExpires, expires)
+	if downloads != "" {
+		query.Set(ParamDownloads, downloads)
+	}
+	query.Set(ParamSignature, s.sign(u.Path, expires, downloads))
+	u.RawQuery = query.Encode()
+	return u.String(), nil
+}
+
+// 校验签名和有效期，不计入下载次数
+func (s *URLSigner) Verify(u *url.URL) error {
+	_, _, err := s.verify(u)
+	return err
+}
+
+func (s *URLSigner) verify(u *url.URL) (time.Time, int, error) {
+
+	query := u.Query()
+	expires, downloads := query.Get(ParamExpires), query.Get(ParamDownloads)
+	signature, err := base64.RawURLEncoding.DecodeString(query.Get(ParamSignature))
+	if err != nil || expires == "" {
+		return time.Time{}, 0, ErrURLSignature
+	}
+	expected, _ := base64.RawURLEncoding.DecodeString(s.sign(u.Path, expires, downloads))
+	if !hmac.Equal(signature, expected) {
+		return time.Time{}, 0, ErrURLSignature
+	}
+
+	// 签名通过后参数一定是SignURL生成的
+	sec, _ := strconv.ParseInt(expires, 10, 64)
+	expiry := time.Unix(sec, 0)
+	if !s.now().Before(expiry) {
+		return time.Time{}, 0, ErrURLExpired
+	}
+	limit := 0
+	if downloads != "" {
+		limit, _ = strconv.Atoi(downloads)
+	}
+	return expiry, limit, nil
+}
+
+// 包装文件服务，如 s.Handler(http.StripPrefix("/files/", http.FileServer(dir)))
+// 签名覆盖的是去除前缀前的完整路径；签名无效或过期时返回403，下载次数用尽时同样返回403
+// 只有开始返回完整内容(200)的GET请求计为一次下载，HEAD、Range分段请求(206)及错误响应不计数
+func (s *URLSigner) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+		expiry, limit, err := s.verify(r.URL)
+		signature := r.URL.Query().Get(ParamSignature)
+		if err == nil && limit > 0 && s.count(signature) >= limit {
+			err = ErrDownloadLimited
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+		if limit > 0 && r.Method == http.MethodGet {
+			// 在next写出响应头时计数，并发请求超出次数时改为返回403
+			w = &downloadWriter{ResponseWriter: w, signer: s, signature: signature, expiry: expiry, limit: limit}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// 已下载次数，链接未使用或未限制次数时返回0
+func (s *URLSigner) Downloads(signedURL string) int {
+	u, err := url.Parse(signedURL)
+	if err != nil {
+		return 0
+	}
+	return s.count(u.Query().Get(ParamSignature))
+}
+
+func (s *URLSigner) count(signature string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if d, ok := s.downloads[signature]; ok {
+		return d.count
+	}
+	return 0
+}
+
+func (s *URLSigner) take(signature string, expiry time.Time, limit int) error {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.downloads == nil {
+		s.downloads = make(map[string]*downloadCount)
+	}
+	// 过期的链接已无法通过校验，每分钟最多清理一次其计数
+	now := s.now()
+	if now.Sub(s.lastSweep) > time.Minute {
+		for k, d := range s.downloads {
+			if !now.Before(d.expires) {
+				delete(s.downloads, k)
+			}
+		}
+		s.lastSweep = now
+	}
+
+	d, ok := s.downloads[signature]
+	if !ok {
+		d = &downloadCount{expires: expiry}
+		s.downloads[signature] = d
+	}
+	if d.count >= limit {
+		return ErrDownloadLimited
+	}
+	d.count++
+	return nil
+}
+
+// 在响应状态确定时计数的ResponseWriter
+type downloadWriter struct {
+	http.ResponseWriter
+	signer    *URLSigner
+	signature string
+	expiry    time.Time
+	limit     int
+	// 已写出响应头
+	wroteHeader bool
+	// 次数已被并发请求用尽，丢弃next写出的内容
+	rejected bool
+}
+
+func (w *downloadWriter) WriteHeader(code int) {
+
+	if w.wroteHeader {
+		if !w.rejected {
+			w.ResponseWriter.WriteHeader(code)
+		}
+		return
+	}
+	w.wroteHeader = true
+
+	if code == http.StatusOK {
+		if err := w.signer.take(w.signature, w.expiry, w.limit); err != nil {
+			w.rejected = true
+			// 清除next设置的Content-Length等响应头
+			header := w.Header()
+			for k := range header {
+				delete(header, k)
+			}
+			http.Error(w.ResponseWriter, err.Error(), http.StatusForbidden)
+			return
+		}
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *downloadWriter) Write(b []byte) (int, error) {
+
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.rejected {
+		return 0, ErrDownloadLimited
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// 供http.ResponseController访问底层的ResponseWriter
+func (w *downloadWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func (s *URLSigner) sign(path, expires, downloads string) string {
+	h := hmac.New(sha256.New, s.Secret)
+	h.Write([]byte(path + "\n" + expires + "\n" + downloads))
+	return base64.RawURLEncoding.EncodeToString(h.Sum(nil))
+}
+
+func (s *URLSigner) now() time.Time {
+	if s.Now != nil {
+		return s.Now()
+	}
+	return time.Now()
+}
@@ -0,0 +1,363 @@
+package extra
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// 签名相关的请求头
+const (
+	HeaderAuthorization = "Authorization"
+	HeaderDate          = "X-Signature-Date"
+	HeaderNonce         = "X-Signature-Nonce"
+	HeaderContentSHA256 = "X-Content-Sha256"
+
+	SignatureAlgorithm = "HMAC-SHA256"
+	// 时间格式与AWS SigV4一致
+	TimeFormat = "20060102T150405Z"
+)
+
+var (
+	ErrMissingSignature  = errors.New("signature: missing or malformed authorization header")
+	ErrUnknownKey        = errors.New("signature: unknown key id")
+	ErrInvalidSignature  = errors.New("signature: signature does not match")
+	ErrRequestExpired    = errors.New("signature: request date is outside the allowed clock skew")
+	ErrReplayedRequest   = errors.New("signature: nonce has already been used")
+	ErrBodyHashMismatch  = errors.New("signature: body hash does not match")
+	ErrMissingSignedPart = errors.New("signature: required header is not signed")
+)
+
+// 这些请求头必须参与签名
+var requiredHeaders = []string{"host", strings.ToLower(HeaderDate), strings.ToLower(HeaderNonce), strings.ToLower(HeaderContentSHA256)}
+
+// 对请求签名，参考AWS SigV4:
+//
+//	CanonicalRequest = Method \n CanonicalPath \n CanonicalQuery \n CanonicalHeaders \n SignedHeaders \n HexSHA256(Body)
+//	StringToSign     = "HMAC-SHA256" \n Date \n HexSHA256(CanonicalRequest)
+//	SigningKey       = HMAC(HMAC("HMAC-SHA256" + Secret, yyyymmdd), "request")
+//	Signature        = Hex(HMAC(SigningKey, StringToSign))
+type Signer struct {
+	KeyID  string
+	Secret []byte
+	// 除必需的请求头外额外参与签名的请求头，如Content-Type
+	Headers []string
+	// 返回当前时间，为nil时使用time.Now
+	Now func() time.Time
+}
+
+// 为请求添加签名相关的请求头，会读取并恢复请求体
+func (s *Signer) Sign(req *http.Request) error {
+
+	body, err := readBody(req)
+	if err != nil {
+		return err
+	}
+	now := time.Now
+	if s.Now != nil {
+		now = s.Now
+	}
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+
+	date := now().UTC().Format(TimeFormat)
+	req.Header.Set(HeaderDate, date)
+	req.Header.Set(HeaderNonce, hex.EncodeToString(nonce))
+	req.Header.Set(HeaderContentSHA256, hexSHA256(body))
+
+	signed := append([]string{}, requiredHeaders...)
+	for _, h := range s.Headers {
+		signed = append(signed, strings.ToLower(h))
+	}
+	signed = uniqueSorted(signed)
+
+	signature := computeSignature(s.Secret, date, canonicalRequest(req, signed, hexSHA256(body)))
+	req.Header.Set(HeaderAuthorization, SignatureAlgorithm+
+		" KeyId="+s.KeyID+
+		", SignedHeaders="+strings.Join(signed, ";")+
+		", Signature="+signature)
+	return nil
+}
+
+// 自动为请求签名的http.RoundTripper
+type Transport struct {
+	Signer *Signer
+	// 为nil时使用http.DefaultTransport
+	Base http.RoundTripper
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+
+	// RoundTripper不应修改原请求，复制一份后签名
+	r := new(http.Request)
+	*r = *req
+	r.Header = make(http.Header, len(req.Header))
+	for k, v := range req.Header {
+		r.Header[k] = append([]string(nil), v...)
+	}
+	if err := t.Signer.Sign(r); err != nil {
+		return nil, err
+	}
+
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return base.RoundTrip(r)
+}
+
+// 根据KeyID返回密钥，未知时返回ErrUnknownKey
+type KeyStore func(keyID string) ([]byte, error)
+
+// 校验请求签名
+type Verifier struct {
+	Keys KeyStore
+	// 允许的时钟偏差，为0时使用5分钟
+	MaxSkew time.Duration
+	// 为nil时不做重放检查
+	Nonces NonceCache
+	// 请求体的最大字节数，为0时使用10MB
+	MaxBodySize int64
+	// 返回当前时间，为nil时使用time.Now
+	Now func() time.Time
+}
+
+// 校验成功时返回签名者的KeyID
+func (v *Verifier) Verify(req *http.Request) (string, error) {
+
+	keyID, signed, signature, err := parseAuthorization(req.Header.Get(HeaderAuthorization))
+	if err != nil {
+		return "", err
+	}
+	for _, h := range requiredHeaders {
+		if !contains(signed, h) {
+			return "", ErrMissingSignedPart
+		}
+	}
+	secret, err := v.Keys(keyID)
+	if err != nil {
+		return "", err
+	}
+
+	// 时间窗口
+	date := req.Header.Get(HeaderDate)
+	t, err := time.Parse(TimeFormat, date)
+	if err != nil {
+		return "", ErrMissingSignature
+	}
+	now := time.Now()
+	if v.Now != nil {
+		now = v.Now()
+	}
+	skew := v.MaxSkew
+	if skew == 0 {
+		skew = 5 * time.Minute
+	}
+	if t.Before(now.Add(-skew)) || t.After(now.Add(skew)) {
+		return "", ErrRequestExpired
+	}
+
+	// 请求体摘要
+	maxBody := v.MaxBodySize
+	if maxBody == 0 {
+		maxBody = 10 << 20
+	}
+	if req.Body != nil {
+		req.Body = ioutil.NopCloser(io.LimitReader(req.Body, maxBody))
+	}
+	body, err := readBody(req)
+	if err != nil {
+		return "", err
+	}
+	bodyHash := hexSHA256(body)
+	if !hmac.Equal([]byte(bodyHash), []byte(req.Header.Get(HeaderContentSHA256))) {
+		return "", ErrBodyHashMismatch
+	}
+
+	expected := computeSignature(secret, date, canonicalRequest(req, signed, bodyHash))
+	if !hmac.Equal([]byte(expected), []byte(signature)) {
+		return "", ErrInvalidSignature
+	}
+
+	// 签名通过后再记录nonce，避免伪造请求占用nonce；nonce只需保存到时间窗口结束
+	if v.Nonces != nil && v.Nonces.Seen(keyID+":"+req.Header.Get(HeaderNonce), t.Add(skew)) {
+		return "", ErrReplayedRequest
+	}
+	return keyID, nil
+}
+
+type contextKey struct{}
+
+// 返回校验通过的请求的KeyID
+func KeyIDFromContext(ctx context.Context) (string, bool) {
+	keyID, ok := ctx.Value(contextKey{}).(string)
+	return keyID, ok
+}
+
+// 校验失败时返回401
+func (v *Verifier) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		keyID, err := v.Verify(r)
+		if err != nil {
+			w.Header().Set("WWW-Authenticate", SignatureAlgorithm)
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), contextKey{}, keyID)))
+	})
+}
+
+func canonicalRequest(req *http.Request, signed []string, bodyHash string) string {
+
+	var b strings.Builder
+	b.WriteString(req.Method + "\n")
+	b.WriteString(canonicalPath(req.URL) + "\n")
+	b.WriteString(canonicalQuery(req.URL.Query()) + "\n")
+	for _, h := range signed {
+		var value string
+		if h == "host" {
+			value = req.Host
+			if value == "" {
+				value = req.URL.Host
+			}
+		} else {
+			// 同名的多个值以逗号连接，并压缩值中的连续空白
+			value = strings.Join(req.Header[http.CanonicalHeaderKey(h)], ",")
+		}
+		b.WriteString(h + ":" + strings.Join(strings.Fields(value), " ") + "\n")
+	}
+	b.WriteString("\n" + strings.Join(signed, ";") + "\n")
+	b.WriteString(bodyHash)
+	return b.String()
+}
+
+func canonicalPath(u *url.URL) string {
+	p := u.EscapedPath()
+	if p == "" {
+		return "/"
+	}
+	return p
+}
+
+// 按键排序，同一个键的多个值按值排序，键和值均按RFC 3986编码
+func canonicalQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var parts []string
+	for _, k := range keys {
+		values := append([]string(nil), query[k]...)
+		sort.Strings(values)
+		for _, v := range values {
+			parts = append(parts, escape(k)+"="+escape(v))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// url.QueryEscape会将空格编码为+，这里统一编码为%20
+func escape(s string) string {
+	return strings.Replace(url.QueryEscape(s), "+", "%20", -1)
+}
+
+func computeSignature(secret []byte, date, canonical string) string {
+	stringToSign := SignatureAlgorithm + "\n" + date + "\n" + hexSHA256([]byte(canonical))
+	// 按日期派生签名密钥，泄露的派生密钥只在当天有效
+	key := hmacSHA256(append([]byte(SignatureAlgorithm), secret...), []byte(date[:8]))
+	key = hmacSHA256(key, []byte("request"))
+	return hex.EncodeToString(hmacSHA256(key, []byte(stringToSign)))
+}
+
+// Authorization: HMAC-SHA256 KeyId=xxx, SignedHeaders=a;b;c, Signature=hex
+func parseAuthorization(header string) (keyID string, signed []string, signature string, err error) {
+
+	if !strings.HasPrefix(header, SignatureAlgorithm+" ") {
+		return "", nil, "", ErrMissingSignature
+	}
+	for _, part := range strings.Split(header[len(SignatureAlgorithm)+1:], ",") {
+		kv := strings.SplitN(strings.TrimSpace(part), "=", 2)
+		if len(kv) != 2 {
+			return "", nil, "", ErrMissingSignature
+		}
+		switch kv[0] {
+		case "KeyId":
+			keyID = kv[1]
+		case "SignedHeaders":
+			signed = strings.Split(kv[1], ";")
+		case "Signature":
+			signature = kv[1]
+		}
+	}
+	if keyID == "" || len(signed) == 0 || signature == "" {
+		return "", nil, "", ErrMissingSignature
+	}
+	// 签名的请求头必须已按规范排序
+	sorted := uniqueSorted(append([]string(nil), signed...))
+	if strings.Join(sorted, ";") != strings.Join(signed, ";") {
+		return "", nil, "", ErrMissingSignature
+	}
+	return keyID, signed, signature, nil
+}
+
+// 读取请求体并替换为可以再次读取的副本
+func readBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	body, err := ioutil.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return ioutil.NopCloser(bytes.NewReader(body)), nil
+	}
+	return body, nil
+}
+
+func hexSHA256(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key, data []byte) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write(data)
+	return h.Sum(nil)
+}
+
+func uniqueSorted(list []string) []string {
+	sort.Strings(list)
+	out := list[:0]
+	for i, s := range list {
+		if i == 0 || s != list[i-1] {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}